@@ -1,8 +1,20 @@
 package app
 
+import (
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
+)
+
 type (
 	// StoreConfig is a config for store db.
 	StoreConfig struct {
+		// Cache is a memory budget shared by the store caches, it's injected by the caller.
+		// If nil, nothing is cached.
+		Cache *sharedcache.Pool `toml:"-"`
+
+		// Minimal size of Receipts values to store them compressed, 0 disables compression.
+		CompressionThreshold int
+
+		// NOTE: fields for config-file back compatibility
 		// Cache size for Receipts.
 		ReceiptsCacheSize int
 		// Cache size for Stakers.
@@ -15,17 +27,14 @@ type (
 // DefaultStoreConfig for product.
 func DefaultStoreConfig() StoreConfig {
 	return StoreConfig{
-		ReceiptsCacheSize:   100,
-		DelegatorsCacheSize: 4000,
-		StakersCacheSize:    4000,
+		CompressionThreshold: 256,
 	}
 }
 
 // LiteStoreConfig is for tests or inmemory.
 func LiteStoreConfig() StoreConfig {
 	return StoreConfig{
-		ReceiptsCacheSize:   100,
-		DelegatorsCacheSize: 400,
-		StakersCacheSize:    400,
+		Cache:                sharedcache.TestPool(),
+		CompressionThreshold: 256,
 	}
 }
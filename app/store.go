@@ -11,20 +11,24 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rlp"
-	"github.com/hashicorp/golang-lru"
 
 	"github.com/Fantom-foundation/go-lachesis/common/bigendian"
+	"github.com/Fantom-foundation/go-lachesis/inter/sfctype"
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/compressed"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/metered"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/nokeyiserr"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 	"github.com/Fantom-foundation/go-lachesis/logger"
 	"github.com/Fantom-foundation/go-lachesis/topicsdb"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 )
 
 // Store is a node persistent storage working over physical key-value database.
 type Store struct {
+	dbs *flushable.SyncedPool
 	cfg StoreConfig
 
 	mainDb kvdb.KeyValueStore
@@ -62,17 +66,23 @@ type Store struct {
 		StakerOldRewards           kvdb.KeyValueStore `table:"7"`
 		StakerDelegatorsOldRewards kvdb.KeyValueStore `table:"8"`
 
+		Migrations kvdb.KeyValueStore `table:"@"`
+
+		// EVM tables
+		EvmRaw     kvdb.KeyValueStore `table:"M"`
+		EvmLogsRaw kvdb.KeyValueStore `table:"L"`
+
 		Evm      ethdb.Database
 		EvmState state.Database
 		EvmLogs  *topicsdb.Index
 	}
 
 	cache struct {
-		Receipts      *lru.Cache `cache:"-"` // store by value
-		Validators    *lru.Cache `cache:"-"` // store by pointer
-		Stakers       *lru.Cache `cache:"-"` // store by pointer
-		Delegators    *lru.Cache `cache:"-"` // store by pointer
-		BlockDowntime *lru.Cache `cache:"-"` // store by pointer
+		Receipts      *sharedcache.Cache `cache:"-"` // store by value
+		Validators    *sharedcache.Cache `cache:"-"` // store by pointer
+		Stakers       *sharedcache.Cache `cache:"-"` // store by pointer
+		Delegators    *sharedcache.Cache `cache:"-"` // store by pointer
+		BlockDowntime *sharedcache.Cache `cache:"-"` // store by pointer
 	}
 
 	mutex struct {
@@ -94,17 +104,21 @@ func NewMemStore() *Store {
 // NewStore creates store over key-value db.
 func NewStore(dbs *flushable.SyncedPool, cfg StoreConfig) *Store {
 	s := &Store{
+		dbs:      dbs,
 		cfg:      cfg,
-		mainDb:   dbs.GetDb("gossip-main"), // TODO: use "app-main" when database versioning is ready
+		mainDb:   dbs.GetDb("gossip-main"), // shared with gossip store, table IDs of the stores must differ
 		Instance: logger.MakeInstance(),
 	}
 
 	table.MigrateTables(&s.table, s.mainDb)
+	metered.WrapTables(&s.table, "app")
+	// the table values are RLP lists, so they never collide with compression headers
+	s.table.Receipts = compressed.Wrap(s.table.Receipts, s.cfg.CompressionThreshold)
 
-	evmTable := nokeyiserr.Wrap(table.New(s.mainDb, []byte("M"))) // ETH expects that "not found" is an error
+	evmTable := nokeyiserr.Wrap(s.table.EvmRaw) // ETH expects that "not found" is an error
 	s.table.Evm = rawdb.NewDatabase(evmTable)
 	s.table.EvmState = state.NewDatabaseWithCache(s.table.Evm, 16)
-	s.table.EvmLogs = topicsdb.New(table.New(s.mainDb, []byte("L")))
+	s.table.EvmLogs = topicsdb.New(s.table.EvmLogsRaw)
 
 	s.initCache()
 
@@ -112,11 +126,22 @@ func NewStore(dbs *flushable.SyncedPool, cfg StoreConfig) *Store {
 }
 
 func (s *Store) initCache() {
-	s.cache.Receipts = s.makeCache(s.cfg.ReceiptsCacheSize)
-	s.cache.Validators = s.makeCache(2)
-	s.cache.Stakers = s.makeCache(s.cfg.StakersCacheSize)
-	s.cache.Delegators = s.makeCache(s.cfg.DelegatorsCacheSize)
-	s.cache.BlockDowntime = s.makeCache(256)
+	s.cache.Receipts = s.makeCache("app/receipts", func(v interface{}) int {
+		size := 0
+		for _, r := range v.([]*receiptRLP) {
+			size += 512 // including bloom
+			for _, l := range r.Receipt.Logs {
+				size += 128 + len(l.Topics)*32 + len(l.Data)
+			}
+		}
+		return size
+	})
+	s.cache.Validators = s.makeCache("app/validators", func(v interface{}) int {
+		return 128 * cap(v.([]sfctype.SfcStakerAndID))
+	})
+	s.cache.Stakers = s.makeCache("app/stakers", sharedcache.Fixed(256))
+	s.cache.Delegators = s.makeCache("app/delegators", sharedcache.Fixed(256))
+	s.cache.BlockDowntime = s.makeCache("app/block_downtime", sharedcache.Fixed(16))
 }
 
 // Close leaves underlying database.
@@ -126,43 +151,36 @@ func (s *Store) Close() {
 	}
 
 	table.MigrateTables(&s.table, nil)
+	s.purgeCaches()
 	table.MigrateCaches(&s.cache, setnil)
 
 	s.mainDb.Close()
 }
 
-// Commit changes.
+// Commit flushes the trie into the main DB, and then flushes all the DBs of the pool.
+// The pool is shared with other stores, so their changes are committed under the same flush ID.
 func (s *Store) Commit(flushID []byte, immediately bool) error {
-	// TODO: enable s.dbs (uncomment all the code) when database versioning is ready
-
 	if flushID == nil {
 		// if flushId not specified, use current time
 		buf := bytes.NewBuffer(nil)
 		buf.Write([]byte{0xbe, 0xee})                                    // 0xbeee eyecatcher that flushed time
 		buf.Write(bigendian.Int64ToBytes(uint64(time.Now().UnixNano()))) // current UnixNano time
-		/*
-			flushID = buf.Bytes()
-		*/
+		flushID = buf.Bytes()
 	}
 
-	/*
-		if !immediately && !s.dbs.IsFlushNeeded() {
-			return nil
-		}
-	*/
+	if !immediately && !s.dbs.IsFlushNeeded() {
+		return nil
+	}
 
 	// Flush trie on the DB
 	err := s.table.EvmState.TrieDB().Cap(0)
 	if err != nil {
 		s.Log.Error("Failed to flush trie DB into main DB", "err", err)
+		return err
 	}
-	return err
 
 	// Flush the DBs
-	/*
-		return s.dbs.Flush(flushID)
-	*/
-
+	return s.dbs.Flush(flushID)
 }
 
 // StateDB returns state database.
@@ -174,6 +192,12 @@ func (s *Store) StateDB(from common.Hash) *state.StateDB {
 	return db
 }
 
+// HasState returns true if state with the root is stored.
+func (s *Store) HasState(root common.Hash) bool {
+	_, err := s.table.EvmState.OpenTrie(root)
+	return err == nil
+}
+
 // StateDB returns state database.
 func (s *Store) IndexLogs(recs ...*types.Log) {
 	err := s.table.EvmLogs.Push(recs...)
@@ -246,15 +270,20 @@ func (s *Store) dropTable(it ethdb.Iterator, t kvdb.KeyValueStore) {
 	}
 }
 
-func (s *Store) makeCache(size int) *lru.Cache {
-	if size <= 0 {
-		return nil
-	}
+// purgeCaches releases the shared cache budget.
+func (s *Store) purgeCaches() {
+	s.cache.Receipts.Purge()
+	s.cache.Validators.Purge()
+	s.cache.Stakers.Purge()
+	s.cache.Delegators.Purge()
+	s.cache.BlockDowntime.Purge()
+}
 
-	cache, err := lru.New(size)
-	if err != nil {
-		s.Log.Crit("Error create LRU cache", "err", err)
-		return nil
+func (s *Store) makeCache(name string, weight sharedcache.Weigher) *sharedcache.Cache {
+	if s.cfg.Cache == nil {
+		// caching is disabled
+		return sharedcache.New(0).NewCache(name, weight)
 	}
-	return cache
+
+	return s.cfg.Cache.NewCache(name, weight)
 }
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/utils/migration"
+)
+
+// Migrations returns the manager of store schema migrations.
+// firstBlock returns the first block of the chain if it's known, it's used to upgrade legacy databases.
+// NOTE: append new migrations to the end of the list only.
+func (s *Store) Migrations(firstBlock func() *inter.Block) *migration.Manager {
+	return migration.New("app", s.table.Migrations,
+		migration.Migration{
+			// legacy databases have no genesis state record, it was taken from the first block on every start
+			Name: "record genesis state of the first block",
+			Exec: func() error {
+				if s.getGenesisState() != nil {
+					return nil
+				}
+				if block := firstBlock(); block != nil {
+					s.setGenesisState(block.Root)
+				}
+				return nil
+			},
+		},
+	)
+}
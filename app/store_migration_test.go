@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+)
+
+func TestRecordGenesisState(t *testing.T) {
+	assertar := assert.New(t)
+
+	s := NewMemStore()
+	assertar.Nil(s.getGenesisState())
+
+	first := &inter.Block{Root: common.HexToHash("0x01")}
+	_, err := s.Migrations(func() *inter.Block {
+		return first
+	}).Exec(false)
+	assertar.NoError(err)
+
+	stored := s.getGenesisState()
+	if assertar.NotNil(stored) {
+		assertar.Equal(first.Root, *stored)
+	}
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
+)
+
+// snapshotTableIDs are IDs of the tables which are copied into snapshots of sealed epochs.
+// It's enough to continue blocks processing from the snapshot, if EVM state is synced separately.
+// Receipts and EVM logs aren't included.
+const snapshotTableIDs = "VvOomsagdXUR12345678"
+
+// ExportSnapshot copies the flushed snapshot tables into dst. The DBs pool must be pinned.
+func (s *Store) ExportSnapshot(dst ethdb.KeyValueWriter) error {
+	return table.CopyTables(dst, s.dbs.GetFlushedDb("gossip-main"), []byte(snapshotTableIDs))
+}
+
+// ImportSnapshot replaces the snapshot tables with the tables from src.
+func (s *Store) ImportSnapshot(src ethdb.Iteratee) error {
+	ids := []byte(snapshotTableIDs)
+	if err := table.DropTables(s.mainDb, ids); err != nil {
+		return err
+	}
+	if err := table.CopyTables(s.mainDb, src, ids); err != nil {
+		return err
+	}
+	s.purgeCaches()
+	return nil
+}
+
+// EvmStateNode returns EVM state trie node or contract code by hash, or nil if not found.
+func (s *Store) EvmStateNode(h common.Hash) []byte {
+	node, _ := s.table.EvmState.TrieDB().Node(h)
+	return node
+}
+
+// EvmDb returns EVM database, which EVM state may be synced into.
+func (s *Store) EvmDb() ethdb.Database {
+	return s.table.Evm
+}
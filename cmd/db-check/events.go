@@ -8,11 +8,12 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/compressed"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 )
 
 func checkEvents(db kvdb.KeyValueStore) {
-	t := table.New(db, []byte("e"))
+	t := compressed.Wrap(table.New(db, []byte("e")), 0)
 
 	it := t.NewIterator()
 	defer it.Release()
@@ -18,7 +18,9 @@ func main() {
 		dir = os.Args[1]
 	}
 
-	p := leveldb.NewProducer(dir)
+	// read-only mode doesn't change DB, but running node still has to be stopped
+	// as leveldb doesn't allow to open locked DB
+	p := leveldb.NewReadOnlyProducer(dir)
 	db := p.OpenDb("gossip-main")
 	defer db.Close()
 
@@ -22,6 +22,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/evmcore"
 	"github.com/Fantom-foundation/go-lachesis/gossip"
 	"github.com/Fantom-foundation/go-lachesis/gossip/gasprice"
+	"github.com/Fantom-foundation/go-lachesis/integration"
 	"github.com/Fantom-foundation/go-lachesis/lachesis"
 )
 
@@ -54,6 +55,13 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: utils.DirectoryString(DefaultDataDir()),
 	}
+
+	// DbEngineFlag defines key-value engine of the databases
+	DbEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Key-value engine of the databases (leveldb or bbolt)",
+		Value: integration.LevelDbEngine,
+	}
 )
 
 // These settings ensure that TOML keys use the same names as Go struct fields.
@@ -76,6 +84,7 @@ var tomlSettings = toml.Config{
 type config struct {
 	Node     node.Config
 	Lachesis gossip.Config
+	Db       integration.DbConfig
 }
 
 func loadAllConfigs(file string, cfg *config) error {
@@ -228,6 +237,16 @@ func gossipConfigWithFlags(ctx *cli.Context, src gossip.Config) gossip.Config {
 	return cfg
 }
 
+func dbConfigWithFlags(ctx *cli.Context, cfg integration.DbConfig) integration.DbConfig {
+	if ctx.GlobalIsSet(DbEngineFlag.Name) {
+		cfg.Engine = ctx.GlobalString(DbEngineFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.CacheFlag.Name) {
+		cfg.Cache = ctx.GlobalInt(utils.CacheFlag.Name)
+	}
+	return cfg
+}
+
 func nodeConfigWithFlags(ctx *cli.Context, cfg node.Config) node.Config {
 	utils.SetNodeConfig(ctx, &cfg)
 	setDataDir(ctx, &cfg)
@@ -237,7 +256,7 @@ func nodeConfigWithFlags(ctx *cli.Context, cfg node.Config) node.Config {
 func makeAllConfigs(ctx *cli.Context) config {
 	// Defaults (low priority)
 	net := defaultLachesisConfig(ctx)
-	cfg := config{Lachesis: gossip.DefaultConfig(net), Node: defaultNodeConfig()}
+	cfg := config{Lachesis: gossip.DefaultConfig(net), Node: defaultNodeConfig(), Db: integration.DefaultDbConfig()}
 
 	// Load config file (medium priority)
 	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
@@ -249,6 +268,7 @@ func makeAllConfigs(ctx *cli.Context) config {
 	// Apply flags (high priority)
 	cfg.Lachesis = gossipConfigWithFlags(ctx, cfg.Lachesis)
 	cfg.Node = nodeConfigWithFlags(ctx, cfg.Node)
+	cfg.Db = dbConfigWithFlags(ctx, cfg.Db)
 
 	return cfg
 }
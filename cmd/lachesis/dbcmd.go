@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/Fantom-foundation/go-lachesis/ethapi"
+	"github.com/Fantom-foundation/go-lachesis/integration"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/dagexport"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+var (
+	dryRunFlag = cli.BoolFlag{
+		Name:  "dryrun",
+		Usage: "Only list pending migrations, don't apply them",
+	}
+	fromEpochFlag = cli.Uint64Flag{
+		Name:  "from-epoch",
+		Usage: "First epoch to verify",
+		Value: 1,
+	}
+	toEpochFlag = cli.Uint64Flag{
+		Name:  "to-epoch",
+		Usage: "Last epoch to verify (default = current epoch)",
+	}
+	exportFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of exported DAG (dot|json|ascii)",
+		Value: dagexport.DOT,
+	}
+	exportFramesFlag = cli.Uint64Flag{
+		Name:  "frames",
+		Usage: "Export only the last N frames of the epoch (default = all)",
+	}
+
+	migrateCommand = cli.Command{
+		Action:    utils.MigrateFlags(migrate),
+		Name:      "migrate",
+		Usage:     "Apply pending database migrations",
+		ArgsUsage: "",
+		Flags: append(append(nodeFlags, testFlags...),
+			dryRunFlag,
+		),
+		Category: "DATABASE COMMANDS",
+		Description: `
+The migrate command upgrades databases schema to the latest known version.
+Node applies pending migrations on start as well, so the command is mostly useful with --dryrun.
+`,
+	}
+
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Create or restore a snapshot of node databases",
+		Category: "DATABASE COMMANDS",
+		Description: `
+Snapshot is a consistent point-in-time copy of all the node databases,
+which may be used to backup a node or to bootstrap a new one.
+Node must be stopped while a snapshot is created or restored.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(createSnapshot),
+				Name:      "create",
+				Usage:     "Write a snapshot of node databases into a file",
+				ArgsUsage: "<file>",
+				Flags:     append(nodeFlags, testFlags...),
+				Category:  "DATABASE COMMANDS",
+				Description: `
+    lachesis snapshot create <file>
+
+writes all the databases of the datadir into the file.
+`,
+			},
+			{
+				Action:    utils.MigrateFlags(restoreSnapshot),
+				Name:      "restore",
+				Usage:     "Fill an empty datadir with databases from a snapshot file",
+				ArgsUsage: "<file>",
+				Flags:     append(nodeFlags, testFlags...),
+				Category:  "DATABASE COMMANDS",
+				Description: `
+    lachesis snapshot restore <file>
+
+restores the databases from the file. The datadir must not contain databases.
+`,
+			},
+		},
+	}
+
+	verifyCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyChain),
+		Name:      "verify",
+		Usage:     "Verify stored blocks by replaying consensus of the stored events",
+		ArgsUsage: "",
+		Flags: append(append(nodeFlags, testFlags...),
+			fromEpochFlag,
+			toEpochFlag,
+		),
+		Category: "DATABASE COMMANDS",
+		Description: `
+    lachesis verify --from-epoch N --to-epoch M
+
+re-feeds the stored events into a fresh in-memory node state, started from the network genesis,
+executes the blocks transactions the same way as the node does,
+and compares the produced blocks (including state roots) of the epochs with the stored ones. The first divergence is reported.
+Epochs before N are replayed as well, but aren't compared. The databases are opened read-only.
+`,
+	}
+
+	forksCommand = cli.Command{
+		Action:    utils.MigrateFlags(dumpForks),
+		Name:      "forks",
+		Usage:     "Dump the detected double-sign evidences",
+		ArgsUsage: "[<epoch>]",
+		Flags:     append(nodeFlags, testFlags...),
+		Category:  "DATABASE COMMANDS",
+		Description: `
+    lachesis forks [<epoch>]
+
+prints the evidences of double-signs (forks) detected in the epoch as JSON,
+or the evidences of all the epochs if epoch isn't specified.
+The databases are opened read-only, but the node must be stopped, as it locks the databases.
+`,
+	}
+
+	dagCommand = cli.Command{
+		Name:     "dag",
+		Usage:    "Inspect the stored events DAG",
+		Category: "DATABASE COMMANDS",
+		Description: `
+Commands for visual debugging of the stored events DAG.
+The databases are opened read-only, but the node must be stopped, as it locks the databases.
+Use ftm_exportEpoch RPC method of a running node instead.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(exportDag),
+				Name:      "export",
+				Usage:     "Export events of an epoch to GraphViz DOT, JSON or ASCII-scheme",
+				ArgsUsage: "<epoch>",
+				Flags: append(append(nodeFlags, testFlags...),
+					exportFormatFlag,
+					exportFramesFlag,
+				),
+				Category: "DATABASE COMMANDS",
+				Description: `
+    lachesis dag export --format dot <epoch> | dot -Tsvg > epoch.svg
+
+prints the events of the epoch, one cluster per validator. Roots are colored by frame,
+Atroposes have red bold border, events of cheaters are octagons
+and not confirmed events have dashed border.
+
+    lachesis dag export --format ascii --frames 3 <epoch>
+
+prints the last 3 frames of the epoch as ASCII-scheme, which may be pasted into a test.
+`,
+			},
+		},
+	}
+)
+
+// migrate is the migrate command.
+func migrate(ctx *cli.Context) error {
+	cfg := makeAllConfigs(ctx)
+
+	return integration.MigrateDbs(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis, ctx.Bool(dryRunFlag.Name))
+}
+
+// createSnapshot is the 'snapshot create' command.
+func createSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	filename := ctx.Args().First()
+	cfg := makeAllConfigs(ctx)
+
+	// write into a temporary file, so a broken snapshot is never left under the name
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		utils.Fatalf("Failed to create snapshot file: %v", err)
+	}
+	manifest, err := integration.CreateSnapshot(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis, f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		utils.Fatalf("Failed to create snapshot: %v", err)
+	}
+	if err = os.Rename(tmp, filename); err != nil {
+		utils.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	log.Info("Snapshot is created", "file", filename, "genesis", manifest.GenesisHash.String(),
+		"epoch", manifest.Epoch, "block", manifest.LastBlock, "atropos", manifest.LastAtropos.String())
+	return nil
+}
+
+// restoreSnapshot is the 'snapshot restore' command.
+func restoreSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	filename := ctx.Args().First()
+	cfg := makeAllConfigs(ctx)
+
+	f, err := os.Open(filename)
+	if err != nil {
+		utils.Fatalf("Failed to open snapshot file: %v", err)
+	}
+	defer f.Close()
+
+	manifest, err := integration.RestoreSnapshot(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis, f)
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot (datadir %s may be partially filled): %v", cfg.Node.DataDir, err)
+	}
+
+	log.Info("Snapshot is restored", "datadir", cfg.Node.DataDir, "genesis", manifest.GenesisHash.String(),
+		"epoch", manifest.Epoch, "block", manifest.LastBlock, "atropos", manifest.LastAtropos.String())
+	return nil
+}
+
+// verifyChain is the verify command.
+func verifyChain(ctx *cli.Context) error {
+	cfg := makeAllConfigs(ctx)
+
+	from := idx.Epoch(ctx.Uint64(fromEpochFlag.Name))
+	to := idx.Epoch(math.MaxUint32)
+	if ctx.IsSet(toEpochFlag.Name) {
+		to = idx.Epoch(ctx.Uint64(toEpochFlag.Name))
+	}
+
+	verified, err := integration.VerifyChain(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis, from, to)
+	if err != nil {
+		return fmt.Errorf("verification failed after block %d: %v", verified, err)
+	}
+
+	log.Info("Chain is verified", "block", verified)
+	return nil
+}
+
+// dumpForks is the forks command.
+func dumpForks(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	cfg := makeAllConfigs(ctx)
+
+	dbs, _, gdb, cdb, err := integration.OpenReadOnlyStores(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis)
+	if err != nil {
+		utils.Fatalf("Failed to open databases: %v", err)
+	}
+	defer dbs.Close()
+
+	var from, to idx.Epoch
+	if len(ctx.Args()) == 1 {
+		epoch, err := strconv.ParseUint(ctx.Args().First(), 10, 32)
+		if err != nil {
+			utils.Fatalf("Invalid epoch: %v", err)
+		}
+		from, to = idx.Epoch(epoch), idx.Epoch(epoch)
+	} else {
+		from, to = 1, cdb.GetEpoch().EpochN
+	}
+
+	var forks []*inter.ForkEvidence
+	for epoch := from; epoch <= to; epoch++ {
+		forks = append(forks, gdb.GetForkEvidences(epoch)...)
+	}
+
+	res := make([]map[string]interface{}, len(forks))
+	for i, f := range forks {
+		res[i], err = ethapi.RPCMarshalForkEvidence(f)
+		if err != nil {
+			return err
+		}
+	}
+	out, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// exportDag is the 'dag export' command.
+func exportDag(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	epoch, err := strconv.ParseUint(ctx.Args().First(), 10, 32)
+	if err != nil {
+		utils.Fatalf("Invalid epoch: %v", err)
+	}
+	cfg := makeAllConfigs(ctx)
+
+	dbs, _, gdb, cdb, err := integration.OpenReadOnlyStores(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis)
+	if err != nil {
+		utils.Fatalf("Failed to open databases: %v", err)
+	}
+	defer dbs.Close()
+
+	dag := gdb.ExportEpoch(idx.Epoch(epoch), 0, 0, cdb.GetEventConfirmedOn)
+	if ctx.IsSet(exportFramesFlag.Name) {
+		dag = dag.LastFrames(idx.Frame(ctx.Uint64(exportFramesFlag.Name)))
+	}
+	out, err := dag.Marshal(ctx.String(exportFormatFlag.Name))
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
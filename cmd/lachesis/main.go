@@ -85,6 +85,7 @@ func init() {
 		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
 		utils.CacheNoPrefetchFlag,
+		DbEngineFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -159,6 +160,12 @@ func init() {
 		javascriptCommand,
 		// See config.go:
 		dumpConfigCommand,
+		// See dbcmd.go:
+		migrateCommand,
+		snapshotCommand,
+		forksCommand,
+		dagCommand,
+		verifyCommand,
 		// See misccmd.go:
 		versionCommand,
 		licenseCommand,
@@ -231,7 +238,7 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 
 	stack := makeConfigNode(ctx, &cfg.Node)
 
-	engine, adb, gdb := integration.MakeEngine(cfg.Node.DataDir, &cfg.Lachesis)
+	engine, adb, gdb := integration.MakeEngine(cfg.Node.DataDir, cfg.Db, &cfg.Lachesis)
 	metrics.SetDataDir(cfg.Node.DataDir)
 
 	// configure emitter
@@ -49,6 +49,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	lachesisparams "github.com/Fantom-foundation/go-lachesis/lachesis/params"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
 )
 
 const (
@@ -1032,6 +1033,73 @@ func RPCMarshalEventHeader(header *inter.EventHeaderData) map[string]interface{}
 	}
 }
 
+// RPCMarshalCertificate converts the given Atropos decision record to the RPC output.
+func RPCMarshalCertificate(cert *election.SignedDecisionRecord) (map[string]interface{}, error) {
+	raw, err := rlp.EncodeToBytes(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	marshalRoot := func(r election.RootAndSlot) map[string]interface{} {
+		return map[string]interface{}{
+			"hash":    hexutil.Bytes(r.ID.Bytes()),
+			"frame":   r.Slot.Frame,
+			"creator": r.Slot.Validator,
+		}
+	}
+	subjects := make([]map[string]interface{}, len(cert.Subjects))
+	for i, d := range cert.Subjects {
+		voters := make([]map[string]interface{}, len(d.Voters))
+		for j, v := range d.Voters {
+			voters[j] = marshalRoot(v)
+		}
+		subjects[i] = map[string]interface{}{
+			"validator": d.Validator,
+			"yes":       d.Yes,
+			"decider":   marshalRoot(d.Decider),
+			"voters":    voters,
+		}
+	}
+
+	headers := make([]map[string]interface{}, len(cert.Headers))
+	for i := range cert.Headers {
+		headers[i] = RPCMarshalEventHeader(&cert.Headers[i].EventHeaderData)
+		headers[i]["sig"] = hexutil.Bytes(cert.Headers[i].Sig)
+	}
+
+	return map[string]interface{}{
+		"epoch":    cert.Epoch(),
+		"frame":    cert.Frame,
+		"atropos":  hexutil.Bytes(cert.Atropos.Bytes()),
+		"subjects": subjects,
+		"headers":  headers,
+		"rlp":      hexutil.Bytes(raw),
+	}, nil
+}
+
+// RPCMarshalForkEvidence converts the given double-sign evidence to the RPC output.
+func RPCMarshalForkEvidence(f *inter.ForkEvidence) (map[string]interface{}, error) {
+	raw, err := rlp.EncodeToBytes(f)
+	if err != nil {
+		return nil, err
+	}
+
+	marshalHeader := func(h *inter.EventHeader) map[string]interface{} {
+		fields := RPCMarshalEventHeader(&h.EventHeaderData)
+		fields["sig"] = hexutil.Bytes(h.Sig)
+		return fields
+	}
+
+	return map[string]interface{}{
+		"creator": f.Creator(),
+		"epoch":   f.A.Epoch,
+		"seq":     f.A.Seq,
+		"a":       marshalHeader(&f.A),
+		"b":       marshalHeader(&f.B),
+		"rlp":     hexutil.Bytes(raw),
+	}, nil
+}
+
 // RPCMarshalEvent converts the given event to the RPC output which depends on fullTx. If inclTx is true transactions are
 // returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
 // transaction hashes.
@@ -35,9 +35,11 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/evmcore"
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/dagexport"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
 	"github.com/Fantom-foundation/go-lachesis/inter/sfctype"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
 )
 
 // PeerProgress is synchronization status of a peer
@@ -90,6 +92,9 @@ type Backend interface {
 	GetEvent(ctx context.Context, shortEventID string) (*inter.Event, error)
 	GetEventHeader(ctx context.Context, shortEventID string) (*inter.EventHeaderData, error)
 	GetConsensusTime(ctx context.Context, shortEventID string) (inter.Timestamp, error)
+	GetBlockCertificate(ctx context.Context, number rpc.BlockNumber) (*election.SignedDecisionRecord, error)
+	GetForkEvidence(ctx context.Context, epoch rpc.BlockNumber) ([]*inter.ForkEvidence, error)
+	ExportEpoch(ctx context.Context, epoch rpc.BlockNumber, from idx.Lamport, limit int) (*dagexport.Dag, error)
 	GetHeads(ctx context.Context, epoch rpc.BlockNumber) (hash.Events, error)
 	CurrentEpoch(ctx context.Context) idx.Epoch
 	GetEpochStats(ctx context.Context, requestedEpoch rpc.BlockNumber) (*sfctype.EpochStats, error)
@@ -132,6 +137,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicDAGChainAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicCertificateAPI(apiBackend),
+			Public:    true,
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
@@ -186,6 +196,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicDAGChainAPI(apiBackend),
 			Public:    true,
+		}, {
+			Namespace: "ftm",
+			Version:   "1.0",
+			Service:   NewPublicCertificateAPI(apiBackend),
+			Public:    true,
 		}, {
 			Namespace: "ftm",
 			Version:   "1.0",
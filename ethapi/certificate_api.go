@@ -0,0 +1,32 @@
+package ethapi
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// PublicCertificateAPI provides an API to access the records of the Atropos decisions.
+type PublicCertificateAPI struct {
+	b Backend
+}
+
+// NewPublicCertificateAPI creates a new certificate API.
+func NewPublicCertificateAPI(b Backend) *PublicCertificateAPI {
+	return &PublicCertificateAPI{b}
+}
+
+// GetBlockCertificate returns record of the block Atropos decision, for inspection only.
+// It may be checked against the epoch validators and their addresses with election.SignedDecisionRecord.Check,
+// the "rlp" field is the record encoding for that. It isn't a proof of finality, so the node must be trusted.
+// * When blockNr is -1 the certificate of latest block is returned.
+func (s *PublicCertificateAPI) GetBlockCertificate(ctx context.Context, blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	cert, err := s.b.GetBlockCertificate(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, nil
+	}
+	return RPCMarshalCertificate(cert)
+}
@@ -13,9 +13,13 @@ import (
 
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/dagexport"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 )
 
+// maxExportedEvents is the max number of events returned by ExportEpoch at once.
+const maxExportedEvents = 10000
+
 // PublicDAGChainAPI provides an API to access the directed acyclic graph chain.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicDAGChainAPI struct {
@@ -69,6 +73,61 @@ func (s *PublicDAGChainAPI) GetHeads(ctx context.Context, epoch rpc.BlockNumber)
 	return eventIDsToHex(res), nil
 }
 
+// GetForkEvidence returns the evidences of double-signs (forks) detected in the epoch.
+// Each evidence contains both conflicting event headers with their signatures,
+// it may be checked with inter.ForkEvidence.Verify, the "rlp" field is the evidence encoding for that.
+// * When epoch is -2 the evidences for latest epoch are returned.
+// * When epoch is -1 the evidences for latest sealed epoch are returned.
+func (s *PublicDAGChainAPI) GetForkEvidence(ctx context.Context, epoch rpc.BlockNumber) ([]map[string]interface{}, error) {
+	forks, err := s.b.GetForkEvidence(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]interface{}, len(forks))
+	for i, f := range forks {
+		res[i], err = RPCMarshalForkEvidence(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ExportEpoch returns the epoch events for visual debugging, in "dot" (GraphViz), "json"
+// or "ascii" (ASCII-scheme, see inter.ASCIIschemeToDAG) format.
+// In DOT, roots are colored by frame, Atroposes have red bold border, events of cheaters are octagons
+// and not confirmed events have dashed border.
+// At most maxExportedEvents events are returned, starting from Lamport from (optional). The result is
+// {"dag": <exported>, "next": <Lamport>}, where next is the from argument for the rest of the epoch, or 0.
+// * When epoch is -2 the latest epoch is exported.
+// * When epoch is -1 the latest sealed epoch is exported.
+func (s *PublicDAGChainAPI) ExportEpoch(ctx context.Context, epoch rpc.BlockNumber, format string, from *hexutil.Uint64) (map[string]interface{}, error) {
+	if format != dagexport.DOT && format != dagexport.JSON && format != dagexport.ASCII {
+		return nil, fmt.Errorf("unknown format %q, expected %q, %q or %q", format, dagexport.DOT, dagexport.JSON, dagexport.ASCII)
+	}
+	var fromLamport idx.Lamport
+	if from != nil {
+		fromLamport = idx.Lamport(*from)
+	}
+	dag, err := s.b.ExportEpoch(ctx, epoch, fromLamport, maxExportedEvents)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]interface{}{
+		"next": hexutil.Uint64(dag.Next),
+	}
+	if format == dagexport.JSON {
+		res["dag"] = dag
+		return res, nil
+	}
+	out, err := dag.Marshal(format)
+	if err != nil {
+		return nil, err
+	}
+	res["dag"] = string(out)
+	return res, nil
+}
+
 // CurrentEpoch returns current epoch number.
 func (s *PublicDAGChainAPI) CurrentEpoch(ctx context.Context) hexutil.Uint64 {
 	return hexutil.Uint64(s.b.CurrentEpoch(ctx))
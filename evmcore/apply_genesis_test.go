@@ -39,11 +39,11 @@ func TestApplyGenesis(t *testing.T) {
 	db1 := rawdb.NewDatabase(
 		nokeyiserr.Wrap(
 			table.New(
-				memorydb.New(), []byte("evm1_"))))
+				memorydb.New(), []byte("1"))))
 	db2 := rawdb.NewDatabase(
 		nokeyiserr.Wrap(
 			table.New(
-				memorydb.New(), []byte("evm2_"))))
+				memorydb.New(), []byte("2"))))
 
 	// no genesis
 	_, err := ApplyGenesis(db1, nil)
@@ -1,8 +1,14 @@
 package gossip
 
 import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
 // PublicEthereumAPI provides an API to access Ethereum-like information.
@@ -41,3 +47,60 @@ func (api *PublicEthereumAPI) Hashrate() hexutil.Uint64 {
 func (api *PublicEthereumAPI) ChainId() hexutil.Uint64 {
 	return hexutil.Uint64(api.s.config.Net.EvmChainConfig().ChainID.Uint64())
 }
+
+// PrivateAdminAPI provides an API to manage peers of the node.
+type PrivateAdminAPI struct {
+	s *Service
+}
+
+// NewPrivateAdminAPI creates a new admin API for gossip.
+func NewPrivateAdminAPI(s *Service) *PrivateAdminAPI {
+	return &PrivateAdminAPI{s}
+}
+
+// LachesisPeers returns reputations of the connected and the tracked peers, including ban reasons.
+func (api *PrivateAdminAPI) LachesisPeers() []PeerReputationInfo {
+	return api.s.pm.PeerReputations()
+}
+
+// Ban bans the node for the number of seconds, or permanently if it's 0.
+// The node is specified by an enode URL or an ID.
+func (api *PrivateAdminAPI) Ban(node string, seconds uint64, reason string) (bool, error) {
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if reason == "" {
+		reason = "banned by admin"
+	}
+	api.s.pm.BanPeer(id, time.Duration(seconds)*time.Second, reason)
+	return true, nil
+}
+
+// Unban lifts the ban of the node. Returns false if the node isn't banned.
+// The node is specified by an enode URL or an ID.
+func (api *PrivateAdminAPI) Unban(node string) (bool, error) {
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	return api.s.pm.UnbanPeer(id), nil
+}
+
+func parseNodeID(node string) (enode.ID, error) {
+	if strings.HasPrefix(node, "enode://") || strings.HasPrefix(node, "enr:") {
+		n, err := enode.Parse(enode.ValidSchemes, node)
+		if err != nil {
+			return enode.ID{}, fmt.Errorf("invalid enode: %v", err)
+		}
+		return n.ID(), nil
+	}
+
+	var id enode.ID
+	b, err := hex.DecodeString(strings.TrimPrefix(node, "0x"))
+	if err != nil || len(b) != len(id) {
+		return id, fmt.Errorf("invalid node ID %q", node)
+	}
+	copy(id[:], b)
+	return id, nil
+}
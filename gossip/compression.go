@@ -0,0 +1,62 @@
+package gossip
+
+import (
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// snappyFeature is a handshake feature of snappy compression of events payloads.
+const snappyFeature = "snappy"
+
+// isCompressible returns true if the message payload is compressed when both peers support it.
+func isCompressible(code uint64) bool {
+	return code == EventsMsg || code == PackMsg
+}
+
+// sendCompressed sends snappy-compressed RLP of the data.
+func sendCompressed(w p2p.MsgWriter, code uint64, data interface{}) error {
+	raw, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	compressed := snappy.Encode(nil, raw)
+
+	compressionRawEgressMeter.Mark(int64(len(raw)))
+	compressionEgressMeter.Mark(int64(len(compressed)))
+	compressionRatioHistogram.Update(compressionRatio(len(compressed), len(raw)))
+
+	return p2p.Send(w, code, compressed)
+}
+
+// decodeCompressed decodes snappy-compressed RLP of the message payload into val.
+func decodeCompressed(msg p2p.Msg, val interface{}) error {
+	var compressed []byte
+	if err := msg.Decode(&compressed); err != nil {
+		return err
+	}
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return err
+	}
+	if size > protocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", size, protocolMaxMsgSize)
+	}
+	raw, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return err
+	}
+
+	compressionRawIngressMeter.Mark(int64(len(raw)))
+	compressionIngressMeter.Mark(int64(len(compressed)))
+
+	return rlp.DecodeBytes(raw, val)
+}
+
+// compressionRatio returns compressed size in percents of raw size.
+func compressionRatio(compressed, raw int) int64 {
+	if raw == 0 {
+		return 100
+	}
+	return int64(compressed * 100 / raw)
+}
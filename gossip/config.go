@@ -3,10 +3,14 @@ package gossip
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/Fantom-foundation/go-lachesis/evmcore"
 	"github.com/Fantom-foundation/go-lachesis/gossip/gasprice"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/lachesis"
 	"github.com/Fantom-foundation/go-lachesis/lachesis/params"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 )
 
 type (
@@ -16,6 +20,37 @@ type (
 
 		LatencyImportance    int
 		ThroughputImportance int
+
+		// EventsCompression enables snappy compression of events payloads, if a peer supports it too
+		EventsCompression bool
+
+		// PeerServing limits serving of requests of a single peer
+		PeerServing ServingLimits
+		// TotalServing limits serving of requests of all the peers
+		TotalServing ServingLimits
+	}
+
+	// ServingLimits is config for serving quotas, 0 means no limit
+	ServingLimits struct {
+		// BytesPerSec is a number of responded bytes per second
+		BytesPerSec uint64
+		// ReadsPerSec is a number of DB reads per second
+		ReadsPerSec uint64
+	}
+
+	// SnapshotConfig is config for snapshot sync
+	SnapshotConfig struct {
+		// Serve enables making snapshots of sealed epochs and serving them to peers
+		Serve bool
+		// Sync enables syncing from a snapshot instead of processing the previous epochs
+		Sync bool
+		// Trusted is a hash of the snapshot info to sync from, it's logged by the serving nodes.
+		// Snapshot sync isn't enabled without it, as the peers may announce a forged snapshot
+		Trusted common.Hash
+		// MinPeers is a number of peers which must announce the same snapshot to sync from it
+		MinPeers int
+		// MinEpochsBehind is a minimal lag behind a snapshot to sync from it
+		MinEpochsBehind idx.Epoch
 	}
 	// Config for the gossip service.
 	Config struct {
@@ -31,6 +66,9 @@ type (
 		// Protocol options
 		Protocol ProtocolConfig
 
+		// Snapshot sync options
+		Snapshot SnapshotConfig
+
 		// Gas Price Oracle options
 		GPO gasprice.Config
 
@@ -51,6 +89,14 @@ type (
 
 	// StoreConfig is a config for store db.
 	StoreConfig struct {
+		// Cache is a memory budget shared by the store caches, it's injected by the caller.
+		// If nil, nothing is cached.
+		Cache *sharedcache.Pool `toml:"-"`
+
+		// Minimal size of Events and Receipts values to store them compressed, 0 disables compression.
+		CompressionThreshold int
+
+		// NOTE: fields for config-file back compatibility
 		// Cache size for Events.
 		EventsCacheSize int
 		// Cache size for EventHeaderData (Epoch db).
@@ -63,8 +109,6 @@ type (
 		TxPositionsCacheSize int
 		// Cache size for EpochStats.
 		EpochStatsCacheSize int
-
-		// NOTE: fields for config-file back compatibility
 		// Cache size for Receipts.
 		ReceiptsCacheSize int
 		// Cache size for Stakers.
@@ -88,6 +132,22 @@ func DefaultConfig(network lachesis.Config) Config {
 		Protocol: ProtocolConfig{
 			LatencyImportance:    60,
 			ThroughputImportance: 40,
+			EventsCompression:    true,
+			PeerServing: ServingLimits{
+				BytesPerSec: 2 * softResponseLimitSize,
+				ReadsPerSec: 4 * hardLimitItems,
+			},
+			TotalServing: ServingLimits{
+				BytesPerSec: 16 * softResponseLimitSize,
+				ReadsPerSec: 32 * hardLimitItems,
+			},
+		},
+
+		Snapshot: SnapshotConfig{
+			Serve:           false,
+			Sync:            false,
+			MinPeers:        2,
+			MinEpochsBehind: 3,
 		},
 
 		GPO: gasprice.Config{
@@ -115,23 +175,14 @@ func DefaultConfig(network lachesis.Config) Config {
 // DefaultStoreConfig for product.
 func DefaultStoreConfig() StoreConfig {
 	return StoreConfig{
-		EventsCacheSize:        500,
-		EventsHeadersCacheSize: 10000,
-		BlockCacheSize:         100,
-		PackInfosCacheSize:     100,
-		TxPositionsCacheSize:   1000,
-		EpochStatsCacheSize:    100,
+		CompressionThreshold: 256,
 	}
 }
 
 // LiteStoreConfig is for tests or inmemory.
 func LiteStoreConfig() StoreConfig {
 	return StoreConfig{
-		EventsCacheSize:        100,
-		EventsHeadersCacheSize: 1000,
-		BlockCacheSize:         100,
-		PackInfosCacheSize:     100,
-		TxPositionsCacheSize:   100,
-		EpochStatsCacheSize:    100,
+		Cache:                sharedcache.TestPool(),
+		CompressionThreshold: 256,
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
 	"github.com/Fantom-foundation/go-lachesis/vector"
 )
 
@@ -14,6 +15,9 @@ import (
 type Consensus interface {
 	// PushEvent takes event for processing.
 	ProcessEvent(e *inter.Event) error
+	// PrecalcEvent calculates consensus data of the event with processed parents ahead of ProcessEvent.
+	// It's safe for concurrent use.
+	PrecalcEvent(e *inter.Event)
 	// GetGenesisHash returns hash of genesis poset works with.
 	GetGenesisHash() common.Hash
 	// GetVectorIndex returns internal vector clock if exists
@@ -30,6 +34,16 @@ type Consensus interface {
 	GetEpochValidators() (*pos.Validators, idx.Epoch)
 	// GetConsensusTime calc consensus timestamp for given event.
 	GetConsensusTime(id hash.Event) (inter.Timestamp, error)
+	// GetBlockCertificate returns certificate of the block Atropos decision.
+	GetBlockCertificate(n idx.Block) *election.Certificate
+	// GetEventConfirmedOn returns frame which event is confirmed on, or 0 if event isn't confirmed.
+	GetEventConfirmedOn(id hash.Event) idx.Frame
+	// EpochSnapshot returns the consensus state at the current epoch start, or nil if the epoch is started already.
+	EpochSnapshot() []byte
+	// VerifyEpochSnapshot checks the epoch snapshot against the last block, without applying it.
+	VerifyEpochSnapshot(raw []byte, lastBlock *inter.Block) error
+	// ApplyEpochSnapshot moves the consensus to the epoch snapshot, which follows the last block.
+	ApplyEpochSnapshot(raw []byte, lastBlock *inter.Block) error
 
 	// Bootstrap must be called (once) before calling other methods
 	Bootstrap(callbacks inter.ConsensusCallbacks)
@@ -10,17 +10,20 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/Fantom-foundation/go-lachesis/eventcheck"
 	"github.com/Fantom-foundation/go-lachesis/evmcore"
+	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/sealing"
 	"github.com/Fantom-foundation/go-lachesis/tracing"
 )
 
-// processEvent extends the engine.ProcessEvent with gossip-specific actions on each event processing
-func (s *Service) processEvent(realEngine Consensus, e *inter.Event) error {
+// processEvent extends the engine.ProcessEvent with app-specific actions on each event processing
+func (s *Processor) processEvent(realEngine Consensus, e *inter.Event) error {
 	// s.engineMu is locked here
 
 	if s.store.HasEventHeader(e.Hash()) { // sanity check
@@ -28,8 +31,12 @@ func (s *Service) processEvent(realEngine Consensus, e *inter.Event) error {
 	}
 
 	// Trace arrival time of events
+	arrivalTime := inter.Timestamp(time.Now().UnixNano())
 	if s.config.EventLocalTimeIndex {
-		s.store.SetEventReceivingTime(e.Hash(), inter.Timestamp(time.Now().UnixNano()))
+		s.store.SetEventReceivingTime(e.Hash(), arrivalTime)
+	}
+	if metrics.Enabled {
+		s.eventArrivals.Add(e.Hash(), arrivalTime)
 	}
 	if s.config.DecisiveEventsIndex {
 		s.currentEvent = e.Hash()
@@ -57,7 +64,9 @@ func (s *Service) processEvent(realEngine Consensus, e *inter.Event) error {
 	s.store.AddHead(e.Epoch, e.Hash())
 
 	s.packsOnNewEvent(e, e.Epoch)
-	s.emitter.OnNewEvent(e)
+	if s.hooks.OnNewEvent != nil {
+		s.hooks.OnNewEvent(e)
+	}
 
 	newEpoch := oldEpoch
 	if realEngine != nil {
@@ -76,21 +85,29 @@ func (s *Service) processEvent(realEngine Consensus, e *inter.Event) error {
 		s.occurredTxs.Clear()
 
 		// notify about new epoch after event connection
-		s.emitter.OnNewEpoch(s.engine.GetValidators(), newEpoch)
+		if s.hooks.OnNewEpoch != nil {
+			s.hooks.OnNewEpoch(s.engine.GetValidators(), newEpoch)
+		}
 		s.feed.newEpoch.Send(newEpoch)
 	}
 
 	immediately := (newEpoch != oldEpoch)
 
+	// app and gossip stores share the DBs pool, so it commits the both
 	err := s.app.Commit(e.Hash().Bytes(), immediately)
 	if err != nil {
 		return err
 	}
-	return s.store.Commit(e.Hash().Bytes(), immediately)
+
+	if newEpoch != oldEpoch && s.config.Snapshot.Serve {
+		// snapshot is made of the DBs flushed on the epoch sealing
+		s.makeSnapshot(newEpoch)
+	}
+	return nil
 }
 
 // applyNewState moves the state according to new block (txs execution, SFC logic, epoch sealing)
-func (s *Service) applyNewState(
+func (s *Processor) applyNewState(
 	block *inter.Block,
 	sealEpoch bool,
 	cheaters inter.Cheaters,
@@ -174,7 +191,7 @@ func (s *Service) applyNewState(
 }
 
 // spillBlockEvents excludes first events which exceed BlockGasHardLimit
-func (s *Service) spillBlockEvents(block *inter.Block) (*inter.Block, inter.Events) {
+func (s *Processor) spillBlockEvents(block *inter.Block) (*inter.Block, inter.Events) {
 	fullEvents := make(inter.Events, len(block.Events))
 	if len(block.Events) == 0 {
 		return block, fullEvents
@@ -204,7 +221,7 @@ func (s *Service) spillBlockEvents(block *inter.Block) (*inter.Block, inter.Even
 }
 
 // assembleEvmBlock converts inter.Block to evmcore.EvmBlock
-func (s *Service) assembleEvmBlock(
+func (s *Processor) assembleEvmBlock(
 	block *inter.Block,
 ) (*evmcore.EvmBlock, inter.Events) {
 	// s.engineMu is locked here
@@ -242,7 +259,7 @@ func filterSkippedTxs(block *inter.Block, evmBlock *evmcore.EvmBlock) *evmcore.E
 }
 
 // executeTransactions execs ordered txns of new block on state.
-func (s *Service) executeEvmTransactions(
+func (s *Processor) executeEvmTransactions(
 	block *inter.Block,
 	evmBlock *evmcore.EvmBlock,
 	statedb *state.StateDB,
@@ -281,7 +298,7 @@ func (s *Service) executeEvmTransactions(
 }
 
 // onEpochSealed applies the new epoch sealing state
-func (s *Service) onEpochSealed(block *inter.Block, cheaters inter.Cheaters) {
+func (s *Processor) onEpochSealed(block *inter.Block, cheaters inter.Cheaters) {
 	// s.engineMu is locked here
 
 	epoch := s.engine.GetEpoch()
@@ -295,19 +312,42 @@ func (s *Service) onEpochSealed(block *inter.Block, cheaters inter.Cheaters) {
 }
 
 // applyBlock execs ordered txns of new block on state, and fills the block DB indexes.
-func (s *Service) applyBlock(block *inter.Block, decidedFrame idx.Frame, cheaters inter.Cheaters) (newAppHash common.Hash, sealEpoch bool) {
+func (s *Processor) applyBlock(block *inter.Block, decidedFrame idx.Frame, cheaters inter.Cheaters) (newAppHash common.Hash, sealEpoch bool) {
 	// s.engineMu is locked here
 
 	confirmBlocksMeter.Inc(1)
-	// if cheater is confirmed, seal epoch right away to prune them from of BFT validators list
 
-	epochStart := s.store.GetEpochStats(pendingEpoch).Start
-	sealEpoch = decidedFrame >= s.config.Net.Dag.MaxEpochBlocks
-	sealEpoch = sealEpoch || block.Time-epochStart >= inter.Timestamp(s.config.Net.Dag.MaxEpochDuration)
-	sealEpoch = sealEpoch || cheaters.Len() > 0
+	epoch := s.engine.GetEpoch()
+	sealingStats := s.store.GetEpochSealingStats(epoch)
+	sealedBy := sealing.RulesOf(&s.config.Net.Dag, epoch).SealEpoch(&sealing.Block{
+		Block:        block,
+		DecidedFrame: decidedFrame,
+		Cheaters:     cheaters,
+		EpochStart:   s.store.GetEpochStats(pendingEpoch).Start,
+		EpochGas:     sealingStats.Gas,
+		ValidatorsChanged: func() bool {
+			return s.validatorsChanged(epoch)
+		},
+		Signaled: sealingStats.Signaled,
+	})
+	sealEpoch = sealedBy != nil
 
 	block, evmBlock, receipts, txPositions, newAppHash := s.applyNewState(block, sealEpoch, cheaters)
 
+	// gather the epoch data for sealing rules
+	if sealEpoch {
+		log.Info("Epoch is sealed", "epoch", epoch, "block", block.Index, "rule", sealedBy.Name())
+		s.store.DelEpochSealingStats(epoch)
+	} else {
+		sealingStats.Gas += block.GasUsed
+		for _, r := range receipts {
+			for _, l := range r.Logs {
+				sealingStats.Signaled = sealingStats.Signaled || sealing.IsSignal(&s.config.Net.Dag, epoch, l)
+			}
+		}
+		s.store.SetEpochSealingStats(epoch, sealingStats)
+	}
+
 	s.store.SetBlock(block)
 	s.store.SetBlockIndex(block.Atropos, block.Index)
 
@@ -349,14 +389,33 @@ func (s *Service) applyBlock(block *inter.Block, decidedFrame idx.Frame, cheater
 			txTtfMeter.Update(latency.Milliseconds())
 		}
 	}
+	// trace time-to-finality of the block events, from their arrival to block time
+	if metrics.Enabled {
+		for _, id := range block.Events {
+			v, ok := s.eventArrivals.Get(id)
+			if !ok {
+				continue
+			}
+			s.eventArrivals.Remove(id)
+			arrivalTime := v.(inter.Timestamp)
+			if block.Time < arrivalTime {
+				continue
+			}
+			eventTtfMeter.Update(time.Duration(block.Time - arrivalTime).Milliseconds())
+		}
+	}
 
 	s.blockParticipated = make(map[idx.StakerID]bool) // reset map of participated validators
 
+	if s.hooks.OnNewBlock != nil {
+		s.hooks.OnNewBlock(block, cheaters)
+	}
+
 	return newAppHash, sealEpoch
 }
 
 // selectValidatorsGroup is a callback type to select new validators group
-func (s *Service) selectValidatorsGroup(oldEpoch, newEpoch idx.Epoch) (newValidators *pos.Validators) {
+func (s *Processor) selectValidatorsGroup(oldEpoch, newEpoch idx.Epoch) (newValidators *pos.Validators) {
 	// s.engineMu is locked here
 
 	builder := pos.NewBuilder()
@@ -368,7 +427,7 @@ func (s *Service) selectValidatorsGroup(oldEpoch, newEpoch idx.Epoch) (newValida
 }
 
 // onEventConfirmed is callback type to notify about event confirmation
-func (s *Service) onEventConfirmed(header *inter.EventHeaderData, seqDepth idx.Event) {
+func (s *Processor) onEventConfirmed(header *inter.EventHeaderData, seqDepth idx.Event) {
 	// s.engineMu is locked here
 
 	if !header.NoTransactions() {
@@ -387,8 +446,26 @@ func (s *Service) onEventConfirmed(header *inter.EventHeaderData, seqDepth idx.E
 	s.blockParticipated[header.Creator] = true
 }
 
+// onForkDetected is callback type to save the evidence of a double-sign
+func (s *Processor) onForkDetected(fork *inter.EventHeaderData, conflicting hash.Event) {
+	// s.engineMu is locked here
+
+	a := s.store.GetEvent(conflicting)
+	b := s.store.GetEvent(fork.Hash())
+	if a == nil || b == nil {
+		s.Log.Error("Fork events not found", "event", fork.Hash().String(), "conflicting", conflicting.String())
+		return
+	}
+	s.store.SetForkEvidence(&inter.ForkEvidence{
+		A: a.EventHeader,
+		B: b.EventHeader,
+	})
+	s.Log.Warn("Double-sign detected", "creator", fork.Creator, "epoch", fork.Epoch, "seq", fork.Seq,
+		"event", fork.Hash().String(), "conflicting", conflicting.String())
+}
+
 // isEventAllowedIntoBlock is callback type to check is event may be within block or not
-func (s *Service) isEventAllowedIntoBlock(header *inter.EventHeaderData, seqDepth idx.Event) bool {
+func (s *Processor) isEventAllowedIntoBlock(header *inter.EventHeaderData, seqDepth idx.Event) bool {
 	// s.engineMu is locked here
 
 	if header.NoTransactions() {
@@ -0,0 +1,44 @@
+package gossip
+
+import (
+	"sort"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/dagexport"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+// ExportEpoch collects the epoch events for visual debugging, starting from Lamport from.
+// At most limit events are collected (0 means no limit), dag.Next is Lamport of the next part.
+// confirmedOn returns frame which event is confirmed on, see poset store.
+func (s *Store) ExportEpoch(epoch idx.Epoch, from idx.Lamport, limit int, confirmedOn func(hash.Event) idx.Frame) *dagexport.Dag {
+	return dagexport.Build(epoch, &dagexport.Source{
+		ForEachEvent: func(onEvent func(e *inter.Event) bool) {
+			s.ForEachEventFrom(epoch, from, onEvent)
+		},
+		IsAtropos: func(id hash.Event) bool {
+			return s.GetBlockIndex(id) != nil
+		},
+		ConfirmedOn: confirmedOn,
+		Cheaters:    s.getEpochCheaters(epoch),
+		Limit:       limit,
+	})
+}
+
+// getEpochCheaters returns the creators of forks, known from the stored fork evidences.
+func (s *Store) getEpochCheaters(epoch idx.Epoch) inter.Cheaters {
+	set := map[idx.StakerID]struct{}{}
+	for _, f := range s.GetForkEvidences(epoch) {
+		set[f.Creator()] = struct{}{}
+	}
+
+	cheaters := make(inter.Cheaters, 0, len(set))
+	for creator := range set {
+		cheaters = append(cheaters, creator)
+	}
+	sort.Slice(cheaters, func(i, j int) bool {
+		return cheaters[i] < cheaters[j]
+	})
+	return cheaters
+}
@@ -33,6 +33,19 @@ func (p *dummyTxPool) AddRemotes(txs []*types.Transaction) []error {
 	return make([]error, len(txs))
 }
 
+// Get returns a transaction by hash, or nil if not found
+func (p *dummyTxPool) Get(hash common.Hash) *types.Transaction {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for _, tx := range p.pool {
+		if tx.Hash() == hash {
+			return tx
+		}
+	}
+	return nil
+}
+
 // Pending returns all the transactions known to the pool
 func (p *dummyTxPool) Pending() (map[common.Address]types.Transactions, error) {
 	p.lock.RLock()
@@ -57,6 +57,11 @@ type EmitterWorld struct {
 	PeersNum  func() int
 
 	AddVersion func(e *inter.Event) *inter.Event
+
+	// Clock returns current time, time.Now is used if nil
+	Clock func() time.Time
+	// Rand is a source of randomness for parents selection, it's seeded with current time if nil
+	Rand *rand.Rand
 }
 
 type Emitter struct {
@@ -98,6 +103,13 @@ func NewEmitter(
 	world EmitterWorld,
 ) *Emitter {
 
+	if world.Clock == nil {
+		world.Clock = time.Now
+	}
+	if world.Rand == nil {
+		world.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
 	txTime, _ := lru.New(TxTimeBufferSize)
 	loggerInstance := logger.MakeInstance()
 	return &Emitter{
@@ -112,9 +124,14 @@ func NewEmitter(
 	}
 }
 
+// now returns current time of emitter's world
+func (em *Emitter) now() time.Time {
+	return em.world.Clock()
+}
+
 // init emitter without starting events emission
 func (em *Emitter) init() {
-	em.syncStatus.connectedTime = time.Now()
+	em.syncStatus.connectedTime = em.now()
 	validators, epoch := em.world.Engine.GetEpochValidators()
 	em.OnNewEpoch(validators, epoch)
 }
@@ -143,14 +160,14 @@ func (em *Emitter) StartEventEmission() {
 			case <-ticker.C:
 				// track synced time
 				if em.world.PeersNum() == 0 {
-					em.syncStatus.connectedTime = time.Now() // connected time ~= last time when it's true that "not connected yet"
+					em.syncStatus.connectedTime = em.now() // connected time ~= last time when it's true that "not connected yet"
 				}
 				if !em.world.IsSynced() {
-					em.syncStatus.syncedTime = time.Now() // synced time ~= last time when it's true that "not synced yet"
+					em.syncStatus.syncedTime = em.now() // synced time ~= last time when it's true that "not synced yet"
 				}
 
 				// must pass at least MinEmitInterval since last event
-				if time.Since(em.prevEmittedTime) >= em.intervals.Min {
+				if em.now().Sub(em.prevEmittedTime) >= em.intervals.Min {
 					em.EmitEvent()
 				}
 			case <-done:
@@ -207,7 +224,7 @@ func (em *Emitter) memorizeTxTimes(txs types.Transactions) {
 	if em.myStakerID == 0 {
 		return // short circuit if not validator
 	}
-	now := time.Now()
+	now := em.now()
 	for _, tx := range txs {
 		_, ok := em.txTime.Get(tx.Hash())
 		if !ok {
@@ -257,7 +274,7 @@ func (em *Emitter) addTxs(e *inter.Event, poolTxs map[common.Address]types.Trans
 
 	maxGasUsed := em.maxGasPowerToUse(e)
 
-	now := time.Now()
+	now := em.now()
 	validators := em.world.Engine.GetValidators()
 	validatorsArr := validators.SortedIDs() // validators must be sorted deterministically
 	validatorsArrStakes := make([]pos.Stake, len(validatorsArr))
@@ -302,8 +319,8 @@ func (em *Emitter) findBestParents(epoch idx.Epoch, myStakerID idx.StakerID) (*h
 	vecClock := em.world.Engine.GetVectorIndex()
 	if vecClock != nil {
 		strategy = ancestor.NewCasualityStrategy(vecClock, em.world.Engine.GetValidators())
-		if rand.Intn(20) == 0 { // every 20th event uses random strategy is avoid repeating patterns in DAG
-			strategy = ancestor.NewRandomStrategy(rand.New(rand.NewSource(time.Now().UnixNano())))
+		if em.world.Rand.Intn(20) == 0 { // every 20th event uses random strategy is avoid repeating patterns in DAG
+			strategy = ancestor.NewRandomStrategy(em.world.Rand)
 		}
 
 		// don't link to known cheaters
@@ -386,7 +403,7 @@ func (em *Emitter) createEvent(poolTxs map[common.Address]types.Transactions) *i
 
 	event.Parents = parents
 	event.Lamport = maxLamport + 1
-	event.ClaimedTime = inter.MaxTimestamp(inter.Timestamp(time.Now().UnixNano()), selfParentTime+1)
+	event.ClaimedTime = inter.MaxTimestamp(inter.Timestamp(em.now().UnixNano()), selfParentTime+1)
 
 	// add version
 	if em.world.AddVersion != nil {
@@ -527,7 +544,7 @@ func (em *Emitter) OnNewEpoch(newValidators *pos.Validators, newEpoch idx.Epoch)
 	em.intervals.Max = time.Duration(piecefunc.Mul(uint64(em.config.EmitIntervals.Max), maxEmitIntervalRatio))
 
 	// track when I've became validator
-	now := time.Now()
+	now := em.now()
 	if em.myStakerID != 0 && !em.world.App.HasEpochValidator(newEpoch-1, em.myStakerID) {
 		em.syncStatus.becameValidatorTime = now
 	}
@@ -543,12 +560,12 @@ func (em *Emitter) OnNewEvent(e *inter.Event) {
 	}
 
 	// event was emitted by me on another instance
-	em.syncStatus.prevExternalEmittedTime = time.Now()
+	em.syncStatus.prevExternalEmittedTime = em.now()
 	if synced, _, _ := em.isSynced(); !synced {
 		return
 	}
 
-	passedSinceEvent := time.Since(inter.MaxTimestamp(e.ClaimedTime, e.MedianTime).Time())
+	passedSinceEvent := em.now().Sub(inter.MaxTimestamp(e.ClaimedTime, e.MedianTime).Time())
 	threshold := em.intervals.SelfForkProtection
 	if threshold > time.Minute {
 		threshold = time.Minute
@@ -573,19 +590,19 @@ func (em *Emitter) isSynced() (bool, string, time.Duration) {
 	if !em.world.IsSynced() {
 		return false, "synchronizing (all the peers have higher/lower epoch)", 0
 	}
-	sinceLastExternalEvent := time.Since(em.syncStatus.prevExternalEmittedTime)
+	sinceLastExternalEvent := em.now().Sub(em.syncStatus.prevExternalEmittedTime)
 	if sinceLastExternalEvent < em.intervals.SelfForkProtection {
 		return false, "synchronizing (not downloaded all the self-events)", em.intervals.SelfForkProtection - sinceLastExternalEvent
 	}
-	sinceBecameValidator := time.Since(em.syncStatus.becameValidatorTime)
+	sinceBecameValidator := em.now().Sub(em.syncStatus.becameValidatorTime)
 	if sinceBecameValidator < em.intervals.SelfForkProtection {
 		return false, "synchronizing (just joined the validators group)", em.intervals.SelfForkProtection - sinceBecameValidator
 	}
-	syncedPassed := time.Since(em.syncStatus.syncedTime)
+	syncedPassed := em.now().Sub(em.syncStatus.syncedTime)
 	if syncedPassed < em.intervals.SelfForkProtection {
 		return false, "synchronized (waiting additional time)", em.intervals.SelfForkProtection - syncedPassed
 	}
-	connectedPassed := time.Since(em.syncStatus.connectedTime)
+	connectedPassed := em.now().Sub(em.syncStatus.connectedTime)
 	if connectedPassed < em.intervals.SelfForkProtection {
 		return false, "synchronizing (recently connected)", em.intervals.SelfForkProtection - connectedPassed
 	}
@@ -726,8 +743,8 @@ func (em *Emitter) EmitEvent() *inter.Event {
 		em.world.OnEmitted(e)
 	}
 	em.gasRate.Mark(int64(e.GasPowerUsed))
-	em.prevEmittedTime = time.Now() // record time after connecting, to add the event processing time"
-	em.Log.Info("New event emitted", "id", e.Hash(), "parents", len(e.Parents), "by", e.Creator, "frame", inter.FmtFrame(e.Frame, e.IsRoot), "txs", e.Transactions.Len(), "t", time.Since(e.ClaimedTime.Time()))
+	em.prevEmittedTime = em.now() // record time after connecting, to add the event processing time"
+	em.Log.Info("New event emitted", "id", e.Hash(), "parents", len(e.Parents), "by", e.Creator, "frame", inter.FmtFrame(e.Frame, e.IsRoot), "txs", e.Transactions.Len(), "t", em.now().Sub(e.ClaimedTime.Time()))
 
 	// metrics
 	for _, t := range e.Transactions {
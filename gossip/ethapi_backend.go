@@ -28,11 +28,13 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/gossip/gasprice"
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/dagexport"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
 	"github.com/Fantom-foundation/go-lachesis/inter/sfctype"
 	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis/sfc"
 	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis/sfc/sfcpos"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
 	"github.com/Fantom-foundation/go-lachesis/topicsdb"
 	"github.com/Fantom-foundation/go-lachesis/tracing"
 )
@@ -179,6 +181,54 @@ func (b *EthAPIBackend) GetConsensusTime(ctx context.Context, shortEventID strin
 	return b.svc.engine.GetConsensusTime(id)
 }
 
+// GetBlockCertificate returns certificate of the block Atropos decision, with the signed headers of its roots.
+func (b *EthAPIBackend) GetBlockCertificate(ctx context.Context, number rpc.BlockNumber) (*election.SignedDecisionRecord, error) {
+	var n idx.Block
+	switch {
+	case number == rpc.PendingBlockNumber:
+		return nil, errors.New("pending block request isn't allowed")
+	case number == rpc.LatestBlockNumber:
+		n, _ = b.svc.engine.LastBlock()
+	default:
+		n = idx.Block(number)
+	}
+	cert := b.svc.engine.GetBlockCertificate(n)
+	if cert == nil {
+		return nil, nil
+	}
+
+	signed := &election.SignedDecisionRecord{
+		Certificate: *cert,
+	}
+	for _, id := range cert.Roots() {
+		e := b.svc.store.GetEvent(id)
+		if e == nil {
+			return nil, fmt.Errorf("certificate root %s not found", id.String())
+		}
+		signed.Headers = append(signed.Headers, e.EventHeader)
+	}
+	return signed, nil
+}
+
+// GetForkEvidence returns the double-sign evidences of the epoch.
+func (b *EthAPIBackend) GetForkEvidence(ctx context.Context, epoch rpc.BlockNumber) ([]*inter.ForkEvidence, error) {
+	requested, err := b.epochWithDefault(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return b.svc.store.GetForkEvidences(requested), nil
+}
+
+// ExportEpoch returns the epoch events for visual debugging, starting from Lamport from.
+// At most limit events are returned, unless limit is 0.
+func (b *EthAPIBackend) ExportEpoch(ctx context.Context, epoch rpc.BlockNumber, from idx.Lamport, limit int) (*dagexport.Dag, error) {
+	requested, err := b.epochWithDefault(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return b.svc.store.ExportEpoch(requested, from, limit, b.svc.engine.GetEventConfirmedOn), nil
+}
+
 func (b *EthAPIBackend) epochWithDefault(ctx context.Context, epoch rpc.BlockNumber) (requested idx.Epoch, err error) {
 	current := b.svc.engine.GetEpoch()
 
@@ -23,7 +23,7 @@ type EvmStateReader struct {
 	app   *app.Store
 }
 
-func (s *Service) GetEvmStateReader() *EvmStateReader {
+func (s *Processor) GetEvmStateReader() *EvmStateReader {
 	return &EvmStateReader{
 		ServiceFeed: &s.feed,
 		engineMu:    s.engineMu,
@@ -43,7 +43,10 @@ var (
 )
 
 // DropPeerFn is a callback type for dropping a peer detected as malicious.
-type DropPeerFn func(peer string)
+type DropPeerFn func(peer string, err error)
+
+// PeerTimeoutFn is a callback type for reporting a peer which didn't return the requested events in time.
+type PeerTimeoutFn func(peer string)
 
 // FilterInterestedFn returns only event which may be requested.
 type FilterInterestedFn func(ids hash.Events) hash.Events
@@ -51,8 +54,8 @@ type FilterInterestedFn func(ids hash.Events) hash.Events
 // EventsRequesterFn is a callback type for sending a event retrieval request.
 type EventsRequesterFn func(hash.Events) error
 
-// PushEventFn is a callback type to connect a received event
-type PushEventFn func(e *inter.Event, peer string)
+// PushEventsFn is a callback type to connect received events
+type PushEventsFn func(events inter.Events, peer string)
 
 // inject represents a schedules import operation.
 type inject struct {
@@ -85,6 +88,7 @@ type Fetcher struct {
 	// Various event channels
 	notify chan *announcesBatch
 	inject chan *inject
+	empty  chan string
 	quit   chan struct{}
 
 	// Callbacks
@@ -97,14 +101,16 @@ type Fetcher struct {
 
 	fetching     map[hash.Event]*oneAnnounce // Announced events, currently fetching
 	fetchingTime map[hash.Event]time.Time
+	emptyAt      map[string]time.Time // Time of the last empty response by peers
 
 	logger.Periodic
 }
 
 type Callback struct {
-	PushEvent      PushEventFn
+	PushEvents     PushEventsFn
 	OnlyInterested FilterInterestedFn
 	DropPeer       DropPeerFn
+	PeerTimeout    PeerTimeoutFn
 
 	HeavyCheck *heavycheck.Checker
 	FirstCheck func(*inter.Event) error
@@ -116,11 +122,13 @@ func New(callback Callback) *Fetcher {
 	return &Fetcher{
 		notify:       make(chan *announcesBatch, maxQueuedAnns),
 		inject:       make(chan *inject, maxQueuedInjects),
+		empty:        make(chan string, maxQueuedAnns),
 		quit:         make(chan struct{}),
 		announces:    make(map[string]int),
 		announced:    make(map[hash.Event][]*oneAnnounce),
 		fetching:     make(map[hash.Event]*oneAnnounce),
 		fetchingTime: make(map[hash.Event]time.Time),
+		emptyAt:      make(map[string]time.Time),
 		callback:     callback,
 
 		Periodic: logger.Periodic{Instance: loggerInstance},
@@ -199,6 +207,15 @@ func (f *Fetcher) Notify(peer string, hashes hash.Events, time time.Time, fetchE
 	return nil
 }
 
+// NotifyEmptyEvents notifies the fetcher that the peer responded without events (e.g. the peer is throttled
+// by its serving quota), so the events requested from the peer before aren't counted as timed out.
+func (f *Fetcher) NotifyEmptyEvents(peer string) {
+	select {
+	case f.empty <- peer:
+	default:
+	}
+}
+
 // Enqueue tries to fill gaps the fetcher's future import queue.
 func (f *Fetcher) Enqueue(peer string, inEvents inter.Events, t time.Time, fetchEvents EventsRequesterFn) error {
 	// Filter already known events
@@ -216,7 +233,7 @@ func (f *Fetcher) Enqueue(peer string, inEvents inter.Events, t time.Time, fetch
 		err := f.callback.FirstCheck(e)
 		if eventcheck.IsBan(err) {
 			f.Periodic.Warn(time.Second, "Incoming event rejected", "event", e.Hash().String(), "creator", e.Creator, "err", err)
-			f.callback.DropPeer(peer)
+			f.callback.DropPeer(peer, err)
 			return err
 		}
 		if err == nil {
@@ -232,7 +249,7 @@ func (f *Fetcher) Enqueue(peer string, inEvents inter.Events, t time.Time, fetch
 			if eventcheck.IsBan(err) {
 				e := res.Events[i]
 				f.Periodic.Warn(time.Second, "Incoming event rejected", "event", e.Hash().String(), "creator", e.Creator, "err", err)
-				f.callback.DropPeer(peer)
+				f.callback.DropPeer(peer, err)
 				return
 			}
 			if err == nil {
@@ -274,17 +291,35 @@ func (f *Fetcher) loop() {
 
 	for {
 		// Clean up any expired event fetches
+		var timedOut map[string]bool
 		for id, announce := range f.fetching {
 			if time.Since(announce.batch.time) > fetchTimeout {
+				if f.emptyAt[announce.batch.peer].Before(f.fetchingTime[id]) {
+					if timedOut == nil {
+						timedOut = make(map[string]bool)
+					}
+					timedOut[announce.batch.peer] = true
+				}
 				f.forgetHash(id)
 			}
 		}
+		for peer := range timedOut {
+			f.callback.PeerTimeout(peer)
+		}
+		for peer, at := range f.emptyAt {
+			if time.Since(at) > fetchTimeout {
+				delete(f.emptyAt, peer)
+			}
+		}
 		// Wait for an outside event to occur
 		select {
 		case <-f.quit:
 			// Fetcher terminating, abort all operations
 			return
 
+		case peer := <-f.empty:
+			f.emptyAt[peer] = time.Now()
+
 		case notification := <-f.notify:
 			// A event was announced, make sure the peer isn't DOSing us
 			propAnnounceInMeter.Update(int64(len(notification.hashes)))
@@ -345,8 +380,10 @@ func (f *Fetcher) loop() {
 					}
 					parents.Add(p)
 				}
+			}
 
-				f.callback.PushEvent(e, op.peer)
+			f.callback.PushEvents(op.events, op.peer)
+			for _, e := range op.events {
 				f.forgetHash(e.Hash())
 			}
 
@@ -8,18 +8,23 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	notify "github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rlp"
 
+	"github.com/Fantom-foundation/go-lachesis/app"
 	"github.com/Fantom-foundation/go-lachesis/eventcheck"
 	"github.com/Fantom-foundation/go-lachesis/evmcore"
 	"github.com/Fantom-foundation/go-lachesis/gossip/fetcher"
 	"github.com/Fantom-foundation/go-lachesis/gossip/ordering"
 	"github.com/Fantom-foundation/go-lachesis/gossip/packsdownloader"
+	"github.com/Fantom-foundation/go-lachesis/gossip/snapsync"
+	"github.com/Fantom-foundation/go-lachesis/gossip/txfetcher"
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
@@ -70,7 +75,9 @@ type ProtocolManager struct {
 
 	peers *peerSet
 
-	serverPool *serverPool
+	serverPool   *serverPool
+	reputation   *reputationSet
+	servingQuota *servingQuota
 
 	txsCh  chan evmcore.NewTxsNotify
 	txsSub notify.Subscription
@@ -78,6 +85,10 @@ type ProtocolManager struct {
 	downloader *packsdownloader.PacksDownloader
 	fetcher    *fetcher.Fetcher
 	buffer     *ordering.EventBuffer
+	snapsyncer *snapsync.Syncer
+	txFetcher  *txfetcher.TxFetcher
+
+	app *app.Store
 
 	store    *Store
 	engine   Consensus
@@ -128,6 +139,7 @@ func NewProtocolManager(
 		engine:      engine,
 		peers:       newPeerSet(),
 		serverPool:  serverPool,
+		reputation:  newReputationSet(s.table.Peers),
 		engineMu:    engineMu,
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
@@ -139,12 +151,55 @@ func NewProtocolManager(
 
 	pm.SetName("PM")
 
+	pm.servingQuota = newServingQuota(config.Protocol.TotalServing, mclock.System{})
+
 	pm.fetcher, pm.buffer = pm.makeFetcher(checkers)
-	pm.downloader = packsdownloader.New(pm.fetcher, pm.onlyNotConnectedEvents, pm.removePeer)
+	pm.txFetcher = txfetcher.New(txfetcher.Callback{
+		OnlyInterested: pm.onlyInterestedTxs,
+	})
+	pm.downloader = packsdownloader.New(pm.fetcher, pm.onlyNotConnectedEvents, pm.removePeer, pm.onPeerTimeout)
 
 	return pm, nil
 }
 
+// enableSnapshots enables serving of EVM state if snapshots are served, and snapshot sync if it's configured.
+func (pm *ProtocolManager) enableSnapshots(app *app.Store, apply func(info *snapsync.Info) error) {
+	pm.app = app
+	if !pm.config.Snapshot.Sync {
+		return
+	}
+	if pm.config.Snapshot.Trusted == (common.Hash{}) {
+		log.Warn("Snapshot sync is disabled, trusted snapshot isn't specified")
+		return
+	}
+
+	pm.snapsyncer = snapsync.New(snapsync.Config{
+		Trusted:         pm.config.Snapshot.Trusted,
+		MinPeers:        pm.config.Snapshot.MinPeers,
+		MinEpochsBehind: pm.config.Snapshot.MinEpochsBehind,
+	}, snapsync.Callback{
+		Epoch:   pm.engine.GetEpoch,
+		StateDb: app.EvmDb(),
+		Reset:   pm.store.DelDownload,
+		Section: func(id byte) ethdb.KeyValueStore {
+			return pm.store.GetDownloadSection(id)
+		},
+		Write: func(write func(), flush bool) {
+			// the DBs pool must not be flushed while it's written
+			pm.engineMu.Lock()
+			defer pm.engineMu.Unlock()
+
+			write()
+			// app and gossip stores share the DBs pool, so it commits the both
+			if err := app.Commit(nil, flush); err != nil {
+				log.Crit("Failed to flush snapshot data", "err", err)
+			}
+		},
+		Apply:    apply,
+		DropPeer: pm.removePeer,
+	})
+}
+
 func (pm *ProtocolManager) makeFetcher(checkers *eventcheck.Checkers) (*fetcher.Fetcher, *ordering.EventBuffer) {
 	// checkers
 	firstCheck := func(e *inter.Event) error {
@@ -173,6 +228,8 @@ func (pm *ProtocolManager) makeFetcher(checkers *eventcheck.Checkers) (*fetcher.
 	// DAG callbacks
 	buffer := ordering.New(eventsBuffSize, ordering.Callback{
 
+		Precalc: pm.engine.PrecalcEvent,
+
 		Process: func(e *inter.Event) error {
 			now := time.Now()
 			pm.engineMu.Lock()
@@ -197,7 +254,7 @@ func (pm *ProtocolManager) makeFetcher(checkers *eventcheck.Checkers) (*fetcher.
 		Drop: func(e *inter.Event, peer string, err error) {
 			if eventcheck.IsBan(err) {
 				log.Warn("Incoming event rejected", "event", e.Hash().String(), "creator", e.Creator, "err", err)
-				pm.removePeer(peer)
+				pm.dropInvalidPeer(peer, err)
 			}
 		},
 
@@ -213,9 +270,13 @@ func (pm *ProtocolManager) makeFetcher(checkers *eventcheck.Checkers) (*fetcher.
 	})
 
 	newFetcher := fetcher.New(fetcher.Callback{
-		PushEvent:      buffer.PushEvent,
+		PushEvents: func(events inter.Events, peer string) {
+			pm.rewardPeer(peer, usefulDeliveryReward)
+			buffer.PushEvents(events, peer)
+		},
 		OnlyInterested: pm.onlyInterestedEvents,
-		DropPeer:       pm.removePeer,
+		DropPeer:       pm.dropInvalidPeer,
+		PeerTimeout:    pm.onPeerTimeout,
 		FirstCheck:     firstCheck,
 		HeavyCheck:     checkers.Heavycheck,
 	})
@@ -256,6 +317,21 @@ func (pm *ProtocolManager) onlyInterestedEvents(ids hash.Events) hash.Events {
 	return interested
 }
 
+func (pm *ProtocolManager) onlyInterestedTxs(hashes []common.Hash) []common.Hash {
+	if len(hashes) == 0 {
+		return hashes
+	}
+
+	interested := make([]common.Hash, 0, len(hashes))
+	for _, h := range hashes {
+		if pm.txpool.Get(h) != nil {
+			continue
+		}
+		interested = append(interested, h)
+	}
+	return interested
+}
+
 func (pm *ProtocolManager) makeProtocol(version uint) p2p.Protocol {
 	length, ok := protocolLengths[version]
 	if !ok {
@@ -267,6 +343,10 @@ func (pm *ProtocolManager) makeProtocol(version uint) p2p.Protocol {
 		Version: version,
 		Length:  length,
 		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			if pm.reputation.Banned(p.ID()) {
+				p.Log().Debug("Banned peer rejected")
+				return p2p.DiscUselessPeer
+			}
 			var entry *poolEntry
 			peer := pm.newPeer(int(version), p, rw)
 			if pm.serverPool != nil {
@@ -293,7 +373,7 @@ func (pm *ProtocolManager) makeProtocol(version uint) p2p.Protocol {
 			return pm.NodeInfo()
 		},
 		PeerInfo: func(id enode.ID) interface{} {
-			if p := pm.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+			if p := pm.peers.Peer(peerID(id)); p != nil {
 				return p.Info()
 			}
 			return nil
@@ -311,6 +391,9 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 	// Unregister the peer from the downloader and peer set
 	_ = pm.downloader.UnregisterPeer(id)
+	if pm.snapsyncer != nil {
+		_ = pm.snapsyncer.UnregisterPeer(id)
+	}
 	if err := pm.peers.Unregister(id); err != nil {
 		log.Error("Peer removal failed", "peer", id, "err", err)
 	}
@@ -320,6 +403,68 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// rewardPeer increases reputation of the connected peer.
+func (pm *ProtocolManager) rewardPeer(id string, points int64) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		pm.reputation.Reward(peer.ID(), points)
+	}
+}
+
+// penalizePeer decreases reputation of the connected peer, and drops the peer if it gets banned.
+func (pm *ProtocolManager) penalizePeer(id string, points int64, reason string) {
+	peer := pm.peers.Peer(id)
+	if peer == nil {
+		return
+	}
+	if pm.reputation.Penalize(peer.ID(), points, reason) {
+		pm.removePeer(id)
+	}
+}
+
+// dropInvalidPeer penalizes and drops the peer which sent an invalid event.
+func (pm *ProtocolManager) dropInvalidPeer(id string, err error) {
+	pm.penalizePeer(id, invalidEventPenalty, "invalid event: "+err.Error())
+	pm.removePeer(id)
+}
+
+// onPeerTimeout penalizes the peer which didn't answer a request in time.
+func (pm *ProtocolManager) onPeerTimeout(id string) {
+	pm.penalizePeer(id, timeoutPenalty, "request timeout")
+}
+
+// BanPeer bans the node for the duration, or permanently if the duration is 0, and drops it if it's connected.
+func (pm *ProtocolManager) BanPeer(id enode.ID, duration time.Duration, reason string) {
+	pm.reputation.Ban(id, duration, reason)
+	pm.removePeer(peerID(id))
+}
+
+// UnbanPeer lifts the node's ban. Returns false if the node isn't banned.
+func (pm *ProtocolManager) UnbanPeer(id enode.ID) bool {
+	return pm.reputation.Unban(id)
+}
+
+// PeerReputations returns reputations of the connected and tracked peers.
+func (pm *ProtocolManager) PeerReputations() []PeerReputationInfo {
+	list := pm.reputation.List()
+	tracked := make(map[enode.ID]int, len(list))
+	for i, info := range list {
+		tracked[info.ID] = i
+	}
+	for _, p := range pm.peers.List() {
+		i, ok := tracked[p.ID()]
+		if !ok {
+			list = append(list, PeerReputationInfo{
+				ID:      p.ID(),
+				Reasons: []string{},
+			})
+			i = len(list) - 1
+		}
+		list[i].Name = p.Name()
+		list[i].Connected = true
+	}
+	return list
+}
+
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
@@ -379,7 +524,9 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, p, rw)
+	peer := newPeer(pv, p, rw)
+	peer.servingQuota = newServingQuota(pm.config.Protocol.PeerServing, mclock.System{})
+	return peer
 }
 
 func (pm *ProtocolManager) myProgress() PeerProgress {
@@ -404,6 +551,15 @@ func (pm *ProtocolManager) highestPeerProgress() PeerProgress {
 	return max
 }
 
+// features returns the supported optional capabilities, which are advertised in the handshake.
+func (pm *ProtocolManager) features() []string {
+	var features []string
+	if pm.config.Protocol.EventsCompression {
+		features = append(features, snappyFeature)
+	}
+	return features
+}
+
 // handle is the callback invoked to manage the life cycle of a peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
@@ -418,7 +574,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		genesis    = pm.engine.GetGenesisHash()
 		myProgress = pm.myProgress()
 	)
-	if err := p.Handshake(pm.config.Net.NetworkID, myProgress, genesis); err != nil {
+	if err := p.Handshake(pm.config.Net.NetworkID, myProgress, genesis, pm.features()); err != nil {
 		p.Log().Debug("Handshake failed", "err", err)
 		return err
 	}
@@ -490,6 +646,17 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			_ = peerDwnlr.NotifyPackInfo(p.progress.Epoch, progress.LastPackInfo.Index, progress.LastPackInfo.Heads, time.Now())
 		}
 
+		// notify snapshot syncer about new peer's epoch
+		if pm.snapsyncer != nil && p.version >= lachesis63 {
+			_ = pm.snapsyncer.RegisterPeer(snapsync.Peer{
+				ID:                p.id,
+				Epoch:             p.progress.Epoch,
+				RequestInfo:       p.RequestSnapshotInfo,
+				RequestStateNodes: p.RequestStateNodes,
+				RequestSection:    p.RequestSnapshotSection,
+			})
+		}
+
 	case msg.Code == NewEventHashesMsg:
 		if pm.fetcher.Overloaded() {
 			break
@@ -517,12 +684,17 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			break
 		}
 		var events []*inter.Event
-		if err := msg.Decode(&events); err != nil {
+		if err := p.decode(msg, &events); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
 		if err := checkLenLimits(len(events), events); err != nil {
 			return err
 		}
+		if len(events) == 0 {
+			// the requested events aren't served, e.g. because of the serving quota
+			pm.fetcher.NotifyEmptyEvents(p.id)
+			break
+		}
 		// Mark the hashes as present at the remote node
 		for _, e := range events {
 			p.MarkEvent(e.Hash())
@@ -548,8 +720,33 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		pm.txpool.AddRemotes(txs)
 
-	case msg.Code == GetEventsMsg:
-		var requests hash.Events
+	case msg.Code == NewEvmTxHashesMsg:
+		if p.version < lachesis64 {
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+		// Transactions can be processed only if synced up
+		if atomic.LoadUint32(&pm.synced) == 0 || pm.txFetcher.OverloadedPeer(p.id) {
+			break
+		}
+		var announces []common.Hash
+		if err := msg.Decode(&announces); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if err := checkLenLimits(len(announces), announces); err != nil {
+			return err
+		}
+		// Mark the hashes as present at the remote node
+		for _, h := range announces {
+			p.MarkTransaction(h)
+		}
+		// Schedule all the unknown hashes for retrieval
+		_ = pm.txFetcher.Notify(p.id, announces, time.Now(), p.RequestTxs)
+
+	case msg.Code == GetEvmTxsMsg:
+		if p.version < lachesis64 {
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+		var requests []common.Hash
 		if err := msg.Decode(&requests); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
@@ -557,25 +754,61 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return err
 		}
 
-		rawEvents := make([]rlp.RawValue, 0, len(requests))
-		ids := make(hash.Events, 0, len(requests))
-		size := 0
-		for _, id := range requests {
-			if raw := pm.store.GetEventRLP(id); raw != nil {
-				rawEvents = append(rawEvents, raw)
-				ids = append(ids, id)
-				size += len(raw)
-			} else {
-				pm.Log.Debug("requested event not found", "hash", id)
+		txs := make(types.Transactions, 0, len(requests))
+		size := common.StorageSize(0)
+		for _, h := range requests {
+			if tx := pm.txpool.Get(h); tx != nil {
+				txs = append(txs, tx)
+				size += tx.Size()
 			}
 			if size >= softResponseLimitSize {
 				break
 			}
 		}
-		if len(rawEvents) != 0 {
-			_ = p.SendEventsRLP(rawEvents, ids)
+		if len(txs) != 0 {
+			_ = p.SendTransactions(txs)
 		}
 
+	case msg.Code == GetEventsMsg:
+		var requests hash.Events
+		if err := msg.Decode(&requests); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if err := checkLenLimits(len(requests), requests); err != nil {
+			return err
+		}
+		estimate := servingCost{
+			bytes: len(requests) * servingEventSize,
+			reads: len(requests),
+		}
+		if estimate.bytes > softResponseLimitSize {
+			estimate.bytes = softResponseLimitSize
+		}
+		pm.serveRequest(p, estimate, func() (spent servingCost) {
+			rawEvents := make([]rlp.RawValue, 0, len(requests))
+			ids := make(hash.Events, 0, len(requests))
+			for _, id := range requests {
+				spent.reads++
+				if raw := pm.store.GetEventRLP(id); raw != nil {
+					rawEvents = append(rawEvents, raw)
+					ids = append(ids, id)
+					spent.bytes += len(raw)
+				} else {
+					pm.Log.Debug("requested event not found", "hash", id)
+				}
+				if spent.bytes >= softResponseLimitSize {
+					break
+				}
+			}
+			if len(rawEvents) != 0 {
+				_ = p.SendEventsRLP(rawEvents, ids)
+			}
+			return
+		}, func() {
+			// empty response, so the peer doesn't wait for the events
+			_ = p.SendEventsRLP([]rlp.RawValue{}, nil)
+		})
+
 	case msg.Code == GetPackInfosMsg:
 		var request getPackInfosData
 		if err := msg.Decode(&request); err != nil {
@@ -584,36 +817,52 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := checkLenLimits(len(request.Indexes), request); err != nil {
 			return err
 		}
-
-		packsNum, ok := pm.store.GetPacksNum(request.Epoch)
-		if !ok {
-			// no packs in the requested epoch
-			break
+		estimate := servingCost{
+			bytes: len(request.Indexes) * servingPackInfoSize,
+			reads: len(request.Indexes) + 1,
 		}
-
-		rawPackInfos := make([]rlp.RawValue, 0, len(request.Indexes))
-		size := 0
-		for _, index := range request.Indexes {
-			if index >= packsNum {
-				// return only pinned and existing packs
-				continue
+		if estimate.bytes > softResponseLimitSize {
+			estimate.bytes = softResponseLimitSize
+		}
+		pm.serveRequest(p, estimate, func() (spent servingCost) {
+			spent.reads++
+			packsNum, ok := pm.store.GetPacksNum(request.Epoch)
+			if !ok {
+				// no packs in the requested epoch
+				return
 			}
 
-			if raw := pm.store.GetPackInfoRLP(request.Epoch, index); raw != nil {
-				rawPackInfos = append(rawPackInfos, raw)
-				size += len(raw)
+			rawPackInfos := make([]rlp.RawValue, 0, len(request.Indexes))
+			for _, index := range request.Indexes {
+				if index >= packsNum {
+					// return only pinned and existing packs
+					continue
+				}
+
+				spent.reads++
+				if raw := pm.store.GetPackInfoRLP(request.Epoch, index); raw != nil {
+					rawPackInfos = append(rawPackInfos, raw)
+					spent.bytes += len(raw)
+				}
+				if spent.bytes >= softResponseLimitSize {
+					break
+				}
 			}
-			if size >= softResponseLimitSize {
-				break
+			if len(rawPackInfos) != 0 {
+				_ = p.SendPackInfosRLP(&packInfosDataRLP{
+					Epoch:           request.Epoch,
+					TotalNumOfPacks: packsNum,
+					RawInfos:        rawPackInfos,
+				})
 			}
-		}
-		if len(rawPackInfos) != 0 {
+			return
+		}, func() {
+			// empty response, so the peer doesn't wait for the pack infos
 			_ = p.SendPackInfosRLP(&packInfosDataRLP{
-				Epoch:           request.Epoch,
-				TotalNumOfPacks: packsNum,
-				RawInfos:        rawPackInfos,
+				Epoch:    request.Epoch,
+				RawInfos: []rlp.RawValue{},
 			})
-		}
+		})
 
 	case msg.Code == GetPackMsg:
 		var request getPackData
@@ -625,21 +874,32 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			// short circuit if future epoch
 			break
 		}
-
-		ids := make(hash.Events, 0, softLimitItems)
-		for i, id := range pm.store.GetPack(request.Epoch, request.Index) {
-			ids = append(ids, id)
-			if i >= softLimitItems {
-				break
-			}
-		}
-		if len(ids) != 0 {
-			_ = p.SendPack(&packData{
-				Epoch: request.Epoch,
-				Index: request.Index,
-				IDs:   ids,
-			})
+		estimate := servingCost{
+			bytes: softLimitItems * len(hash.Event{}),
+			reads: softLimitItems + 1,
 		}
+		pm.serveRequest(p, estimate, func() servingCost {
+			ids := make(hash.Events, 0, softLimitItems)
+			for i, id := range pm.store.GetPack(request.Epoch, request.Index) {
+				ids = append(ids, id)
+				if i >= softLimitItems {
+					break
+				}
+			}
+			if len(ids) != 0 {
+				_ = p.SendPack(&packData{
+					Epoch: request.Epoch,
+					Index: request.Index,
+					IDs:   ids,
+				})
+			}
+			return servingCost{
+				bytes: len(ids) * len(hash.Event{}),
+				reads: len(ids) + 1,
+			}
+		}, func() {
+			// a not answered pack request isn't penalized, and an empty pack is invalid, so no response
+		})
 
 	case msg.Code == PackInfosMsg:
 		if peerDwnlr == nil {
@@ -654,6 +914,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return err
 		}
 
+		if len(infos.Infos) == 0 {
+			// the requested pack infos aren't served, e.g. because of the serving quota
+			peerDwnlr.NotifyEmptyPackInfos()
+		}
 		// notify about number of packs this peer has
 		_ = peerDwnlr.NotifyPacksNum(infos.Epoch, infos.TotalNumOfPacks)
 
@@ -675,7 +939,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		var pack packData
-		if err := msg.Decode(&pack); err != nil {
+		if err := p.decode(msg, &pack); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
 		if err := checkLenLimits(len(pack.IDs), pack); err != nil {
@@ -691,6 +955,129 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Notify downloader about new pack
 		_ = peerDwnlr.NotifyPack(pack.Epoch, pack.Index, pack.IDs, time.Now(), p.RequestEvents)
 
+	case msg.Code == GetSnapshotInfoMsg:
+		infos := make([]*snapsync.Info, 0, 1)
+		if pm.config.Snapshot.Serve {
+			if info := pm.store.GetSnapshotInfo(); info != nil {
+				infos = append(infos, info)
+			}
+		}
+		_ = p.SendSnapshotInfo(infos)
+
+	case msg.Code == SnapshotInfoMsg:
+		if pm.snapsyncer == nil {
+			break
+		}
+
+		var infos []*snapsync.Info
+		if err := msg.Decode(&infos); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if len(infos) > 1 {
+			return errResp(ErrMsgTooLarge, "%v", msg)
+		}
+		if len(infos) != 0 {
+			_ = pm.snapsyncer.NotifyInfo(p.id, infos[0])
+		}
+
+	case msg.Code == GetStateNodesMsg:
+		var requests []common.Hash
+		if err := msg.Decode(&requests); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if err := checkLenLimits(len(requests), requests); err != nil {
+			return err
+		}
+		if !pm.config.Snapshot.Serve || pm.app == nil {
+			_ = p.SendStateNodes([][]byte{})
+			break
+		}
+		estimate := servingCost{
+			bytes: len(requests) * servingStateNodeSize,
+			reads: len(requests),
+		}
+		if estimate.bytes > softResponseLimitSize {
+			estimate.bytes = softResponseLimitSize
+		}
+		pm.serveRequest(p, estimate, func() (spent servingCost) {
+			nodes := make([][]byte, 0, len(requests))
+			for _, h := range requests {
+				spent.reads++
+				if node := pm.app.EvmStateNode(h); node != nil {
+					nodes = append(nodes, node)
+					spent.bytes += len(node)
+				}
+				if spent.bytes >= softResponseLimitSize {
+					break
+				}
+			}
+			// answer even if nothing is found, so the requests are released sooner
+			_ = p.SendStateNodes(nodes)
+			return
+		}, func() {
+			// empty response, so the peer doesn't wait for the nodes
+			_ = p.SendStateNodes([][]byte{})
+		})
+
+	case msg.Code == StateNodesMsg:
+		if pm.snapsyncer == nil {
+			break
+		}
+
+		var nodes [][]byte
+		if err := msg.Decode(&nodes); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if len(nodes) > hardLimitItems {
+			return errResp(ErrMsgTooLarge, "%v", msg)
+		}
+		_ = pm.snapsyncer.NotifyStateNodes(p.id, nodes)
+
+	case msg.Code == GetSnapshotSectionMsg:
+		var request getSnapshotSectionData
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if !pm.config.Snapshot.Serve {
+			break
+		}
+
+		// the snapshot is replaced under the lock
+		pm.engineMu.RLock()
+		info := pm.store.GetSnapshotInfo()
+		if info == nil || info.Epoch != request.Epoch {
+			pm.engineMu.RUnlock()
+			break
+		}
+		keys, vals, last, err := snapsync.ReadRange(pm.store.GetSnapshotSection(pm.store.GetSnapshotSlot(), request.Section), request.From, softLimitItems, softResponseLimitSize)
+		pm.engineMu.RUnlock()
+		if err != nil {
+			pm.Log.Error("Failed to read snapshot", "err", err)
+			break
+		}
+		_ = p.SendSnapshotSection(&snapshotSectionData{
+			Epoch:   request.Epoch,
+			Section: request.Section,
+			From:    request.From,
+			Keys:    keys,
+			Values:  vals,
+			Last:    last,
+		})
+
+	case msg.Code == SnapshotSectionMsg:
+		if pm.snapsyncer == nil {
+			break
+		}
+
+		var section snapshotSectionData
+		if err := msg.Decode(&section); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if len(section.Keys) > hardLimitItems || len(section.Values) > hardLimitItems {
+			return errResp(ErrMsgTooLarge, "%v", msg)
+		}
+		_ = pm.snapsyncer.NotifySection(p.id, section.Epoch, section.Section, section.From, section.Keys, section.Values, section.Last)
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -770,20 +1157,31 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 		txs = txs[:softLimitItems]
 	}
 
-	var txset = make(map[*peer]types.Transactions)
+	var (
+		txset = make(map[*peer]types.Transactions)
+		annos = make(map[*peer][]common.Hash)
+	)
 
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		peers := pm.peers.PeersWithoutTx(tx.Hash())
-		for _, peer := range peers {
-			txset[peer] = append(txset[peer], tx)
+		// send full transaction to a subset of peers, and announce it to the rest
+		fullRecipients := int(math.Sqrt(float64(len(peers))))
+		for i, peer := range peers {
+			if i < fullRecipients || peer.version < lachesis64 {
+				txset[peer] = append(txset[peer], tx)
+			} else {
+				annos[peer] = append(annos[peer], tx.Hash())
+			}
 		}
 		log.Trace("Broadcast transaction", "hash", tx.Hash(), "recipients", len(peers))
 	}
-	// FIXME include this again: peers = peers[:int(math.Sqrt(float64(len(peers))))]
 	for peer, txs := range txset {
 		peer.AsyncSendTransactions(txs)
 	}
+	for peer, hashes := range annos {
+		peer.AsyncSendTxHashes(hashes)
+	}
 }
 
 // Mined broadcast loop
@@ -8,8 +8,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Fantom-foundation/go-lachesis/app"
@@ -30,6 +33,12 @@ func TestGetEvents62(t *testing.T) {
 	testGetEvents(t, lachesis62)
 }
 
+// Tests that events can be retrieved compressed.
+func TestGetEvents65(t *testing.T) {
+	logger.SetTestMode(t)
+	testGetEvents(t, lachesis65)
+}
+
 func testGetEvents(t *testing.T, protocol int) {
 	assertar := assert.New(t)
 
@@ -90,7 +99,14 @@ func testGetEvents(t *testing.T, protocol int) {
 		if !assertar.NoError(p2p.Send(peer.app, GetEventsMsg, tt.query)) {
 			return
 		}
-		if err := p2p.ExpectMsg(peer.app, EventsMsg, tt.expect); err != nil {
+		var expect interface{} = tt.expect
+		if protocol >= lachesis65 {
+			// the payload is compressed
+			raw, err := rlp.EncodeToBytes(tt.expect)
+			assertar.NoError(err)
+			expect = snappy.Encode(nil, raw)
+		}
+		if err := p2p.ExpectMsg(peer.app, EventsMsg, expect); err != nil {
 			t.Errorf("test %d: events mismatch: %v", i, err)
 		}
 		if t.Failed() {
@@ -99,6 +115,91 @@ func testGetEvents(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that requests over the serving quota aren't served.
+func TestServingQuota(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	var events []*inter.Event
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, func(e *inter.Event) {
+		events = append(events, e)
+	})
+
+	peer, _ := newTestPeer("peer", lachesis62, pm, true)
+	defer peer.close()
+
+	clock := &mclock.Simulated{}
+	peer.servingQuota = newServingQuota(ServingLimits{BytesPerSec: 1, ReadsPerSec: 1}, clock)
+
+	// the first request is served
+	assertar.NoError(p2p.Send(peer.app, GetEventsMsg, []hash.Event{events[0].Hash()}))
+	assertar.NoError(p2p.ExpectMsg(peer.app, EventsMsg, []*inter.Event{events[0]}))
+
+	// the quota is exhausted for too long, so the second request gets an empty response
+	assertar.NoError(p2p.Send(peer.app, GetEventsMsg, []hash.Event{events[1].Hash()}))
+	assertar.NoError(p2p.ExpectMsg(peer.app, EventsMsg, []*inter.Event{}))
+
+	// the quota is refilled
+	clock.Run(time.Hour)
+	assertar.NoError(p2p.Send(peer.app, GetEventsMsg, []hash.Event{events[2].Hash()}))
+	assertar.NoError(p2p.ExpectMsg(peer.app, EventsMsg, []*inter.Event{events[2]}))
+}
+
+// Tests that requests over the serving quota are served later.
+func TestServingQuotaQueue(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	var events []*inter.Event
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, func(e *inter.Event) {
+		events = append(events, e)
+	})
+
+	peer, _ := newTestPeer("peer", lachesis62, pm, true)
+	defer peer.close()
+
+	clock := &mclock.Simulated{}
+	peer.servingQuota = newServingQuota(ServingLimits{ReadsPerSec: 1}, clock)
+
+	// the quota allows to serve 2 requests at once
+	for i := 0; i < 2; i++ {
+		assertar.NoError(p2p.Send(peer.app, GetEventsMsg, []hash.Event{events[i].Hash()}))
+		assertar.NoError(p2p.ExpectMsg(peer.app, EventsMsg, []*inter.Event{events[i]}))
+	}
+
+	// the third request waits for the quota
+	assertar.NoError(p2p.Send(peer.app, GetEventsMsg, []hash.Event{events[2].Hash()}))
+	go func() {
+		clock.WaitForTimers(1)
+		clock.Run(time.Second)
+	}()
+	assertar.NoError(p2p.ExpectMsg(peer.app, EventsMsg, []*inter.Event{events[2]}))
+}
+
+// Tests that EVM state nodes are served only if snapshots are served.
+func TestGetStateNodes(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, nil)
+	adb := app.NewMemStore()
+	state, _, err := adb.ApplyGenesis(&pm.config.Net)
+	assertar.NoError(err)
+	root := adb.EvmStateNode(state.Root)
+	assertar.NotNil(root)
+	pm.enableSnapshots(adb, nil)
+
+	peer, _ := newTestPeer("peer", lachesis63, pm, true)
+	defer peer.close()
+
+	assertar.NoError(p2p.Send(peer.app, GetStateNodesMsg, []common.Hash{state.Root}))
+	assertar.NoError(p2p.ExpectMsg(peer.app, StateNodesMsg, [][]byte{}))
+
+	pm.config.Snapshot.Serve = true
+	assertar.NoError(p2p.Send(peer.app, GetStateNodesMsg, []common.Hash{state.Root}))
+	assertar.NoError(p2p.ExpectMsg(peer.app, StateNodesMsg, [][]byte{root}))
+}
+
 func TestBroadcastEvent(t *testing.T) {
 	logger.SetTestMode(t)
 
@@ -142,7 +243,7 @@ func testBroadcastEvent(t *testing.T, totalPeers int, forcedAggressiveBroadcast
 
 	// create stores
 	app := app.NewMemStore()
-	state, _, err := app.ApplyGenesis(&net, nil)
+	state, _, err := app.ApplyGenesis(&net)
 	if !assertar.NoError(err) {
 		return
 	}
@@ -273,5 +374,5 @@ func mockCheckers(epoch idx.Epoch, net *lachesis.Config, engine Consensus, s *St
 	heavyCheckReader.Addrs.Store(ReadEpochPubKeys(a, epoch))
 	gasPowerCheckReader := &GasPowerCheckReader{}
 	gasPowerCheckReader.Ctx.Store(ReadGasPowerContext(s, a, engine.GetValidators(), engine.GetEpoch(), &net.Economy))
-	return makeCheckers(net, heavyCheckReader, gasPowerCheckReader, engine, s)
+	return MakeCheckers(net, heavyCheckReader, gasPowerCheckReader, engine, s)
 }
@@ -36,7 +36,7 @@ func newTestProtocolManager(nodesNum int, eventsNum int, newtx chan<- []*types.T
 	}
 
 	app := app.NewMemStore()
-	state, _, err := app.ApplyGenesis(&net, nil)
+	state, _, err := app.ApplyGenesis(&net)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -162,6 +162,9 @@ func (p *testPeer) handshake(t *testing.T, progress *PeerProgress, genesis commo
 		DummyTD:           big.NewInt(int64(progress.NumOfBlocks)), // for ETH clients
 		DummyCurrentBlock: common.Hash(progress.LastBlock),
 	}
+	if p.version >= lachesis65 {
+		msg.Features = []string{snappyFeature}
+	}
 	if err := p2p.ExpectMsg(p.app, EthStatusMsg, msg); err != nil {
 		t.Fatalf("status recv: %v", err)
 	}
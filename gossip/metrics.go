@@ -10,6 +10,18 @@ var (
 	confirmBlocksMeter = metrics.NewRegisteredCounter("confirm/blocks", nil)
 	confirmTxnsMeter   = metrics.NewRegisteredCounter("confirm/transactions", nil)
 	txTtfMeter         = metrics.NewRegisteredHistogram("tx_ttf", nil, metrics.NewUniformSample(500))
+	eventTtfMeter      = metrics.NewRegisteredHistogram("event_ttf", nil, metrics.NewUniformSample(500))
+
+	compressionRawEgressMeter  = metrics.NewRegisteredMeter("compression/egress/raw", nil)
+	compressionEgressMeter     = metrics.NewRegisteredMeter("compression/egress/compressed", nil)
+	compressionRawIngressMeter = metrics.NewRegisteredMeter("compression/ingress/raw", nil)
+	compressionIngressMeter    = metrics.NewRegisteredMeter("compression/ingress/compressed", nil)
+	compressionRatioHistogram  = metrics.NewRegisteredHistogram("compression/ratio", nil, metrics.NewUniformSample(500))
+
+	peerBanMeter = metrics.NewRegisteredMeter("p2p/bans", nil)
+
+	servingThrottledMeter = metrics.NewRegisteredMeter("serving/throttled", nil)
+	servingDroppedMeter   = metrics.NewRegisteredMeter("serving/dropped", nil)
 )
 
 var txLatency = meta.NewTxs()
@@ -1,13 +1,19 @@
 package ordering
 
 import (
+	"errors"
+	"runtime"
+
 	"github.com/hashicorp/golang-lru"
 
 	"github.com/Fantom-foundation/go-lachesis/eventcheck"
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/pipeline"
 )
 
+var errNotConnected = errors.New("event isn't connected")
+
 type (
 	// event is a inter.Event and data for ordering purpose.
 	event struct {
@@ -18,6 +24,8 @@ type (
 
 	// Callback is a set of EventBuffer()'s args.
 	Callback struct {
+		// Precalc is called concurrently for events with connected parents before Process (optional)
+		Precalc func(e *inter.Event)
 		Process func(e *inter.Event) error
 		Drop    func(e *inter.Event, peer string, err error)
 		Get     func(hash.Event) *inter.EventHeaderData
@@ -29,14 +37,26 @@ type (
 type EventBuffer struct {
 	incompletes *lru.Cache // event hash -> event
 	callback    Callback
+	workers     *pipeline.Workers // Precalc workers, nil if Precalc isn't set
 }
 
 func New(buffSize int, callback Callback) *EventBuffer {
 	incompletes, _ := lru.New(buffSize)
-	return &EventBuffer{
+	buf := &EventBuffer{
 		incompletes: incompletes,
 		callback:    callback,
 	}
+	if callback.Precalc != nil {
+		buf.workers = pipeline.NewWorkers(runtime.NumCPU())
+	}
+	return buf
+}
+
+// Stop terminates the Precalc workers. Events pushed after are precalculated by the caller.
+func (buf *EventBuffer) Stop() {
+	if buf.workers != nil {
+		buf.workers.Stop()
+	}
 }
 
 func (buf *EventBuffer) PushEvent(e *inter.Event, peer string) {
@@ -60,13 +80,65 @@ func (buf *EventBuffer) getIncompleteEventsList() []*event {
 	return res
 }
 
+// PushEvents takes a batch of events in any order. Events with connected parents
+// are checked and then precalculated by Precalc concurrently, while Process is called one by one.
+func (buf *EventBuffer) PushEvents(events inter.Events, peer string) {
+	if buf.callback.Precalc == nil || len(events) <= 1 {
+		for _, e := range events {
+			buf.PushEvent(e, peer)
+		}
+		return
+	}
+
+	connected := make(hash.Events, 0, len(events))
+	incomplete := pipeline.Run(buf.workers, events, pipeline.Callback{
+		Check: func(e *inter.Event) error {
+			if !buf.checkEvent(&event{Event: e, peer: peer}, true) {
+				return errNotConnected
+			}
+			return nil
+		},
+		Precalc: buf.callback.Precalc,
+		Process: func(e *inter.Event) error {
+			if !buf.processCheckedEvent(&event{Event: e, peer: peer}) {
+				return errNotConnected
+			}
+			connected.Add(e.Hash())
+			return nil
+		},
+		Exists: buf.callback.Exists,
+	})
+
+	// events with not connected parents are buffered
+	for _, e := range incomplete {
+		buf.processEvent(&event{Event: e, peer: peer}, true)
+	}
+	incompleteEventsList := buf.getIncompleteEventsList()
+	for _, id := range connected {
+		buf.pushChildren(id, incompleteEventsList)
+	}
+}
+
 func (buf *EventBuffer) pushEvent(e *event, incompleteEventsList []*event, strict bool) {
+	if !buf.processEvent(e, strict) {
+		return
+	}
+	buf.pushChildren(e.Hash(), incompleteEventsList)
+}
+
+// processEvent returns true if the event is connected.
+func (buf *EventBuffer) processEvent(e *event, strict bool) bool {
+	return buf.checkEvent(e, strict) && buf.processCheckedEvent(e)
+}
+
+// checkEvent returns true if the event's parents are connected and the event passed the checks.
+func (buf *EventBuffer) checkEvent(e *event, strict bool) bool {
 	// LRU is thread-safe, no need in mutex
 	if buf.callback.Exists(e.Hash()) {
 		if strict {
 			buf.callback.Drop(e.Event, e.peer, eventcheck.ErrAlreadyConnectedEvent)
 		}
-		return
+		return false
 	}
 
 	parents := make([]*inter.EventHeaderData, len(e.Parents)) // use local buffer for thread safety
@@ -75,7 +147,7 @@ func (buf *EventBuffer) pushEvent(e *event, incompleteEventsList []*event, stric
 		parent := buf.callback.Get(p)
 		if parent == nil {
 			buf.incompletes.Add(e.Hash(), e)
-			return
+			return false
 		}
 		parents[i] = parent
 	}
@@ -85,20 +157,27 @@ func (buf *EventBuffer) pushEvent(e *event, incompleteEventsList []*event, stric
 		err := buf.callback.Check(e.Event, parents)
 		if err != nil {
 			buf.callback.Drop(e.Event, e.peer, err)
-			return
+			return false
 		}
 	}
+	return true
+}
 
-	// process
+// processCheckedEvent returns true if the checked event is connected.
+func (buf *EventBuffer) processCheckedEvent(e *event) bool {
 	err := buf.callback.Process(e.Event)
 	if err != nil {
 		buf.callback.Drop(e.Event, e.peer, err)
-		return
+		return false
 	}
 
-	// now child events may become complete, check it again
-	eHash := e.Hash()
-	buf.incompletes.Remove(eHash)
+	buf.incompletes.Remove(e.Hash())
+	return true
+}
+
+// pushChildren pushes the buffered children of the connected event,
+// because child events may become complete now.
+func (buf *EventBuffer) pushChildren(eHash hash.Event, incompleteEventsList []*event) {
 	for _, child := range incompleteEventsList {
 		for _, parent := range child.Parents {
 			if parent == eHash {
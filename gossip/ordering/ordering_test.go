@@ -2,6 +2,7 @@ package ordering
 
 import (
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
@@ -72,3 +73,83 @@ func TestEventBuffer(t *testing.T) {
 		}
 	}
 }
+
+func TestEventBufferPushEvents(t *testing.T) {
+	nodes := inter.GenNodes(5)
+
+	var ordered inter.Events
+	r := rand.New(rand.NewSource(time.Now().Unix()))
+	_ = inter.ForEachRandEvent(nodes, 10, 3, r, inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			ordered = append(ordered, e)
+		},
+		Build: func(e *inter.Event, name string) *inter.Event {
+			e.Epoch = 1
+			e.ClaimedTime = inter.Timestamp(e.Seq)
+			return e
+		},
+	})
+
+	var precalcMu sync.Mutex
+	precalced := hash.EventsSet{}
+	processed := make(map[hash.Event]*inter.EventHeaderData)
+	buffer := New(len(nodes)*10, Callback{
+
+		Precalc: func(e *inter.Event) {
+			precalcMu.Lock()
+			defer precalcMu.Unlock()
+			precalced.Add(e.Hash())
+		},
+
+		Process: func(e *inter.Event) error {
+			if _, ok := processed[e.Hash()]; ok {
+				t.Fatalf("%s already processed", e.String())
+				return nil
+			}
+			for _, p := range e.Parents {
+				if _, ok := processed[p]; !ok {
+					t.Fatalf("got %s before parent %s", e.String(), p.String())
+					return nil
+				}
+			}
+			processed[e.Hash()] = &e.EventHeaderData
+			return nil
+		},
+
+		Drop: func(e *inter.Event, peer string, err error) {
+			t.Fatalf("%s unexpectedly dropped with %s", e.String(), err)
+		},
+
+		Exists: func(e hash.Event) bool {
+			return processed[e] != nil
+		},
+
+		Get: func(e hash.Event) *inter.EventHeaderData {
+			return processed[e]
+		},
+
+		Check: parentscheck.New(&lachesis.DagConfig{}).Validate,
+	})
+	defer buffer.Stop()
+
+	// push shuffled events by random batches
+	unordered := make(inter.Events, len(ordered))
+	for i, j := range rand.Perm(len(ordered)) {
+		unordered[j] = ordered[i]
+	}
+	for len(unordered) != 0 {
+		n := 1 + r.Intn(len(unordered))
+		buffer.PushEvents(unordered[:n], "")
+		unordered = unordered[n:]
+	}
+
+	// everything is processed
+	for _, e := range ordered {
+		if _, ok := processed[e.Hash()]; !ok {
+			t.Fatal("event wasn't processed")
+		}
+	}
+	if len(precalced) == 0 {
+		t.Fatal("events weren't precalculated")
+	}
+}
@@ -18,7 +18,7 @@ const (
 	maxPackEventsNum = softLimitItems
 )
 
-func (s *Service) packsOnNewEvent(e *inter.Event, epoch idx.Epoch) {
+func (s *Processor) packsOnNewEvent(e *inter.Event, epoch idx.Epoch) {
 	// due to default values, we don't need to explicitly set values at a start of an epoch
 	packIdx := s.store.GetPacksNumOrDefault(epoch)
 	packInfo := s.store.GetPackInfoOrDefault(s.engine.GetEpoch(), packIdx)
@@ -38,7 +38,7 @@ func (s *Service) packsOnNewEvent(e *inter.Event, epoch idx.Epoch) {
 	s.store.SetPackInfo(epoch, packIdx, packInfo)
 }
 
-func (s *Service) packsOnNewEpoch(oldEpoch, newEpoch idx.Epoch) {
+func (s *Processor) packsOnNewEpoch(oldEpoch, newEpoch idx.Epoch) {
 	// pin the last pack
 	packIdx := s.store.GetPacksNumOrDefault(oldEpoch)
 	packInfo := s.store.GetPackInfoOrDefault(s.engine.GetEpoch(), packIdx)
@@ -23,6 +23,7 @@ const (
 type PacksDownloader struct {
 	// Callbacks
 	dropPeer         dropPeerFn
+	peerTimeout      peerTimeoutFn
 	fetcher          *fetcher.Fetcher
 	onlyNotConnected onlyNotConnectedFn
 
@@ -34,11 +35,12 @@ type PacksDownloader struct {
 }
 
 // New creates a packs fetcher to retrieve events based on pack announcements.
-func New(fetcher *fetcher.Fetcher, onlyNotConnected onlyNotConnectedFn, dropPeer dropPeerFn) *PacksDownloader {
+func New(fetcher *fetcher.Fetcher, onlyNotConnected onlyNotConnectedFn, dropPeer dropPeerFn, peerTimeout peerTimeoutFn) *PacksDownloader {
 	return &PacksDownloader{
 		fetcher:          fetcher,
 		onlyNotConnected: onlyNotConnected,
 		dropPeer:         dropPeer,
+		peerTimeout:      peerTimeout,
 		peers:            make(map[string]*PeerPacksDownloader),
 		peersMu:          new(sync.RWMutex),
 	}
@@ -72,7 +74,7 @@ func (d *PacksDownloader) RegisterPeer(peer Peer, myEpoch idx.Epoch) error {
 	}
 
 	log.Trace("Registering sync peer", "peer", peer.ID, "epoch", myEpoch)
-	d.peers[peer.ID] = newPeer(peer, myEpoch, d.fetcher, d.onlyNotConnected, d.dropPeer)
+	d.peers[peer.ID] = newPeer(peer, myEpoch, d.fetcher, d.onlyNotConnected, d.dropPeer, d.peerTimeout)
 	d.peers[peer.ID].Start()
 
 	return nil
@@ -89,7 +91,7 @@ func (d *PacksDownloader) OnNewEpoch(myEpoch idx.Epoch, peerEpoch func(string) i
 
 		if peerEpoch(peerID) >= myEpoch {
 			// allocate new peer for the new epoch
-			newPeerDwnld := newPeer(peerDwnld.peer, myEpoch, d.fetcher, d.onlyNotConnected, d.dropPeer)
+			newPeerDwnld := newPeer(peerDwnld.peer, myEpoch, d.fetcher, d.onlyNotConnected, d.dropPeer, d.peerTimeout)
 			newPeerDwnld.Start()
 			newPeers[peerID] = newPeerDwnld
 		} else {
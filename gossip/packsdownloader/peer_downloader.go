@@ -42,6 +42,9 @@ type onlyNotConnectedFn func(ids hash.Events) hash.Events
 // dropPeerFn is a callback type for dropping a peer detected as malicious.
 type dropPeerFn func(peer string)
 
+// peerTimeoutFn is a callback type for reporting a peer which didn't answer a request in time.
+type peerTimeoutFn func(peer string)
+
 // request pack info from the peer
 type packInfoRequesterFn func(epoch idx.Epoch, indexes []idx.Pack) error
 
@@ -76,11 +79,13 @@ type PeerPacksDownloader struct {
 	notifyInfo     chan *packInfoData
 	notifyPacksNum chan *packsNumData
 	notifyPack     chan *packData
+	notifyEmpty    chan struct{}
 
 	quit chan struct{}
 
 	// Callbacks
 	dropPeer         dropPeerFn
+	peerTimeout      peerTimeoutFn
 	fetcher          *fetcher.Fetcher
 	onlyNotConnected onlyNotConnectedFn
 
@@ -93,14 +98,16 @@ type PeerPacksDownloader struct {
 	fetchingInfo map[idx.Pack]time.Time // the packs we've requested
 	fetchingFull map[idx.Pack]time.Time // the packs we've requested
 	prevRequest  time.Time              // time of prev. request to the peer
+	emptyInfos   time.Time              // time of the last response without pack infos
 }
 
 // New creates a packs fetcher to retrieve events based on pack announcements. Works only with 1 peer.
-func newPeer(peer Peer, myEpoch idx.Epoch, fetcher *fetcher.Fetcher, onlyNotConnected onlyNotConnectedFn, dropPeer dropPeerFn) *PeerPacksDownloader {
+func newPeer(peer Peer, myEpoch idx.Epoch, fetcher *fetcher.Fetcher, onlyNotConnected onlyNotConnectedFn, dropPeer dropPeerFn, peerTimeout peerTimeoutFn) *PeerPacksDownloader {
 	return &PeerPacksDownloader{
 		notifyInfo:       make(chan *packInfoData, maxQueuedInfos),
 		notifyPacksNum:   make(chan *packsNumData, maxQueuedInfos),
 		notifyPack:       make(chan *packData, maxQueuedFullPacks),
+		notifyEmpty:      make(chan struct{}, 1),
 		quit:             make(chan struct{}),
 		packInfos:        tree.NewWithIntComparator(),
 		fetchingInfo:     make(map[idx.Pack]time.Time),
@@ -110,6 +117,7 @@ func newPeer(peer Peer, myEpoch idx.Epoch, fetcher *fetcher.Fetcher, onlyNotConn
 		fetcher:          fetcher,
 		onlyNotConnected: onlyNotConnected,
 		dropPeer:         dropPeer,
+		peerTimeout:      peerTimeout,
 	}
 }
 
@@ -163,6 +171,15 @@ func (d *PeerPacksDownloader) NotifyPacksNum(epoch idx.Epoch, packsNum idx.Pack)
 	}
 }
 
+// NotifyEmptyPackInfos notifies that the peer responded without pack infos (e.g. the peer is throttled
+// by its serving quota), so the pack infos requested before aren't counted as timed out.
+func (d *PeerPacksDownloader) NotifyEmptyPackInfos() {
+	select {
+	case d.notifyEmpty <- struct{}{}:
+	default:
+	}
+}
+
 // NotifyPack injects new packs from a peer
 func (d *PeerPacksDownloader) NotifyPack(epoch idx.Epoch, index idx.Pack, ids hash.Events, time time.Time, fetchEvents fetcher.EventsRequesterFn) error {
 	if d.myEpoch != epoch {
@@ -229,6 +246,9 @@ func (d *PeerPacksDownloader) loop() {
 			d.tryToSync()
 			d.sweepKnown()
 
+		case <-d.notifyEmpty:
+			d.emptyInfos = time.Now()
+
 		case pack := <-d.notifyPack:
 			if d.myEpoch != pack.epoch {
 				continue // from another epoch
@@ -307,6 +327,10 @@ func (d *PeerPacksDownloader) timedRequestFullPack(index idx.Pack, pinned bool)
 func (d *PeerPacksDownloader) timedRequestPackInfo(index idx.Pack) {
 	prevRequestTime := d.fetchingInfo[index]
 	if prevRequestTime.IsZero() || time.Since(prevRequestTime) >= arriveTimeout {
+		if !prevRequestTime.IsZero() && d.emptyInfos.Before(prevRequestTime) {
+			// the requested pack info didn't arrive
+			d.peerTimeout(d.peer.ID)
+		}
 		err := d.peer.RequestPackInfos(d.myEpoch, []idx.Pack{index})
 		if err != nil {
 			log.Warn("Pack info request error", "index", index, "peer", d.peer.ID, "err", err)
@@ -7,6 +7,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 
 	tree "github.com/emirpasic/gods/maps/treemap"
 )
@@ -187,3 +188,29 @@ func testBinarySearch(t *testing.T, test *binarySearchTest) {
 		d.packsNum = idx.Pack(i)
 	}
 }
+
+func TestPackInfoTimeout(t *testing.T) {
+	assertar := assert.New(t)
+
+	timeouts := 0
+	d := newPeer(Peer{
+		ID: "peer",
+		RequestPackInfos: func(epoch idx.Epoch, indexes []idx.Pack) error {
+			return nil
+		},
+	}, 1, nil, nil, nil, func(peer string) {
+		timeouts++
+	})
+
+	// not answered request is penalized
+	d.timedRequestPackInfo(1)
+	d.fetchingInfo[1] = d.fetchingInfo[1].Add(-arriveTimeout)
+	d.timedRequestPackInfo(1)
+	assertar.Equal(1, timeouts)
+
+	// request answered by an empty response isn't penalized
+	d.emptyInfos = time.Now()
+	d.fetchingInfo[1] = d.fetchingInfo[1].Add(-arriveTimeout)
+	d.timedRequestPackInfo(1)
+	assertar.Equal(1, timeouts)
+}
@@ -11,8 +11,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rlp"
 
+	"github.com/Fantom-foundation/go-lachesis/gossip/snapsync"
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
@@ -33,6 +35,10 @@ const (
 	// contain a single transaction, or thousands.
 	maxQueuedTxs = 128
 
+	// maxQueuedTxAnns is the maximum number of transaction announcements to queue up
+	// before dropping broadcasts.
+	maxQueuedTxAnns = 128
+
 	// maxQueuedProps is the maximum number of event propagations to queue up before
 	// dropping broadcasts.
 	maxQueuedProps = 128
@@ -58,18 +64,23 @@ type peer struct {
 	*p2p.Peer
 	rw p2p.MsgReadWriter
 
-	version int // Protocol version negotiated
+	version     int  // Protocol version negotiated
+	compression bool // Whether events payloads are compressed
+
+	knownTxs     mapset.Set                // Set of transaction hashes known to be known by this peer
+	knownEvents  mapset.Set                // Set of event hashes known to be known by this peer
+	queuedTxs    chan []*types.Transaction // Queue of transactions to broadcast to the peer
+	queuedTxAnns chan []common.Hash        // Queue of transactions to announce to the peer
+	queuedProps  chan inter.Events         // Queue of events to broadcast to the peer
+	queuedAnns   chan hash.Events          // Queue of events to announce to the peer
+	term         chan struct{}             // Termination channel to stop the broadcaster
 
-	knownTxs    mapset.Set                // Set of transaction hashes known to be known by this peer
-	knownEvents mapset.Set                // Set of event hashes known to be known by this peer
-	queuedTxs   chan []*types.Transaction // Queue of transactions to broadcast to the peer
-	queuedProps chan inter.Events         // Queue of events to broadcast to the peer
-	queuedAnns  chan hash.Events          // Queue of events to announce to the peer
-	term        chan struct{}             // Termination channel to stop the broadcaster
+	queuedServing chan *servingRequest // Queue of the peer's requests which wait for the serving quotas
 
 	progress PeerProgress
 
-	poolEntry *poolEntry
+	poolEntry    *poolEntry
+	servingQuota *servingQuota
 
 	sync.RWMutex
 }
@@ -105,19 +116,27 @@ func (a *PeerProgress) Less(b PeerProgress) bool {
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	return &peer{
-		Peer:        p,
-		rw:          rw,
-		version:     version,
-		id:          fmt.Sprintf("%x", p.ID().Bytes()[:8]),
-		knownTxs:    mapset.NewSet(),
-		knownEvents: mapset.NewSet(),
-		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
-		queuedProps: make(chan inter.Events, maxQueuedProps),
-		queuedAnns:  make(chan hash.Events, maxQueuedAnns),
-		term:        make(chan struct{}),
+		Peer:         p,
+		rw:           rw,
+		version:      version,
+		id:           peerID(p.ID()),
+		knownTxs:     mapset.NewSet(),
+		knownEvents:  mapset.NewSet(),
+		queuedTxs:    make(chan []*types.Transaction, maxQueuedTxs),
+		queuedTxAnns: make(chan []common.Hash, maxQueuedTxAnns),
+		queuedProps:  make(chan inter.Events, maxQueuedProps),
+		queuedAnns:   make(chan hash.Events, maxQueuedAnns),
+		term:         make(chan struct{}),
+
+		queuedServing: make(chan *servingRequest, maxQueuedServing),
 	}
 }
 
+// peerID returns the short peer identifier of the node.
+func peerID(id enode.ID) string {
+	return fmt.Sprintf("%x", id[:8])
+}
+
 // broadcast is a write loop that multiplexes event propagations, announcements
 // and transaction broadcasts into the remote peer. The goal is to have an async
 // writer that does not lock up node internals.
@@ -130,6 +149,12 @@ func (p *peer) broadcast() {
 			}
 			p.Log().Trace("Broadcast transactions", "count", len(txs))
 
+		case hashes := <-p.queuedTxAnns:
+			if err := p.SendTxHashes(hashes); err != nil {
+				return
+			}
+			p.Log().Trace("Broadcast transaction hashes", "count", len(hashes))
+
 		case events := <-p.queuedProps:
 			if err := p.SendEvents(events); err != nil {
 				return
@@ -148,7 +173,27 @@ func (p *peer) broadcast() {
 	}
 }
 
-// close signals the broadcast goroutine to terminate.
+// serve is a loop that serves the peer's requests which wait for the serving quotas.
+func (p *peer) serve() {
+	for {
+		select {
+		case req := <-p.queuedServing:
+			if delay := time.Duration(req.at - p.servingQuota.clock.Now()); delay > 0 {
+				select {
+				case <-p.servingQuota.clock.After(delay):
+				case <-p.term:
+					return
+				}
+			}
+			req.serve()
+
+		case <-p.term:
+			return
+		}
+	}
+}
+
+// close signals the broadcast and serve goroutines to terminate.
 func (p *peer) close() {
 	close(p.term)
 }
@@ -212,6 +257,36 @@ func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
 	}
 }
 
+// SendTxHashes announces the availability of a number of transactions through
+// a hash notification.
+func (p *peer) SendTxHashes(hashes []common.Hash) error {
+	// Mark all the transaction hashes as known, but ensure we don't overflow our limits
+	for _, h := range hashes {
+		p.knownTxs.Add(h)
+	}
+	for p.knownTxs.Cardinality() >= maxKnownTxs {
+		p.knownTxs.Pop()
+	}
+	return p2p.Send(p.rw, NewEvmTxHashesMsg, hashes)
+}
+
+// AsyncSendTxHashes queues the availability of transactions for propagation to a
+// remote peer. If the peer's broadcast queue is full, the hashes are silently dropped.
+func (p *peer) AsyncSendTxHashes(hashes []common.Hash) {
+	select {
+	case p.queuedTxAnns <- hashes:
+		// Mark all the transaction hashes as known, but ensure we don't overflow our limits
+		for _, h := range hashes {
+			p.knownTxs.Add(h)
+		}
+		for p.knownTxs.Cardinality() >= maxKnownTxs {
+			p.knownTxs.Pop()
+		}
+	default:
+		p.Log().Debug("Dropping transaction announcement", "count", len(hashes))
+	}
+}
+
 // SendNewEventHashes announces the availability of a number of events through
 // a hash notification.
 func (p *peer) SendNewEventHashes(hashes []hash.Event) error {
@@ -252,7 +327,7 @@ func (p *peer) SendEvents(events inter.Events) error {
 			p.knownEvents.Pop()
 		}
 	}
-	return p2p.Send(p.rw, EventsMsg, events)
+	return p.send(EventsMsg, events)
 }
 
 func (p *peer) SendEventsRLP(events []rlp.RawValue, ids []hash.Event) error {
@@ -263,7 +338,7 @@ func (p *peer) SendEventsRLP(events []rlp.RawValue, ids []hash.Event) error {
 			p.knownEvents.Pop()
 		}
 	}
-	return p2p.Send(p.rw, EventsMsg, events)
+	return p.send(EventsMsg, events)
 }
 
 func (p *peer) SendPackInfosRLP(packInfos *packInfosDataRLP) error {
@@ -271,7 +346,7 @@ func (p *peer) SendPackInfosRLP(packInfos *packInfosDataRLP) error {
 }
 
 func (p *peer) SendPack(pack *packData) error {
-	return p2p.Send(p.rw, PackMsg, pack)
+	return p.send(PackMsg, pack)
 }
 
 // AsyncSendEvents queues an entire event for propagation to a remote peer. If
@@ -333,6 +408,22 @@ func (p *peer) RequestEvents(ids hash.Events) error {
 	return nil
 }
 
+func (p *peer) RequestTxs(hashes []common.Hash) error {
+	// divide big batch into smaller ones
+	for start := 0; start < len(hashes); start += softLimitItems {
+		end := len(hashes)
+		if end > start+softLimitItems {
+			end = start + softLimitItems
+		}
+		p.Log().Debug("Fetching batch of transactions", "count", len(hashes[start:end]))
+		err := p2p.Send(p.rw, GetEvmTxsMsg, hashes[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *peer) RequestPackInfos(epoch idx.Epoch, indexes []idx.Pack) error {
 	return p2p.Send(p.rw, GetPackInfosMsg, getPackInfosData{
 		Epoch:   epoch,
@@ -347,22 +438,55 @@ func (p *peer) RequestPack(epoch idx.Epoch, index idx.Pack) error {
 	})
 }
 
+func (p *peer) RequestSnapshotInfo() error {
+	return p2p.Send(p.rw, GetSnapshotInfoMsg, struct{}{})
+}
+
+func (p *peer) SendSnapshotInfo(infos []*snapsync.Info) error {
+	return p2p.Send(p.rw, SnapshotInfoMsg, infos)
+}
+
+func (p *peer) RequestStateNodes(hashes []common.Hash) error {
+	p.Log().Debug("Fetching batch of state nodes", "count", len(hashes))
+	return p2p.Send(p.rw, GetStateNodesMsg, hashes)
+}
+
+func (p *peer) SendStateNodes(nodes [][]byte) error {
+	return p2p.Send(p.rw, StateNodesMsg, nodes)
+}
+
+func (p *peer) RequestSnapshotSection(epoch idx.Epoch, section byte, from []byte) error {
+	return p2p.Send(p.rw, GetSnapshotSectionMsg, getSnapshotSectionData{
+		Epoch:   epoch,
+		Section: section,
+		From:    from,
+	})
+}
+
+func (p *peer) SendSnapshotSection(section *snapshotSectionData) error {
+	return p2p.Send(p.rw, SnapshotSectionMsg, section)
+}
+
 // Handshake executes the protocol handshake, negotiating version number,
 // network IDs, difficulties, head and genesis object.
-func (p *peer) Handshake(network uint64, progress PeerProgress, genesis common.Hash) error {
+func (p *peer) Handshake(network uint64, progress PeerProgress, genesis common.Hash, features []string) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
 	var status ethStatusData // safe to read after two values have been received from errc
 
 	go func() {
 		// send both EthStatusMsg and ProgressMsg, eth62 clients will understand only status
-		err := p2p.Send(p.rw, EthStatusMsg, &ethStatusData{
+		myStatus := &ethStatusData{
 			ProtocolVersion:   uint32(p.version),
 			NetworkID:         network,
 			Genesis:           genesis,
 			DummyTD:           big.NewInt(int64(progress.NumOfBlocks)), // for ETH clients
 			DummyCurrentBlock: common.Hash(progress.LastBlock),
-		})
+		}
+		if p.version >= lachesis65 {
+			myStatus.Features = features
+		}
+		err := p2p.Send(p.rw, EthStatusMsg, myStatus)
 		if err != nil {
 			errc <- err
 		}
@@ -384,9 +508,39 @@ func (p *peer) Handshake(network uint64, progress PeerProgress, genesis common.H
 			return p2p.DiscReadTimeout
 		}
 	}
+
+	// enable the features which both peers support
+	if p.version >= lachesis65 {
+		p.compression = hasFeature(features, snappyFeature) && hasFeature(status.Features, snappyFeature)
+	}
 	return nil
 }
 
+func hasFeature(features []string, feature string) bool {
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// send sends the message, compressing the payload if it's negotiated.
+func (p *peer) send(code uint64, data interface{}) error {
+	if p.compression && isCompressible(code) {
+		return sendCompressed(p.rw, code, data)
+	}
+	return p2p.Send(p.rw, code, data)
+}
+
+// decode decodes the message payload, decompressing it if it's negotiated.
+func (p *peer) decode(msg p2p.Msg, val interface{}) error {
+	if p.compression && isCompressible(msg.Code) {
+		return decodeCompressed(msg, val)
+	}
+	return msg.Decode(val)
+}
+
 func (p *peer) SendProgress(progress PeerProgress) error {
 	return p2p.Send(p.rw, ProgressMsg, progress)
 }
@@ -455,6 +609,7 @@ func (ps *peerSet) Register(p *peer) error {
 	}
 	ps.peers[p.id] = p
 	go p.broadcast()
+	go p.serve()
 
 	return nil
 }
@@ -18,7 +18,7 @@ func PoiPeriod(t inter.Timestamp, config *lachesis.EconomyConfig) uint64 {
 }
 
 // UpdateAddressPOI calculate and save POI for user
-func (s *Service) UpdateAddressPOI(address common.Address, senderTotalFee *big.Int, poiPeriod uint64) {
+func (s *Processor) UpdateAddressPOI(address common.Address, senderTotalFee *big.Int, poiPeriod uint64) {
 	/*if senderTotalFee.Sign() == 0 {
 		s.store.SetAddressPOI(address, common.Big0)
 		return // avoid division by 0
@@ -29,7 +29,7 @@ func (s *Service) UpdateAddressPOI(address common.Address, senderTotalFee *big.I
 }
 
 // updateUsersPOI calculates the Proof Of Importance weights for users
-func (s *Service) updateUsersPOI(block *inter.Block, evmBlock *evmcore.EvmBlock, receipts types.Receipts, totalFee *big.Int, sealEpoch bool) {
+func (s *Processor) updateUsersPOI(block *inter.Block, evmBlock *evmcore.EvmBlock, receipts types.Receipts, totalFee *big.Int, sealEpoch bool) {
 	// User POI calculations
 	poiPeriod := PoiPeriod(block.Time, &s.config.Net.Economy)
 	s.app.AddPoiFee(poiPeriod, totalFee)
@@ -74,7 +74,7 @@ func (s *Service) updateUsersPOI(block *inter.Block, evmBlock *evmcore.EvmBlock,
 }
 
 // UpdateStakerPOI calculate and save POI for staker
-func (s *Service) UpdateStakerPOI(stakerID idx.StakerID, stakerAddress common.Address, poiPeriod uint64) {
+func (s *Processor) UpdateStakerPOI(stakerID idx.StakerID, stakerAddress common.Address, poiPeriod uint64) {
 	staker := s.app.GetSfcStaker(stakerID)
 
 	vFee := s.app.GetAddressFee(stakerAddress, poiPeriod)
@@ -100,7 +100,7 @@ func (s *Service) UpdateStakerPOI(stakerID idx.StakerID, stakerAddress common.Ad
 }
 
 // updateStakersPOI calculates the Proof Of Importance weights for stakers
-func (s *Service) updateStakersPOI(block *inter.Block, sealEpoch bool) {
+func (s *Processor) updateStakersPOI(block *inter.Block, sealEpoch bool) {
 	// Stakers POI calculations
 	poiPeriod := PoiPeriod(block.Time, &s.config.Net.Economy)
 	prevBlockPoiPeriod := PoiPeriod(s.store.GetBlock(block.Index-1).Time, &s.config.Net.Economy)
@@ -7,6 +7,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
 	"github.com/Fantom-foundation/go-lachesis/vector"
 )
 
@@ -24,6 +25,15 @@ func (hook *HookedEngine) ProcessEvent(e *inter.Event) error {
 	return hook.processEvent(hook.engine, e)
 }
 
+// PrecalcEvent calculates consensus data of the event ahead of ProcessEvent.
+// PrecalcEvent is safe for concurrent use.
+func (hook *HookedEngine) PrecalcEvent(e *inter.Event) {
+	if hook.engine == nil {
+		return
+	}
+	hook.engine.PrecalcEvent(e)
+}
+
 // GetVectorIndex returns vector clock.
 func (hook *HookedEngine) GetVectorIndex() *vector.Index {
 	if hook.engine == nil {
@@ -89,6 +99,46 @@ func (hook *HookedEngine) GetConsensusTime(id hash.Event) (inter.Timestamp, erro
 	return hook.engine.GetConsensusTime(id)
 }
 
+// GetBlockCertificate returns certificate of the block Atropos decision.
+func (hook *HookedEngine) GetBlockCertificate(n idx.Block) *election.Certificate {
+	if hook.engine == nil {
+		return nil
+	}
+	return hook.engine.GetBlockCertificate(n)
+}
+
+// GetEventConfirmedOn returns frame which event is confirmed on, or 0 if event isn't confirmed.
+func (hook *HookedEngine) GetEventConfirmedOn(id hash.Event) idx.Frame {
+	if hook.engine == nil {
+		return 0
+	}
+	return hook.engine.GetEventConfirmedOn(id)
+}
+
+// EpochSnapshot returns the consensus state at the current epoch start, or nil if the epoch is started already.
+func (hook *HookedEngine) EpochSnapshot() []byte {
+	if hook.engine == nil {
+		return nil
+	}
+	return hook.engine.EpochSnapshot()
+}
+
+// VerifyEpochSnapshot checks the epoch snapshot against the last block, without applying it.
+func (hook *HookedEngine) VerifyEpochSnapshot(raw []byte, lastBlock *inter.Block) error {
+	if hook.engine == nil {
+		return nil
+	}
+	return hook.engine.VerifyEpochSnapshot(raw, lastBlock)
+}
+
+// ApplyEpochSnapshot moves the consensus to the epoch snapshot, which follows the last block.
+func (hook *HookedEngine) ApplyEpochSnapshot(raw []byte, lastBlock *inter.Block) error {
+	if hook.engine == nil {
+		return nil
+	}
+	return hook.engine.ApplyEpochSnapshot(raw, lastBlock)
+}
+
 // Bootstrap restores poset's state from store.
 func (hook *HookedEngine) Bootstrap(callbacks inter.ConsensusCallbacks) {
 	if hook.engine == nil {
@@ -0,0 +1,114 @@
+package gossip
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/hashicorp/golang-lru"
+
+	"github.com/Fantom-foundation/go-lachesis/app"
+	"github.com/Fantom-foundation/go-lachesis/eventcheck"
+	"github.com/Fantom-foundation/go-lachesis/gossip/occuredtxs"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+// ProcessorHooks are the node-specific actions on events, blocks and epochs processing.
+type ProcessorHooks struct {
+	// OnNewEvent is called after event is connected
+	OnNewEvent func(e *inter.Event)
+	// OnNewBlock is called after block is applied
+	OnNewBlock func(block *inter.Block, cheaters inter.Cheaters)
+	// OnNewEpoch is called after event which has sealed the epoch is connected
+	OnNewEpoch func(newValidators *pos.Validators, newEpoch idx.Epoch)
+}
+
+// Processor is the application part of the node: it connects events and applies the decided blocks
+// (txs execution, SFC logic, epoch sealing). It has no network parts,
+// so the chain is processed the same way by Service and by the offline tools.
+type Processor struct {
+	config *Config
+
+	store               *Store
+	app                 *app.Store
+	engine              Consensus
+	engineMu            *sync.RWMutex
+	occurredTxs         *occuredtxs.Buffer
+	heavyCheckReader    HeavyCheckReader
+	gasPowerCheckReader GasPowerCheckReader
+	checkers            *eventcheck.Checkers
+
+	// global variables. TODO refactor to pass them as arguments if possible
+	blockParticipated map[idx.StakerID]bool // validators who participated in last block
+	currentEvent      hash.Event            // current event which is being processed
+
+	// eventArrivals is a local arrival time of recent events, for event_ttf metric only.
+	// It doesn't depend on EventLocalTimeIndex, which is off by default.
+	eventArrivals *lru.Cache // event hash -> arrival time
+
+	snapshotWg   sync.WaitGroup // background making of the epoch snapshot
+	snapshotting uint32
+
+	feed  ServiceFeed
+	hooks ProcessorHooks
+
+	logger.Instance
+}
+
+// NewProcessor wraps the engine to process events into the stores, and bootstraps it.
+func NewProcessor(config *Config, store *Store, engine Consensus, app *app.Store, hooks ProcessorHooks) *Processor {
+	p := &Processor{
+		config: config,
+
+		store: store,
+		app:   app,
+
+		engineMu:          new(sync.RWMutex),
+		occurredTxs:       occuredtxs.New(txsRingBufferSize, types.NewEIP155Signer(config.Net.EvmChainConfig().ChainID)),
+		blockParticipated: make(map[idx.StakerID]bool),
+
+		hooks: hooks,
+
+		Instance: logger.MakeInstance(),
+	}
+	p.eventArrivals, _ = lru.New(eventArrivalsBufferSize)
+
+	// wrap engine
+	p.engine = &HookedEngine{
+		engine:       engine,
+		processEvent: p.processEvent,
+	}
+	p.engine.Bootstrap(inter.ConsensusCallbacks{
+		ApplyBlock:              p.applyBlock,
+		SelectValidatorsGroup:   p.selectValidatorsGroup,
+		OnEventConfirmed:        p.onEventConfirmed,
+		IsEventAllowedIntoBlock: p.isEventAllowedIntoBlock,
+		OnForkDetected:          p.onForkDetected,
+	})
+
+	// create checkers
+	p.heavyCheckReader.Addrs.Store(ReadEpochPubKeys(p.app, p.engine.GetEpoch()))                                                               // read pub keys of current epoch from disk
+	p.gasPowerCheckReader.Ctx.Store(ReadGasPowerContext(p.store, p.app, p.engine.GetValidators(), p.engine.GetEpoch(), &p.config.Net.Economy)) // read gaspower check data from disk
+	p.checkers = MakeCheckers(&p.config.Net, &p.heavyCheckReader, &p.gasPowerCheckReader, p.engine, p.store)
+
+	return p
+}
+
+// Engine returns the wrapped engine. Its ProcessEvent connects event into the stores and applies the decided blocks.
+// EngineMu must be locked while the engine is used.
+func (s *Processor) Engine() Consensus {
+	return s.engine
+}
+
+// EngineMu returns the mutex of engine and stores.
+func (s *Processor) EngineMu() *sync.RWMutex {
+	return s.engineMu
+}
+
+// Checkers returns the event checkers, which are updated on each new epoch.
+func (s *Processor) Checkers() *eventcheck.Checkers {
+	return s.checkers
+}
@@ -16,16 +16,19 @@ import (
 // Constants to match up protocol versions and messages
 const (
 	lachesis62 = 62 // derived from eth62
+	lachesis63 = 63 // lachesis62 with snapshot sync
+	lachesis64 = 64 // lachesis63 with transactions announcements, derived from eth65
+	lachesis65 = 65 // lachesis64 with negotiated compression of events
 )
 
 // protocolName is the official short name of the protocol used during capability negotiation.
 const protocolName = "lachesis"
 
 // ProtocolVersions are the supported versions of the protocol (first is primary).
-var ProtocolVersions = []uint{lachesis62}
+var ProtocolVersions = []uint{lachesis65, lachesis64, lachesis63, lachesis62}
 
 // protocolLengths are the number of implemented message corresponding to different protocol versions.
-var protocolLengths = map[uint]uint64{lachesis62: PackMsg + 1}
+var protocolLengths = map[uint]uint64{lachesis65: SnapshotSectionMsg + 1, lachesis64: SnapshotSectionMsg + 1, lachesis63: SnapshotSectionMsg + 1, lachesis62: PackMsg + 1}
 
 const protocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -35,6 +38,13 @@ const (
 	EthStatusMsg = 0x00
 	EvmTxMsg     = 0x02
 
+	// Protocol messages belonging to eth/65, supported since lachesis/64
+
+	// Non-aggressive transactions propagation. Signals about new transactions, sending only their hashes.
+	NewEvmTxHashesMsg = 0x08
+	// Request the batch of pooled transactions by hashes. An answer is EvmTxMsg.
+	GetEvmTxsMsg = 0x09
+
 	// Protocol messages belonging to lachesis/62
 
 	// Signals about the current synchronization status.
@@ -62,6 +72,23 @@ const (
 	GetPackMsg = 0xf6
 	// Contains the requested pack. An answer to GetPackMsg.
 	PackMsg = 0xf7
+
+	// Protocol messages belonging to lachesis/63
+
+	// Request info of the latest snapshot of a sealed epoch
+	GetSnapshotInfoMsg = 0xf8
+	// Contains the snapshot info. An answer to GetSnapshotInfoMsg.
+	SnapshotInfoMsg = 0xf9
+
+	// Request EVM state trie nodes or contract codes by hashes
+	GetStateNodesMsg = 0xfa
+	// Contains the requested state nodes. An answer to GetStateNodesMsg.
+	StateNodesMsg = 0xfb
+
+	// Request a range of key-value pairs of the snapshot section
+	GetSnapshotSectionMsg = 0xfc
+	// Contains the requested range. An answer to GetSnapshotSectionMsg.
+	SnapshotSectionMsg = 0xfd
 )
 
 type errCode int
@@ -101,6 +128,9 @@ type txPool interface {
 	// AddRemotes should add the given transactions to the pool.
 	AddRemotes([]*types.Transaction) []error
 
+	// Get should return a pooled transaction by hash, or nil if not found.
+	Get(hash common.Hash) *types.Transaction
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
@@ -117,6 +147,9 @@ type ethStatusData struct {
 	DummyTD           *big.Int
 	DummyCurrentBlock common.Hash
 	Genesis           common.Hash
+	// Features are supported optional capabilities, e.g. compression.
+	// Sent only since lachesis65, for compatibility with eth62 clients.
+	Features []string `rlp:"tail"`
 }
 
 // PeerProgress is synchronization status of a peer
@@ -154,3 +187,18 @@ type packData struct {
 	Index idx.Pack
 	IDs   hash.Events
 }
+
+type getSnapshotSectionData struct {
+	Epoch   idx.Epoch
+	Section byte
+	From    []byte
+}
+
+type snapshotSectionData struct {
+	Epoch   idx.Epoch
+	Section byte
+	From    []byte
+	Keys    [][]byte
+	Values  [][]byte
+	Last    bool
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/stretchr/testify/assert"
 
+	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/lachesis"
 	"github.com/Fantom-foundation/go-lachesis/logger"
 )
@@ -167,3 +169,142 @@ func testSendTransactions(t *testing.T, protocol int) {
 	}
 	wg.Wait()
 }
+
+// This test checks that pending transactions are announced by hashes, and then sent on request.
+func TestSendTxHashes64(t *testing.T) {
+	logger.SetTestMode(t)
+
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, nil)
+	defer pm.Stop()
+
+	alltxs := make([]*types.Transaction, 10)
+	for nonce := range alltxs {
+		alltxs[nonce] = newTestTransaction(testAccount, uint64(nonce), 0)
+	}
+	pm.txpool.AddRemotes(alltxs)
+
+	p, _ := newTestPeer("peer", lachesis64, pm, true)
+	defer p.close()
+
+	// receive announces
+	seen := make(map[common.Hash]bool)
+	for len(seen) < len(alltxs) {
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		if msg.Code != NewEvmTxHashesMsg {
+			t.Fatalf("got code %d, want NewEvmTxHashesMsg", msg.Code)
+		}
+		var hashes []common.Hash
+		if err := msg.Decode(&hashes); err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range hashes {
+			seen[h] = true
+		}
+	}
+	for _, tx := range alltxs {
+		if !seen[tx.Hash()] {
+			t.Errorf("tx isn't announced: %x", tx.Hash())
+		}
+	}
+
+	// request the txs
+	if err := p2p.Send(p.app, GetEvmTxsMsg, []common.Hash{alltxs[1].Hash(), alltxs[3].Hash()}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	msg, err := p.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if msg.Code != EvmTxMsg {
+		t.Fatalf("got code %d, want EvmTxMsg", msg.Code)
+	}
+	var txs []*types.Transaction
+	if err := msg.Decode(&txs); err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 || txs[0].Hash() != alltxs[1].Hash() || txs[1].Hash() != alltxs[3].Hash() {
+		t.Errorf("got wrong txs: %v", txs)
+	}
+}
+
+// This test checks that announced transactions are requested.
+func TestRecvTxHashes64(t *testing.T) {
+	logger.SetTestMode(t)
+
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, nil)
+	pm.synced = 1 // mark synced to accept transactions
+	p, _ := newTestPeer("peer", lachesis64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, NewEvmTxHashesMsg, []common.Hash{tx.Hash()}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	// the tx is requested after a time allowance for a direct broadcast, skip other messages
+	var msg p2p.Msg
+	for msg.Code != GetEvmTxsMsg {
+		if msg.Payload != nil {
+			_ = msg.Discard()
+		}
+		var err error
+		msg, err = p.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+	}
+	var hashes []common.Hash
+	if err := msg.Decode(&hashes); err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 1 || hashes[0] != tx.Hash() {
+		t.Errorf("requested wrong hashes: %v", hashes)
+	}
+}
+
+// This test checks that only compressed events are accepted from a peer which negotiated compression.
+func TestRecvCompressedEvents65(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, nil)
+	p, errc := newTestPeer("peer", lachesis65, pm, true)
+	defer pm.Stop()
+	defer p.close()
+	assertar.True(p.compression)
+
+	// uncompressed payload isn't accepted
+	if err := p2p.Send(p.app, EventsMsg, []interface{}{}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case <-time.After(2 * time.Second):
+		t.Errorf("peer isn't dropped")
+	case err := <-errc:
+		assertar.Error(err)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	assertar := assert.New(t)
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	events := []*inter.Event{inter.NewEvent(), inter.NewEvent()}
+	go func() {
+		assertar.NoError(sendCompressed(net, EventsMsg, events))
+	}()
+	msg, err := app.ReadMsg()
+	assertar.NoError(err)
+
+	var got []*inter.Event
+	assertar.NoError(decodeCompressed(msg, &got))
+	assertar.Equal(len(events), len(got))
+	for i := range events {
+		assertar.Equal(events[i].Hash(), got[i].Hash())
+	}
+}
@@ -0,0 +1,303 @@
+package gossip
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+const (
+	// reputationDbPrefix is the key prefix of peer bans in the server pool DB
+	reputationDbPrefix = "reputation/"
+
+	maxReputation = 100  // Maximum score which a peer may accumulate by useful deliveries
+	banReputation = -100 // Score at which a peer gets banned temporarily
+
+	usefulDeliveryReward = 1   // Reward for a delivery of events which passed the checks
+	timeoutPenalty       = 10  // Penalty for a not answered request
+	invalidEventPenalty  = 100 // Penalty for an event which didn't pass the checks
+
+	tempBanPeriod     = 10 * time.Minute // Duration of the first temporary ban, doubled with every next ban
+	permanentBanAfter = 5                // Number of temporary bans after which the peer gets banned permanently
+
+	maxReasons      = 8    // Number of the last reputation changes remembered per peer
+	maxTrackedPeers = 4096 // Number of peers tracked in memory, ban records of the forgotten ones are kept in DB
+)
+
+// peerBan is a persistent ban record of a peer.
+type peerBan struct {
+	Until     uint64 // Unix time (in seconds) of the ban end, not used for a permanent ban
+	Permanent bool
+	Bans      uint32 // Number of the peer's bans
+	Reason    string
+}
+
+// peerReputation is a reputation of a peer.
+type peerReputation struct {
+	score   int64
+	reasons []string
+	ban     peerBan
+}
+
+// PeerReputationInfo is a reputation of a peer, exposed over RPC.
+type PeerReputationInfo struct {
+	ID          enode.ID   `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	Connected   bool       `json:"connected"`
+	Score       int64      `json:"score"`
+	Banned      bool       `json:"banned"`
+	Permanent   bool       `json:"permanent"`
+	BannedUntil *time.Time `json:"bannedUntil,omitempty"`
+	Bans        uint32     `json:"bans"`
+	BanReason   string     `json:"banReason,omitempty"`
+	Reasons     []string   `json:"reasons"`
+}
+
+func (r *peerReputation) banned(now time.Time) bool {
+	return r.ban.Permanent || uint64(now.Unix()) < r.ban.Until
+}
+
+func (r *peerReputation) addReason(reason string) {
+	r.reasons = append(r.reasons, reason)
+	if len(r.reasons) > maxReasons {
+		r.reasons = r.reasons[len(r.reasons)-maxReasons:]
+	}
+}
+
+// reputationSet tracks reputations of peers by enode IDs.
+// Peer bans are persisted in the server pool DB, and are loaded back if the peer was forgotten.
+type reputationSet struct {
+	db kvdb.KeyValueStore
+
+	peers map[enode.ID]*peerReputation
+	mu    sync.Mutex
+}
+
+func newReputationSet(db kvdb.KeyValueStore) *reputationSet {
+	rs := &reputationSet{
+		db:    db,
+		peers: make(map[enode.ID]*peerReputation),
+	}
+	rs.loadBans()
+	return rs
+}
+
+// Banned returns true if the peer is banned at the moment.
+func (rs *reputationSet) Banned(id enode.ID) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r := rs.peers[id]
+	if r == nil {
+		r = rs.load(id)
+	}
+	return r != nil && r.banned(time.Now())
+}
+
+// Reward increases the peer's reputation for useful deliveries.
+func (rs *reputationSet) Reward(id enode.ID, points int64) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r := rs.get(id)
+	r.score += points
+	if r.score > maxReputation {
+		r.score = maxReputation
+	}
+}
+
+// Penalize decreases the peer's reputation, and bans the peer if the reputation is too low.
+// Returns true if the peer is banned.
+func (rs *reputationSet) Penalize(id enode.ID, points int64, reason string) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	now := time.Now()
+	r := rs.get(id)
+	if r.banned(now) {
+		return true
+	}
+	r.score -= points
+	r.addReason(reason)
+	if r.score > banReputation {
+		return false
+	}
+
+	r.ban.Bans++
+	if r.ban.Bans >= permanentBanAfter {
+		rs.ban(id, r, 0, reason)
+	} else {
+		rs.ban(id, r, tempBanPeriod<<(r.ban.Bans-1), reason)
+	}
+	return true
+}
+
+// Ban bans the peer for the duration, or permanently if the duration is 0.
+func (rs *reputationSet) Ban(id enode.ID, duration time.Duration, reason string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r := rs.get(id)
+	r.ban.Bans++
+	r.addReason(reason)
+	rs.ban(id, r, duration, reason)
+}
+
+// Unban lifts the peer's ban and resets its reputation.
+// Number of the peer's bans is kept, so the next ban is longer.
+// Returns false if the peer isn't banned.
+func (rs *reputationSet) Unban(id enode.ID) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r := rs.get(id)
+	if !r.banned(time.Now()) {
+		return false
+	}
+	r.score = 0
+	r.ban.Permanent = false
+	r.ban.Until = 0
+	rs.saveBan(id, r)
+	log.Info("Peer is unbanned", "id", id)
+	return true
+}
+
+// List returns reputations of the tracked peers, banned ones are first.
+func (rs *reputationSet) List() []PeerReputationInfo {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	now := time.Now()
+	list := make([]PeerReputationInfo, 0, len(rs.peers))
+	for id, r := range rs.peers {
+		info := PeerReputationInfo{
+			ID:        id,
+			Score:     r.score,
+			Banned:    r.banned(now),
+			Permanent: r.ban.Permanent,
+			Bans:      r.ban.Bans,
+			BanReason: r.ban.Reason,
+			Reasons:   append([]string{}, r.reasons...),
+		}
+		if info.Banned && !info.Permanent {
+			until := time.Unix(int64(r.ban.Until), 0)
+			info.BannedUntil = &until
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Banned != list[j].Banned {
+			return list[i].Banned
+		}
+		return list[i].Score < list[j].Score
+	})
+	return list
+}
+
+func (rs *reputationSet) get(id enode.ID) *peerReputation {
+	r := rs.peers[id]
+	if r == nil {
+		if len(rs.peers) >= maxTrackedPeers {
+			rs.forget()
+		}
+		r = rs.load(id)
+		if r == nil {
+			r = &peerReputation{}
+		}
+		rs.peers[id] = r
+	}
+	return r
+}
+
+// forget erases reputations of peers which have no ban records and aren't penalized.
+// If it isn't enough, then peers with ban records are forgotten too (the records are kept in DB),
+// and penalized peers are forgotten the last.
+func (rs *reputationSet) forget() {
+	now := time.Now()
+	for _, forgettable := range []func(r *peerReputation) bool{
+		func(r *peerReputation) bool { return r.ban.Bans == 0 && r.score >= 0 },
+		func(r *peerReputation) bool { return r.ban.Bans != 0 && !r.banned(now) },
+		func(r *peerReputation) bool { return r.ban.Bans != 0 },
+		func(r *peerReputation) bool { return true },
+	} {
+		for id, r := range rs.peers {
+			if forgettable(r) {
+				delete(rs.peers, id)
+			}
+			if len(rs.peers) < maxTrackedPeers/2 {
+				return
+			}
+		}
+	}
+}
+
+func (rs *reputationSet) ban(id enode.ID, r *peerReputation, duration time.Duration, reason string) {
+	r.score = 0
+	r.ban.Reason = reason
+	r.ban.Permanent = duration == 0
+	if !r.ban.Permanent {
+		r.ban.Until = uint64(time.Now().Add(duration).Unix())
+	}
+	peerBanMeter.Mark(1)
+	log.Warn("Peer is banned", "id", id, "duration", duration, "permanent", r.ban.Permanent, "reason", reason)
+
+	rs.saveBan(id, r)
+}
+
+func (rs *reputationSet) saveBan(id enode.ID, r *peerReputation) {
+	enc, err := rlp.EncodeToBytes(&r.ban)
+	if err != nil {
+		log.Crit("Failed to encode peer ban", "err", err)
+	}
+	if err := rs.db.Put(reputationKey(id), enc); err != nil {
+		log.Error("Failed to save peer ban", "id", id, "err", err)
+	}
+}
+
+func (rs *reputationSet) loadBans() {
+	it := rs.db.NewIteratorWithPrefix([]byte(reputationDbPrefix))
+	defer it.Release()
+
+	for it.Next() && len(rs.peers) < maxTrackedPeers/2 {
+		var id enode.ID
+		if len(it.Key()) != len(reputationDbPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(reputationDbPrefix):])
+
+		r := &peerReputation{}
+		if err := rlp.DecodeBytes(it.Value(), &r.ban); err != nil {
+			log.Debug("Failed to decode peer ban", "id", id, "err", err)
+			continue
+		}
+		rs.peers[id] = r
+	}
+}
+
+// load returns the peer's ban record from DB, or nil if the peer has no bans.
+func (rs *reputationSet) load(id enode.ID) *peerReputation {
+	enc, err := rs.db.Get(reputationKey(id))
+	if err != nil {
+		log.Error("Failed to read peer ban", "id", id, "err", err)
+		return nil
+	}
+	if enc == nil {
+		return nil
+	}
+	r := &peerReputation{}
+	if err := rlp.DecodeBytes(enc, &r.ban); err != nil {
+		log.Debug("Failed to decode peer ban", "id", id, "err", err)
+		return nil
+	}
+	return r
+}
+
+func reputationKey(id enode.ID) []byte {
+	return append([]byte(reputationDbPrefix), id.Bytes()...)
+}
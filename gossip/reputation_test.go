@@ -0,0 +1,155 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+func TestReputationSet(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	db := memorydb.New()
+	rs := newReputationSet(db)
+
+	good := enode.ID{1}
+	bad := enode.ID{2}
+
+	// useful deliveries are capped
+	for i := 0; i < maxReputation*2; i++ {
+		rs.Reward(good, usefulDeliveryReward)
+	}
+	assertar.False(rs.Penalize(good, invalidEventPenalty, "invalid event"))
+	assertar.False(rs.Banned(good))
+
+	// low reputation leads to a temporary ban
+	for i := 0; i < -banReputation/timeoutPenalty-1; i++ {
+		assertar.False(rs.Penalize(bad, timeoutPenalty, "request timeout"))
+	}
+	assertar.True(rs.Penalize(bad, timeoutPenalty, "request timeout"))
+	assertar.True(rs.Banned(bad))
+
+	list := rs.List()
+	if !assertar.Len(list, 2) {
+		return
+	}
+	assertar.Equal(bad, list[0].ID)
+	assertar.True(list[0].Banned)
+	assertar.False(list[0].Permanent)
+	assertar.NotNil(list[0].BannedUntil)
+	assertar.Equal("request timeout", list[0].BanReason)
+	assertar.Equal(maxReasons, len(list[0].Reasons))
+	assertar.Equal(good, list[1].ID)
+	assertar.Equal(int64(maxReputation-invalidEventPenalty), list[1].Score)
+
+	// bans are persistent
+	restored := newReputationSet(db)
+	assertar.True(restored.Banned(bad))
+	assertar.False(restored.Banned(good))
+
+	// unban
+	assertar.True(restored.Unban(bad))
+	assertar.False(restored.Unban(bad))
+	assertar.False(restored.Banned(bad))
+	assertar.False(newReputationSet(db).Banned(bad))
+
+	// ban history is kept after unban
+	list = newReputationSet(db).List()
+	if assertar.Len(list, 1) {
+		assertar.Equal(bad, list[0].ID)
+		assertar.Equal(uint32(1), list[0].Bans)
+	}
+	assertar.True(restored.Penalize(bad, -banReputation, "invalid event"))
+	assertar.Equal(uint32(2), restored.peers[bad].ban.Bans)
+}
+
+func TestReputationSetForget(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	db := memorydb.New()
+	rs := newReputationSet(db)
+
+	// every tracked peer is banned
+	for i := 0; i < maxTrackedPeers; i++ {
+		rs.Ban(enode.ID{byte(i), byte(i >> 8), 1}, 0, "test")
+	}
+	assertar.Equal(maxTrackedPeers, len(rs.peers))
+
+	// peers are forgotten anyway, but their bans are kept
+	rs.Reward(enode.ID{2}, usefulDeliveryReward)
+	assertar.True(len(rs.peers) <= maxTrackedPeers/2)
+	for i := 0; i < maxTrackedPeers; i++ {
+		assertar.True(rs.Banned(enode.ID{byte(i), byte(i >> 8), 1}))
+	}
+	assertar.False(rs.Banned(enode.ID{2}))
+}
+
+func TestReputationSetBans(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	db := memorydb.New()
+	rs := newReputationSet(db)
+
+	peer := enode.ID{1}
+
+	// temporary ban expires
+	rs.Ban(peer, time.Second, "test")
+	assertar.True(rs.Banned(peer))
+	time.Sleep(2 * time.Second)
+	assertar.False(rs.Banned(peer))
+
+	// repeated bans become permanent
+	for i := 1; i < permanentBanAfter; i++ {
+		rs.peers[peer].ban.Until = 0
+		assertar.True(rs.Penalize(peer, invalidEventPenalty, "invalid event"))
+	}
+	assertar.True(rs.Banned(peer))
+	assertar.True(newReputationSet(db).Banned(peer))
+	list := rs.List()
+	assertar.True(list[0].Permanent)
+	assertar.Nil(list[0].BannedUntil)
+	assertar.Equal(uint32(permanentBanAfter), list[0].Bans)
+}
+
+func TestBannedPeerRejected(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	pm, _ := newTestProtocolManagerMust(t, 5, 5, nil, nil)
+	defer pm.Stop()
+
+	p, _ := newTestPeer("peer", lachesis62, pm, true)
+	defer p.close()
+	time.Sleep(250 * time.Millisecond)
+	assertar.NotNil(pm.peers.Peer(p.id))
+
+	// banned peer is dropped
+	pm.BanPeer(p.ID(), 0, "test")
+	assertar.Nil(pm.peers.Peer(p.id))
+	assertar.True(pm.reputation.Banned(p.ID()))
+
+	list := pm.PeerReputations()
+	if assertar.Len(list, 1) {
+		assertar.Equal(p.ID(), list[0].ID)
+		assertar.True(list[0].Banned)
+		assertar.False(list[0].Connected)
+	}
+
+	// and can't connect again
+	protocol := pm.makeProtocol(lachesis62)
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	assertar.Equal(p2p.DiscUselessPeer, protocol.Run(p2p.NewPeer(p.ID(), "peer", nil), net))
+
+	assertar.True(pm.UnbanPeer(p.ID()))
+	assertar.False(pm.reputation.Banned(p.ID()))
+}
@@ -16,7 +16,7 @@ const (
 )
 
 // updateOriginationScores calculates the origination scores
-func (s *Service) updateOriginationScores(block *inter.Block, evmBlock *evmcore.EvmBlock, receipts types.Receipts, txPositions map[common.Hash]TxPosition, sealEpoch bool) {
+func (s *Processor) updateOriginationScores(block *inter.Block, evmBlock *evmcore.EvmBlock, receipts types.Receipts, txPositions map[common.Hash]TxPosition, sealEpoch bool) {
 	epoch := s.engine.GetEpoch()
 	// Calc origination scores
 	for i, tx := range evmBlock.Transactions {
@@ -57,7 +57,7 @@ func (s *Service) updateOriginationScores(block *inter.Block, evmBlock *evmcore.
 }
 
 // updateValidationScores calculates the validation scores
-func (s *Service) updateValidationScores(block *inter.Block, sealEpoch bool) {
+func (s *Processor) updateValidationScores(block *inter.Block, sealEpoch bool) {
 	blockTimeDiff := block.Time - s.store.GetBlock(block.Index-1).Time
 
 	// Calc validation scores
@@ -29,17 +29,16 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/evmcore"
 	"github.com/Fantom-foundation/go-lachesis/gossip/filters"
 	"github.com/Fantom-foundation/go-lachesis/gossip/gasprice"
-	"github.com/Fantom-foundation/go-lachesis/gossip/occuredtxs"
-	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
 	"github.com/Fantom-foundation/go-lachesis/lachesis"
 	"github.com/Fantom-foundation/go-lachesis/lachesis/params"
-	"github.com/Fantom-foundation/go-lachesis/logger"
 )
 
 const (
-	txsRingBufferSize = 20000 // Maximum number of stored hashes of included but not confirmed txs
+	txsRingBufferSize       = 20000 // Maximum number of stored hashes of included but not confirmed txs
+	eventArrivalsBufferSize = 20000 // Maximum number of stored arrival times of not confirmed events
 )
 
 type ServiceFeed struct {
@@ -79,8 +78,6 @@ func (f *ServiceFeed) SubscribeNewLogs(ch chan<- []*types.Log) notify.Subscripti
 
 // Service implements go-ethereum/node.Service interface.
 type Service struct {
-	config *Config
-
 	wg   sync.WaitGroup
 	done chan struct{}
 
@@ -91,62 +88,35 @@ type Service struct {
 	serverPool *serverPool
 
 	// application
-	node                *node.ServiceContext
-	store               *Store
-	app                 *app.Store
-	engine              Consensus
-	engineMu            *sync.RWMutex
-	emitter             *Emitter
-	txpool              *evmcore.TxPool
-	occurredTxs         *occuredtxs.Buffer
-	heavyCheckReader    HeavyCheckReader
-	gasPowerCheckReader GasPowerCheckReader
-	checkers            *eventcheck.Checkers
-
-	// global variables. TODO refactor to pass them as arguments if possible
-	blockParticipated map[idx.StakerID]bool // validators who participated in last block
-	currentEvent      hash.Event            // current event which is being processed
-
-	feed ServiceFeed
+	*Processor
+	node    *node.ServiceContext
+	emitter *Emitter
+	txpool  *evmcore.TxPool
 
 	// application protocol
 	pm *ProtocolManager
 
 	EthAPI        *EthAPIBackend
 	netRPCService *ethapi.PublicNetAPI
-
-	logger.Instance
 }
 
 func NewService(ctx *node.ServiceContext, config *Config, store *Store, engine Consensus, app *app.Store) (*Service, error) {
 	svc := &Service{
-		config: config,
-
 		done: make(chan struct{}),
 
 		Name: fmt.Sprintf("Node-%d", rand.Int()),
 
-		node:  ctx,
-		store: store,
-		app:   app,
-
-		engineMu:          new(sync.RWMutex),
-		occurredTxs:       occuredtxs.New(txsRingBufferSize, types.NewEIP155Signer(config.Net.EvmChainConfig().ChainID)),
-		blockParticipated: make(map[idx.StakerID]bool),
-
-		Instance: logger.MakeInstance(),
+		node: ctx,
 	}
 
-	// wrap engine
-	svc.engine = &HookedEngine{
-		engine:       engine,
-		processEvent: svc.processEvent,
-	}
-	svc.engine.Bootstrap(inter.ConsensusCallbacks{
-		ApplyBlock:              svc.applyBlock,
-		SelectValidatorsGroup:   svc.selectValidatorsGroup,
-		OnEventConfirmed:        svc.onEventConfirmed,
-		IsEventAllowedIntoBlock: svc.isEventAllowedIntoBlock,
+	// create app processor, it wraps engine
+	svc.Processor = NewProcessor(config, store, engine, app, ProcessorHooks{
+		OnNewEvent: func(e *inter.Event) {
+			svc.emitter.OnNewEvent(e)
+		},
+		OnNewEpoch: func(newValidators *pos.Validators, newEpoch idx.Epoch) {
+			svc.emitter.OnNewEpoch(newValidators, newEpoch)
+		},
 	})
 
 	// create server pool
@@ -160,14 +130,12 @@ func NewService(ctx *node.ServiceContext, config *Config, store *Store, engine C
 	}
 	svc.txpool = evmcore.NewTxPool(config.TxPool, config.Net.EvmChainConfig(), stateReader)
 
-	// create checkers
-	svc.heavyCheckReader.Addrs.Store(ReadEpochPubKeys(svc.app, svc.engine.GetEpoch()))                                                                     // read pub keys of current epoch from disk
-	svc.gasPowerCheckReader.Ctx.Store(ReadGasPowerContext(svc.store, svc.app, svc.engine.GetValidators(), svc.engine.GetEpoch(), &svc.config.Net.Economy)) // read gaspower check data from disk
-	svc.checkers = makeCheckers(&svc.config.Net, &svc.heavyCheckReader, &svc.gasPowerCheckReader, svc.engine, svc.store)
-
 	// create protocol manager
 	var err error
 	svc.pm, err = NewProtocolManager(config, &svc.feed, svc.txpool, svc.engineMu, svc.checkers, store, svc.engine, svc.serverPool)
+	if err == nil {
+		svc.pm.enableSnapshots(app, svc.applySnapshot)
+	}
 
 	// create API backend
 	svc.EthAPI = &EthAPIBackend{config.ExtRPCEnabled, svc, stateReader, nil}
@@ -176,8 +144,8 @@ func NewService(ctx *node.ServiceContext, config *Config, store *Store, engine C
 	return svc, err
 }
 
-// makeCheckers builds event checkers
-func makeCheckers(net *lachesis.Config, heavyCheckReader *HeavyCheckReader, gasPowerCheckReader *GasPowerCheckReader, engine Consensus, store *Store) *eventcheck.Checkers {
+// MakeCheckers builds event checkers
+func MakeCheckers(net *lachesis.Config, heavyCheckReader *HeavyCheckReader, gasPowerCheckReader *GasPowerCheckReader, engine Consensus, store *Store) *eventcheck.Checkers {
 	// create signatures checker
 	ledgerID := net.EvmChainConfig().ChainID
 	heavyCheck := heavycheck.NewDefault(&net.Dag, heavyCheckReader, types.NewEIP155Signer(ledgerID))
@@ -276,6 +244,11 @@ func (s *Service) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   NewPrivateAdminAPI(s),
+			Public:    false,
 		},
 	}...)
 
@@ -317,17 +290,15 @@ func (s *Service) Stop() error {
 	s.emitter.StopEventEmission()
 	s.pm.Stop()
 	s.wg.Wait()
+	s.snapshotWg.Wait()
 	s.feed.scope.Close()
 
 	// flush the state at exit, after all the routines stopped
 	s.engineMu.Lock()
 	defer s.engineMu.Unlock()
 
-	err := s.app.Commit(nil, true)
-	if err != nil {
-		return err
-	}
-	return s.store.Commit(nil, true)
+	// app and gossip stores share the DBs pool, so it commits the both
+	return s.app.Commit(nil, true)
 }
 
 // AccountManager return node's account manager
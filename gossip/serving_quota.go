@@ -0,0 +1,118 @@
+package gossip
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+
+	"github.com/Fantom-foundation/go-lachesis/utils/tokenbucket"
+)
+
+const (
+	// maxServingDelay is the maximum time a request may wait for the serving quotas, before it's rejected
+	maxServingDelay = 1 * time.Second
+	// servingBurst is the period of the quotas which may be spent at once
+	servingBurst = 2
+	// maxQueuedServing is the maximum number of the peer's requests waiting for the serving quotas
+	maxQueuedServing = 16
+
+	// servingEventSize is the estimated size of a served event
+	servingEventSize = 1024
+	// servingPackInfoSize is the estimated size of a served pack info
+	servingPackInfoSize = 256
+	// servingStateNodeSize is the estimated size of a served EVM state trie node
+	servingStateNodeSize = 512
+)
+
+// servingQuota limits the responded bytes and DB reads of served requests.
+type servingQuota struct {
+	bytes *tokenbucket.Bucket
+	reads *tokenbucket.Bucket
+	clock mclock.Clock
+}
+
+// servingCost is the responded bytes and DB reads of a request.
+type servingCost struct {
+	bytes int
+	reads int
+}
+
+// servingRequest is a request which waits for the serving quotas.
+type servingRequest struct {
+	at    mclock.AbsTime
+	serve func()
+}
+
+func newServingQuota(limits ServingLimits, clock mclock.Clock) *servingQuota {
+	return &servingQuota{
+		bytes: tokenbucket.New(limits.BytesPerSec, limits.BytesPerSec*servingBurst, clock),
+		reads: tokenbucket.New(limits.ReadsPerSec, limits.ReadsPerSec*servingBurst, clock),
+		clock: clock,
+	}
+}
+
+// delay returns time until the quota allows to serve a request.
+func (q *servingQuota) delay() time.Duration {
+	delay := q.bytes.Delay()
+	if d := q.reads.Delay(); d > delay {
+		delay = d
+	}
+	return delay
+}
+
+// reserve charges the quota for a request before it's served.
+func (q *servingQuota) reserve(cost servingCost) {
+	q.bytes.Spend(uint64(cost.bytes))
+	q.reads.Spend(uint64(cost.reads))
+}
+
+// correct charges the quota for the actual cost of a served request, instead of the reserved one.
+func (q *servingQuota) correct(reserved, spent servingCost) {
+	q.bytes.Refund(uint64(reserved.bytes))
+	q.bytes.Spend(uint64(spent.bytes))
+	q.reads.Refund(uint64(reserved.reads))
+	q.reads.Spend(uint64(spent.reads))
+}
+
+// serveRequest serves the request within the peer's and the total serving quotas.
+// The estimated cost is reserved before serving, and the quotas are corrected by the actual cost after.
+// If the quotas are exhausted, then the request is queued to be served by the peer's serving loop,
+// or it's rejected by the throttled() reply if the queue is full or the quotas are exhausted for too long.
+func (pm *ProtocolManager) serveRequest(p *peer, estimate servingCost, serve func() servingCost, throttled func()) {
+	delay := p.servingQuota.delay()
+	if d := pm.servingQuota.delay(); d > delay {
+		delay = d
+	}
+	if delay > maxServingDelay {
+		servingDroppedMeter.Mark(1)
+		pm.Log.Debug("Request rejected, serving quota is exhausted", "peer", p.id, "delay", delay)
+		throttled()
+		return
+	}
+
+	req := &servingRequest{
+		at: p.servingQuota.clock.Now().Add(delay),
+		serve: func() {
+			spent := serve()
+			p.servingQuota.correct(estimate, spent)
+			pm.servingQuota.correct(estimate, spent)
+		},
+	}
+	p.servingQuota.reserve(estimate)
+	pm.servingQuota.reserve(estimate)
+	if delay == 0 && len(p.queuedServing) == 0 {
+		req.serve()
+		return
+	}
+
+	select {
+	case p.queuedServing <- req:
+		servingThrottledMeter.Mark(1)
+	default:
+		p.servingQuota.correct(estimate, servingCost{})
+		pm.servingQuota.correct(estimate, servingCost{})
+		servingDroppedMeter.Mark(1)
+		pm.Log.Debug("Request rejected, serving queue is full", "peer", p.id)
+		throttled()
+	}
+}
@@ -18,7 +18,7 @@ import (
 )
 
 // GetActiveSfcStakers returns stakers which will become validators in next epoch
-func (s *Service) GetActiveSfcStakers() []sfctype.SfcStakerAndID {
+func (s *Processor) GetActiveSfcStakers() []sfctype.SfcStakerAndID {
 	stakers := make([]sfctype.SfcStakerAndID, 0, 200)
 	s.app.ForEachSfcStaker(func(it sfctype.SfcStakerAndID) {
 		if it.Staker.Ok() {
@@ -28,7 +28,28 @@ func (s *Service) GetActiveSfcStakers() []sfctype.SfcStakerAndID {
 	return stakers
 }
 
-func (s *Service) delAllStakerData(stakerID idx.StakerID) {
+// validatorsChanged returns true if the stakers which will become validators in next epoch
+// differ from the validators of the epoch.
+func (s *Processor) validatorsChanged(epoch idx.Epoch) bool {
+	validators := s.app.GetEpochValidators(epoch)
+	stakers := s.GetActiveSfcStakers()
+	if len(validators) != len(stakers) {
+		return true
+	}
+
+	isValidator := make(map[idx.StakerID]bool, len(validators))
+	for _, it := range validators {
+		isValidator[it.StakerID] = true
+	}
+	for _, it := range stakers {
+		if !isValidator[it.StakerID] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Processor) delAllStakerData(stakerID idx.StakerID) {
 	s.app.DelSfcStaker(stakerID)
 	s.app.ResetBlocksMissed(stakerID)
 	s.app.DelActiveValidationScore(stakerID)
@@ -41,7 +62,7 @@ func (s *Service) delAllStakerData(stakerID idx.StakerID) {
 	s.app.DelStakerDelegatorsClaimedRewards(stakerID)
 }
 
-func (s *Service) delAllDelegatorData(address common.Address) {
+func (s *Processor) delAllDelegatorData(address common.Address) {
 	s.app.DelSfcDelegator(address)
 	s.app.DelDelegatorClaimedRewards(address)
 }
@@ -50,7 +71,7 @@ var (
 	max128 = new(big.Int).Sub(math.BigPow(2, 128), common.Big1)
 )
 
-func (s *Service) calcRewardWeights(stakers []sfctype.SfcStakerAndID, _epochDuration inter.Timestamp) (baseRewardWeights []*big.Int, txRewardWeights []*big.Int) {
+func (s *Processor) calcRewardWeights(stakers []sfctype.SfcStakerAndID, _epochDuration inter.Timestamp) (baseRewardWeights []*big.Int, txRewardWeights []*big.Int) {
 	validationScores := make([]*big.Int, 0, len(stakers))
 	originationScores := make([]*big.Int, 0, len(stakers))
 	pois := make([]*big.Int, 0, len(stakers))
@@ -110,7 +131,7 @@ func (s *Service) calcRewardWeights(stakers []sfctype.SfcStakerAndID, _epochDura
 }
 
 // getRewardPerSec returns current rewardPerSec, depending on config and value provided by SFC
-func (s *Service) getRewardPerSec() *big.Int {
+func (s *Processor) getRewardPerSec() *big.Int {
 	rewardPerSecond := s.app.GetSfcConstants(s.engine.GetEpoch() - 1).BaseRewardPerSec
 	if rewardPerSecond == nil || rewardPerSecond.Sign() == 0 {
 		rewardPerSecond = s.config.Net.Economy.InitialRewardPerSecond
@@ -122,7 +143,7 @@ func (s *Service) getRewardPerSec() *big.Int {
 }
 
 // processSfc applies the new SFC state
-func (s *Service) processSfc(block *inter.Block, receipts types.Receipts, blockFee *big.Int, sealEpoch bool, cheaters inter.Cheaters, statedb *state.StateDB) {
+func (s *Processor) processSfc(block *inter.Block, receipts types.Receipts, blockFee *big.Int, sealEpoch bool, cheaters inter.Cheaters, statedb *state.StateDB) {
 	// s.engineMu is locked here
 
 	// process SFC contract logs
@@ -0,0 +1,145 @@
+package gossip
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/Fantom-foundation/go-lachesis/evmcore"
+	"github.com/Fantom-foundation/go-lachesis/gossip/snapsync"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+var (
+	errOutdatedSnapshot = errors.New("snapshot is outdated")
+	errNoSnapshotState  = errors.New("snapshot EVM state isn't synced")
+)
+
+// makeSnapshot starts making the snapshot of the just sealed epoch, which replaces the served snapshot once it's made.
+// The snapshot tables are exported from the flushed DBs in background, so the engine isn't locked meanwhile.
+func (s *Processor) makeSnapshot(epoch idx.Epoch) {
+	// s.engineMu is locked here, the DBs are flushed on the epoch sealing
+
+	consensus := s.engine.EpochSnapshot()
+	if consensus == nil {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&s.snapshotting, 0, 1) {
+		s.Log.Warn("Snapshot is skipped, the previous one isn't made yet", "epoch", epoch)
+		return
+	}
+	lastBlock, _ := s.engine.LastBlock()
+	info := &snapsync.Info{
+		Epoch:     epoch,
+		Block:     s.store.GetBlock(lastBlock),
+		Consensus: consensus,
+	}
+
+	slot := s.store.GetFreeSnapshotSlot()
+
+	// the flushed data isn't changed until unpin
+	unpin := s.store.dbs.Pin()
+
+	s.snapshotWg.Add(1)
+	go func() {
+		defer s.snapshotWg.Done()
+		defer atomic.StoreUint32(&s.snapshotting, 0)
+
+		s.exportSnapshot(info, slot, unpin)
+	}()
+}
+
+// exportSnapshot writes the snapshot sections into the free slot, and replaces the served snapshot.
+func (s *Processor) exportSnapshot(info *snapsync.Info, slot byte, unpin func()) {
+	// data of an interrupted export
+	s.store.DelSnapshot(slot)
+
+	exports := []struct {
+		id     byte
+		export func(dst ethdb.KeyValueWriter) error
+	}{
+		{appSection, s.app.ExportSnapshot},
+		{gossipSection, s.store.ExportSnapshot},
+	}
+	for _, x := range exports {
+		data := s.store.GetSnapshotSection(slot, x.id)
+		if err := x.export(data); err != nil {
+			s.Log.Crit("Failed to export snapshot", "err", err)
+		}
+		section, err := snapsync.SectionOf(x.id, data)
+		if err != nil {
+			s.Log.Crit("Failed to read snapshot", "err", err)
+		}
+		info.Sections = append(info.Sections, section)
+	}
+	unpin()
+
+	// the served snapshot is read under the lock
+	s.engineMu.Lock()
+	defer s.engineMu.Unlock()
+
+	prevSlot := s.store.GetSnapshotSlot()
+	s.store.SetSnapshotInfo(info, slot)
+	s.store.DelSnapshot(prevSlot)
+	s.Log.Info("New snapshot", "epoch", info.Epoch, "block", info.Block.Index, "hash", info.Hash())
+}
+
+// applySnapshot moves the node to the downloaded snapshot of a sealed epoch.
+func (s *Service) applySnapshot(info *snapsync.Info) error {
+	s.engineMu.Lock()
+	defer s.engineMu.Unlock()
+
+	oldEpoch := s.engine.GetEpoch()
+	if info.Epoch <= oldEpoch {
+		return errOutdatedSnapshot
+	}
+	if !s.app.HasState(info.Block.Root) {
+		return errNoSnapshotState
+	}
+
+	// consensus is checked against the block before any changes
+	err := s.engine.VerifyEpochSnapshot(info.Consensus, info.Block)
+	if err != nil {
+		return err
+	}
+
+	// the imports are staged in the DBs pool, and the engine is switched last,
+	// so the DBs aren't flushed until all the snapshot is applied
+	if err := s.app.ImportSnapshot(s.store.GetDownloadSection(appSection)); err != nil {
+		s.Log.Crit("Failed to import snapshot", "err", err)
+	}
+	if err := s.store.ImportSnapshot(s.store.GetDownloadSection(gossipSection)); err != nil {
+		s.Log.Crit("Failed to import snapshot", "err", err)
+	}
+	s.store.DelDownload()
+
+	s.store.SetBlock(info.Block)
+	s.store.SetBlockIndex(info.Block.Atropos, info.Block.Index)
+
+	if err := s.engine.ApplyEpochSnapshot(info.Consensus, info.Block); err != nil {
+		s.Log.Crit("Failed to apply snapshot", "err", err)
+	}
+
+	newEpoch := s.engine.GetEpoch()
+
+	// notify event checkers about new validation data
+	s.heavyCheckReader.Addrs.Store(ReadEpochPubKeys(s.app, newEpoch))
+	s.gasPowerCheckReader.Ctx.Store(ReadGasPowerContext(s.store, s.app, s.engine.GetValidators(), newEpoch, &s.config.Net.Economy))
+
+	// prunings
+	s.store.delEpochStore(oldEpoch)
+	s.store.getEpochStore(newEpoch)
+	s.occurredTxs.Clear()
+
+	// notify about new epoch and block
+	s.emitter.OnNewEpoch(s.engine.GetValidators(), newEpoch)
+	s.feed.newEpoch.Send(newEpoch)
+	s.feed.newBlock.Send(evmcore.ChainHeadNotify{Block: &evmcore.EvmBlock{
+		EvmHeader: *evmcore.ToEvmHeader(info.Block),
+	}})
+
+	// app and gossip stores share the DBs pool, so it commits the both.
+	// The DBs are flushed as of the last block of the sealed epoch
+	return s.app.Commit(info.Block.Atropos.Bytes(), true)
+}
@@ -0,0 +1,103 @@
+package snapsync
+
+import (
+	"hash"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+type (
+	// Info is a descriptor of a sealed epoch snapshot.
+	Info struct {
+		// Epoch which starts from the snapshot
+		Epoch idx.Epoch
+		// Block is the last block of the sealed epoch, EVM state is verified against its Root
+		Block *inter.Block
+		// Consensus is the consensus state at the epoch start
+		Consensus []byte
+		// Sections are descriptors of the snapshot tables data
+		Sections []Section
+	}
+
+	// Section is a descriptor of a part of the snapshot tables data.
+	Section struct {
+		ID     byte
+		Num    uint64      // number of key-value pairs
+		Digest common.Hash // hash of the ordered key-value pairs
+	}
+
+	// Digest calculates a section descriptor by the ordered key-value pairs.
+	Digest struct {
+		hasher hash.Hash
+		num    uint64
+	}
+)
+
+// Hash of the snapshot info.
+func (i *Info) Hash() common.Hash {
+	hasher := sha3.NewLegacyKeccak256()
+	if err := rlp.Encode(hasher, i); err != nil {
+		panic(err)
+	}
+	return common.BytesToHash(hasher.Sum(nil))
+}
+
+// NewDigest constructor.
+func NewDigest() *Digest {
+	return &Digest{
+		hasher: sha3.NewLegacyKeccak256(),
+	}
+}
+
+// Add the next key-value pair.
+func (d *Digest) Add(key, val []byte) {
+	if err := rlp.Encode(d.hasher, [][]byte{key, val}); err != nil {
+		panic(err)
+	}
+	d.num++
+}
+
+// Section returns the descriptor of the added key-value pairs.
+func (d *Digest) Section(id byte) Section {
+	return Section{
+		ID:     id,
+		Num:    d.num,
+		Digest: common.BytesToHash(d.hasher.Sum(nil)),
+	}
+}
+
+// SectionOf calculates the descriptor of the section data.
+func SectionOf(id byte, db ethdb.Iteratee) (Section, error) {
+	d := NewDigest()
+
+	it := db.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		d.Add(it.Key(), it.Value())
+	}
+	return d.Section(id), it.Error()
+}
+
+// ReadRange reads the ordered key-value pairs, starting from the key, until one of the limits is reached.
+// Returns last=true if there are no more pairs.
+func ReadRange(db ethdb.Iteratee, from []byte, maxItems, maxSize int) (keys, vals [][]byte, last bool, err error) {
+	it := db.NewIteratorWithStart(from)
+	defer it.Release()
+
+	size := 0
+	for len(keys) < maxItems && size < maxSize {
+		if !it.Next() {
+			return keys, vals, true, it.Error()
+		}
+		keys = append(keys, common.CopyBytes(it.Key()))
+		vals = append(vals, common.CopyBytes(it.Value()))
+		size += len(it.Key()) + len(it.Value())
+	}
+	return keys, vals, false, it.Error()
+}
@@ -0,0 +1,602 @@
+package snapsync
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+/*
+ * Syncer is a network agent, which is responsible for syncing the node from a snapshot of a sealed epoch,
+ * instead of processing all the previous epochs.
+ * It requests snapshot infos from the peers, and picks the trusted snapshot once it's announced by enough peers.
+ * The snapshot info is anchored by its hash from the config, so the peers can't forge the snapshot data.
+ * EVM state is downloaded by trie nodes, each node is verified by its hash, starting from the block state root.
+ * The sections of the snapshot tables are downloaded by key ranges, and verified by the section digests.
+ * Once all the data is downloaded, the snapshot is applied.
+ */
+
+const (
+	recheckInterval   = 100 * time.Millisecond // Time between checks of the download progress
+	infoRecheckPeriod = time.Minute            // Time between snapshot info requests to a peer
+	requestTimeout    = 10 * time.Second       // Time allowance for a peer to answer a request
+
+	maxStateNodes = 384 // Maximum number of state nodes to request from a peer at once
+	bloomSize     = 64  // Size of the state sync bloom filter, in MB
+
+	// maxQueuedNotifies is the maximum number of notifications to queue up before
+	// dropping incoming responses.
+	maxQueuedNotifies = 32
+)
+
+var (
+	errTerminated = errors.New("terminated")
+)
+
+type (
+	// Config of Syncer.
+	Config struct {
+		// Trusted is a hash of the snapshot info to download, other snapshots are ignored
+		Trusted common.Hash
+		// MinPeers is a number of peers which must announce the same snapshot to download it
+		MinPeers int
+		// MinEpochsBehind is a minimal lag of the node behind a snapshot to download it
+		MinEpochsBehind idx.Epoch
+	}
+
+	// Peer is a source of snapshots.
+	Peer struct {
+		ID    string
+		Epoch idx.Epoch
+
+		RequestInfo       infoRequesterFn
+		RequestStateNodes stateNodesRequesterFn
+		RequestSection    sectionRequesterFn
+	}
+
+	// Callback is a set of Syncer's callbacks.
+	Callback struct {
+		// Epoch returns current epoch of the node
+		Epoch func() idx.Epoch
+		// StateDb is a DB which EVM state is written into
+		StateDb ethdb.Database
+		// Reset erases the previous snapshot data before a download
+		Reset func()
+		// Section returns a table which the snapshot section is written into
+		Section func(id byte) ethdb.KeyValueStore
+		// Write executes the writes of downloaded data, and flushes them onto disk if too much data is written,
+		// or immediately if flush is true
+		Write func(write func(), flush bool)
+		// Apply moves the node to the downloaded snapshot
+		Apply func(info *Info) error
+		// DropPeer drops a peer detected as malicious
+		DropPeer func(peer string)
+	}
+
+	// request snapshot info from the peer
+	infoRequesterFn func() error
+	// request state trie nodes or contract codes by hashes from the peer
+	stateNodesRequesterFn func(hashes []common.Hash) error
+	// request the section key-value pairs, starting from the key, from the peer
+	sectionRequesterFn func(epoch idx.Epoch, section byte, from []byte) error
+)
+
+type (
+	infoData struct {
+		peer string
+		info *Info
+	}
+
+	stateNodesData struct {
+		peer  string
+		nodes [][]byte
+	}
+
+	sectionData struct {
+		peer    string
+		epoch   idx.Epoch
+		section byte
+		from    []byte
+		keys    [][]byte
+		vals    [][]byte
+		last    bool
+	}
+
+	peerState struct {
+		peer *Peer
+
+		info     *Info
+		infoHash common.Hash
+		infoTime time.Time // time of the last info request
+
+		requested time.Time     // time of the pending request, zero if the peer is idle
+		nodes     []common.Hash // state nodes which are requested from the peer
+		section   *sectionState // section which is requested from the peer
+	}
+
+	sectionState struct {
+		expected Section
+		from     []byte // next key to download
+		digest   *Digest
+		done     bool
+	}
+
+	download struct {
+		info     *Info
+		hash     common.Hash
+		state    *trie.Sync
+		bloom    *trie.SyncBloom
+		tasks    map[common.Hash]struct{} // state nodes which aren't requested yet
+		sections []*sectionState
+	}
+)
+
+// Syncer is responsible for choosing a snapshot which is announced by peers, downloading and applying it.
+type Syncer struct {
+	cfg      Config
+	callback Callback
+
+	register      chan *Peer
+	unregister    chan string
+	notifyInfo    chan *infoData
+	notifyNodes   chan *stateNodesData
+	notifySection chan *sectionData
+	quit          chan struct{}
+	wg            sync.WaitGroup
+
+	// State
+	peers    map[string]*peerState
+	target   *download
+	rejected map[common.Hash]bool // snapshots which are failed to apply
+}
+
+// New creates a snapshot syncer.
+func New(cfg Config, callback Callback) *Syncer {
+	if cfg.MinPeers < 1 {
+		cfg.MinPeers = 1
+	}
+	return &Syncer{
+		cfg:           cfg,
+		callback:      callback,
+		register:      make(chan *Peer, maxQueuedNotifies),
+		unregister:    make(chan string, maxQueuedNotifies),
+		notifyInfo:    make(chan *infoData, maxQueuedNotifies),
+		notifyNodes:   make(chan *stateNodesData, maxQueuedNotifies),
+		notifySection: make(chan *sectionData, maxQueuedNotifies),
+		quit:          make(chan struct{}),
+		peers:         make(map[string]*peerState),
+		rejected:      make(map[common.Hash]bool),
+	}
+}
+
+// Start boots up the syncer.
+func (s *Syncer) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop terminates the syncer, canceling all pending operations.
+// It waits until the downloaded data is written.
+func (s *Syncer) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// RegisterPeer injects a new snapshots source, or updates the peer's epoch.
+func (s *Syncer) RegisterPeer(peer Peer) error {
+	select {
+	case s.register <- &peer:
+		return nil
+	case <-s.quit:
+		return errTerminated
+	}
+}
+
+// UnregisterPeer removes a snapshots source.
+func (s *Syncer) UnregisterPeer(peer string) error {
+	select {
+	case s.unregister <- peer:
+		return nil
+	case <-s.quit:
+		return errTerminated
+	}
+}
+
+// NotifyInfo injects a snapshot info from a peer.
+func (s *Syncer) NotifyInfo(peer string, info *Info) error {
+	select {
+	case s.notifyInfo <- &infoData{peer, info}:
+		return nil
+	case <-s.quit:
+		return errTerminated
+	}
+}
+
+// NotifyStateNodes injects state trie nodes or contract codes from a peer.
+func (s *Syncer) NotifyStateNodes(peer string, nodes [][]byte) error {
+	select {
+	case s.notifyNodes <- &stateNodesData{peer, nodes}:
+		return nil
+	case <-s.quit:
+		return errTerminated
+	}
+}
+
+// NotifySection injects a range of the section key-value pairs from a peer.
+func (s *Syncer) NotifySection(peer string, epoch idx.Epoch, section byte, from []byte, keys, vals [][]byte, last bool) error {
+	op := &sectionData{
+		peer:    peer,
+		epoch:   epoch,
+		section: section,
+		from:    from,
+		keys:    keys,
+		vals:    vals,
+		last:    last,
+	}
+	select {
+	case s.notifySection <- op:
+		return nil
+	case <-s.quit:
+		return errTerminated
+	}
+}
+
+// Loop is the main syncer's loop, checking and processing various notifications
+func (s *Syncer) loop() {
+	defer s.wg.Done()
+	syncTicker := time.NewTicker(recheckInterval)
+	defer syncTicker.Stop()
+	defer s.dropTarget()
+
+	for {
+		// Wait for an outside event to occur
+		select {
+		case <-s.quit:
+			return
+
+		case peer := <-s.register:
+			if p := s.peers[peer.ID]; p != nil {
+				p.peer = peer
+				continue
+			}
+			s.peers[peer.ID] = &peerState{
+				peer: peer,
+			}
+
+		case id := <-s.unregister:
+			if p := s.peers[id]; p != nil {
+				s.release(p)
+				delete(s.peers, id)
+			}
+
+		case op := <-s.notifyInfo:
+			if p := s.peers[op.peer]; p != nil && op.info != nil && op.info.Block != nil {
+				p.info = op.info
+				p.infoHash = op.info.Hash()
+			}
+
+		case op := <-s.notifyNodes:
+			s.processStateNodes(op)
+
+		case op := <-s.notifySection:
+			s.processSection(op)
+
+		case <-syncTicker.C:
+			s.tryToSync()
+		}
+	}
+}
+
+func (s *Syncer) tryToSync() {
+	myEpoch := s.callback.Epoch()
+	now := time.Now()
+
+	for _, p := range s.peers {
+		// refresh snapshot infos of the peers which are far ahead
+		if p.peer.Epoch >= myEpoch+s.cfg.MinEpochsBehind && now.Sub(p.infoTime) >= infoRecheckPeriod {
+			p.infoTime = now
+			_ = p.peer.RequestInfo()
+		}
+		// release timed out requests
+		if !p.requested.IsZero() && now.Sub(p.requested) >= requestTimeout {
+			if p.section != nil {
+				// the peer probably has no the snapshot anymore
+				p.info, p.infoHash = nil, common.Hash{}
+				p.infoTime = time.Time{}
+			}
+			s.release(p)
+		}
+	}
+
+	if s.target != nil && (s.target.info.Epoch < myEpoch+s.cfg.MinEpochsBehind || s.sources() == 0) {
+		// synced up already, or the snapshot isn't available anymore
+		s.dropTarget()
+	}
+	if s.target == nil {
+		s.pickTarget(myEpoch)
+	}
+	if s.target == nil {
+		return
+	}
+
+	if s.complete() {
+		s.applyTarget()
+		return
+	}
+	for _, p := range s.peers {
+		if p.requested.IsZero() {
+			s.requestFrom(p, now)
+		}
+	}
+}
+
+// pickTarget chooses the trusted snapshot if it's announced by enough peers.
+func (s *Syncer) pickTarget(myEpoch idx.Epoch) {
+	if s.rejected[s.cfg.Trusted] {
+		return
+	}
+	votes := 0
+	var best *Info
+	for _, p := range s.peers {
+		if p.info == nil || p.infoHash != s.cfg.Trusted || p.info.Epoch < myEpoch+s.cfg.MinEpochsBehind {
+			continue
+		}
+		votes++
+		best = p.info
+	}
+	if votes < s.cfg.MinPeers {
+		return
+	}
+
+	log.Info("Snapshot sync started", "epoch", best.Epoch, "block", best.Block.Index, "root", best.Block.Root)
+	s.callback.Write(s.callback.Reset, false)
+	bloom := trie.NewSyncBloom(bloomSize, s.callback.StateDb)
+	s.target = &download{
+		info:  best,
+		hash:  best.Hash(),
+		state: state.NewStateSync(best.Block.Root, s.callback.StateDb, bloom),
+		bloom: bloom,
+		tasks: make(map[common.Hash]struct{}),
+	}
+	for _, section := range best.Sections {
+		s.target.sections = append(s.target.sections, &sectionState{
+			expected: section,
+			digest:   NewDigest(),
+		})
+	}
+}
+
+func (s *Syncer) dropTarget() {
+	if s.target == nil {
+		return
+	}
+	for _, p := range s.peers {
+		s.release(p)
+	}
+	s.target.bloom.Close()
+	s.target = nil
+}
+
+func (s *Syncer) applyTarget() {
+	target := s.target
+	s.dropTarget()
+
+	err := s.callback.Apply(target.info)
+	if err != nil {
+		log.Error("Failed to apply snapshot", "epoch", target.info.Epoch, "err", err)
+		s.rejected[target.hash] = true
+		return
+	}
+	log.Info("Snapshot sync finished", "epoch", target.info.Epoch, "block", target.info.Block.Index)
+}
+
+// sources returns number of peers which have the target snapshot.
+func (s *Syncer) sources() int {
+	num := 0
+	for _, p := range s.peers {
+		if p.infoHash == s.target.hash {
+			num++
+		}
+	}
+	return num
+}
+
+func (s *Syncer) complete() bool {
+	for _, section := range s.target.sections {
+		if !section.done {
+			return false
+		}
+	}
+	for _, p := range s.peers {
+		if len(p.nodes) != 0 {
+			return false
+		}
+	}
+	return len(s.target.tasks) == 0 && s.target.state.Pending() == 0
+}
+
+// requestFrom requests a not downloaded section from the peer if the peer has the target snapshot,
+// or a batch of state nodes otherwise.
+func (s *Syncer) requestFrom(p *peerState, now time.Time) {
+	if p.infoHash == s.target.hash {
+		for _, section := range s.target.sections {
+			if section.done || s.requestedSection(section) {
+				continue
+			}
+			p.section = section
+			p.requested = now
+			_ = p.peer.RequestSection(s.target.info.Epoch, section.expected.ID, section.from)
+			return
+		}
+	}
+
+	for _, h := range s.target.state.Missing(maxStateNodes - len(s.target.tasks)) {
+		s.target.tasks[h] = struct{}{}
+	}
+	if len(s.target.tasks) == 0 {
+		return
+	}
+	for h := range s.target.tasks {
+		p.nodes = append(p.nodes, h)
+		delete(s.target.tasks, h)
+		if len(p.nodes) >= maxStateNodes {
+			break
+		}
+	}
+	p.requested = now
+	_ = p.peer.RequestStateNodes(p.nodes)
+}
+
+func (s *Syncer) requestedSection(section *sectionState) bool {
+	for _, p := range s.peers {
+		if p.section == section {
+			return true
+		}
+	}
+	return false
+}
+
+// release returns the pending requests of the peer back into the queue.
+func (s *Syncer) release(p *peerState) {
+	if s.target != nil {
+		for _, h := range p.nodes {
+			s.target.tasks[h] = struct{}{}
+		}
+	}
+	p.nodes = nil
+	p.section = nil
+	p.requested = time.Time{}
+}
+
+func (s *Syncer) processStateNodes(op *stateNodesData) {
+	p := s.peers[op.peer]
+	if p == nil || s.target == nil || len(p.nodes) == 0 {
+		return
+	}
+	requested := make(map[common.Hash]bool, len(p.nodes))
+	for _, h := range p.nodes {
+		requested[h] = true
+	}
+
+	for _, blob := range op.nodes {
+		// the node is proven to be a part of the state by its hash
+		h := crypto.Keccak256Hash(blob)
+		if !requested[h] {
+			continue
+		}
+		_, _, err := s.target.state.Process([]trie.SyncResult{{Hash: h, Data: blob}})
+		if err != nil && err != trie.ErrNotRequested && err != trie.ErrAlreadyProcessed {
+			log.Error("Invalid state node", "peer", op.peer, "hash", h, "err", err)
+			continue
+		}
+		delete(requested, h)
+	}
+
+	s.callback.Write(func() {
+		batch := s.callback.StateDb.NewBatch()
+		if err := s.target.state.Commit(batch); err != nil {
+			log.Crit("Failed to commit state nodes", "err", err)
+		}
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write state nodes", "err", err)
+		}
+	}, false)
+
+	// not delivered nodes will be requested again
+	p.nodes = p.nodes[:0]
+	for h := range requested {
+		p.nodes = append(p.nodes, h)
+	}
+	s.release(p)
+}
+
+func (s *Syncer) processSection(op *sectionData) {
+	p := s.peers[op.peer]
+	if p == nil || s.target == nil || p.section == nil {
+		return
+	}
+	section := p.section
+	if op.epoch != s.target.info.Epoch || op.section != section.expected.ID || !bytes.Equal(op.from, section.from) {
+		return
+	}
+	s.release(p)
+
+	if len(op.keys) != len(op.vals) {
+		s.callback.DropPeer(op.peer)
+		return
+	}
+	if len(op.keys) == 0 && !op.last {
+		return
+	}
+	// keys must be ordered, so the digest is the same
+	for i, key := range op.keys {
+		if bytes.Compare(key, section.from) < 0 || i != 0 && bytes.Compare(key, op.keys[i-1]) <= 0 {
+			log.Warn("Snapshot section isn't ordered", "peer", op.peer, "section", section.expected.ID)
+			s.callback.DropPeer(op.peer)
+			return
+		}
+	}
+
+	for i, key := range op.keys {
+		section.digest.Add(key, op.vals[i])
+	}
+	mismatch := op.last && section.digest.Section(section.expected.ID) != section.expected
+
+	// the section is flushed once it's downloaded, so the downloaded sections aren't kept in memory
+	table := s.callback.Section(section.expected.ID)
+	s.callback.Write(func() {
+		if mismatch {
+			// download the section again
+			if err := clearTable(table); err != nil {
+				log.Crit("Failed to erase snapshot section", "err", err)
+			}
+			return
+		}
+		for i, key := range op.keys {
+			if err := table.Put(key, op.vals[i]); err != nil {
+				log.Crit("Failed to put key-value", "err", err)
+			}
+		}
+	}, op.last && !mismatch)
+
+	if mismatch {
+		log.Warn("Snapshot section mismatches the info", "peer", op.peer, "section", section.expected.ID)
+		p.info, p.infoHash = nil, common.Hash{}
+		s.callback.DropPeer(op.peer)
+		section.from = nil
+		section.digest = NewDigest()
+		return
+	}
+	if len(op.keys) != 0 {
+		section.from = append(common.CopyBytes(op.keys[len(op.keys)-1]), 0)
+	}
+	section.done = op.last
+}
+
+// clearTable erases all the table data.
+func clearTable(db ethdb.KeyValueStore) error {
+	for {
+		keys, _, _, err := ReadRange(db, nil, 10000, int(^uint(0)>>1))
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		for _, key := range keys {
+			if err := db.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package snapsync
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
+)
+
+type testServer struct {
+	info     *Info
+	stateDb  state.Database
+	sections map[byte]ethdb.KeyValueStore
+	corrupt  bool
+}
+
+func newTestServer(t *testing.T, epoch idx.Epoch, accounts int) *testServer {
+	srv := &testServer{
+		stateDb:  state.NewDatabase(rawdb.NewMemoryDatabase()),
+		sections: make(map[byte]ethdb.KeyValueStore),
+	}
+
+	statedb, err := state.New(common.Hash{}, srv.stateDb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < accounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetBalance(addr, big.NewInt(int64(i*1000)))
+		statedb.SetNonce(addr, uint64(i))
+		if i%10 == 0 {
+			statedb.SetCode(addr, []byte(fmt.Sprintf("code%d", i)))
+			statedb.SetState(addr, common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i+1))))
+		}
+	}
+	root, err := statedb.Commit(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.stateDb.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.info = &Info{
+		Epoch: epoch,
+		Block: &inter.Block{
+			Index: idx.Block(epoch * 10),
+			Root:  root,
+		},
+		Consensus: []byte("consensus"),
+	}
+	for _, id := range []byte{'a', 'g'} {
+		db := memorydb.New()
+		for i := 0; i < accounts; i++ {
+			_ = db.Put([]byte(fmt.Sprintf("%c%d", id, i)), []byte(fmt.Sprintf("val%d", i)))
+		}
+		srv.sections[id] = db
+		section, err := SectionOf(id, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.info.Sections = append(srv.info.Sections, section)
+	}
+
+	return srv
+}
+
+func (srv *testServer) peer(id string, s *Syncer) Peer {
+	return Peer{
+		ID:    id,
+		Epoch: srv.info.Epoch,
+		RequestInfo: func() error {
+			go s.NotifyInfo(id, srv.info)
+			return nil
+		},
+		RequestStateNodes: func(hashes []common.Hash) error {
+			nodes := make([][]byte, 0, len(hashes))
+			for _, h := range hashes {
+				if node, err := srv.stateDb.TrieDB().Node(h); err == nil {
+					nodes = append(nodes, node)
+				}
+			}
+			go s.NotifyStateNodes(id, nodes)
+			return nil
+		},
+		RequestSection: func(epoch idx.Epoch, section byte, from []byte) error {
+			keys, vals, last, _ := ReadRange(srv.sections[section], from, 7, 1024)
+			if srv.corrupt && len(vals) != 0 {
+				vals[0] = []byte("corrupted")
+			}
+			go s.NotifySection(id, epoch, section, from, keys, vals, last)
+			return nil
+		},
+	}
+}
+
+type testClient struct {
+	epoch    idx.Epoch
+	stateDb  ethdb.Database
+	sections ethdb.KeyValueStore
+	applied  chan *Info
+	dropped  chan string
+}
+
+func (c *testClient) callback() Callback {
+	return Callback{
+		Epoch: func() idx.Epoch {
+			return c.epoch
+		},
+		StateDb: c.stateDb,
+		Reset: func() {
+			_ = table.DropTables(c.sections, []byte{'a', 'g'})
+		},
+		Section: func(id byte) ethdb.KeyValueStore {
+			return table.New(c.sections, []byte{id})
+		},
+		Write: func(write func(), flush bool) {
+			write()
+		},
+		Apply: func(info *Info) error {
+			c.applied <- info
+			return nil
+		},
+		DropPeer: func(peer string) {
+			c.dropped <- peer
+		},
+	}
+}
+
+func newTestClient() *testClient {
+	return &testClient{
+		epoch:    1,
+		stateDb:  rawdb.NewMemoryDatabase(),
+		sections: memorydb.New(),
+		applied:  make(chan *Info, 1),
+		dropped:  make(chan string, 10),
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	assertar := assert.New(t)
+
+	srv := newTestServer(t, 10, 100)
+	client := newTestClient()
+
+	s := New(Config{
+		Trusted:         srv.info.Hash(),
+		MinPeers:        2,
+		MinEpochsBehind: 3,
+	}, client.callback())
+	s.Start()
+	defer s.Stop()
+
+	// single peer isn't enough
+	assertar.NoError(s.RegisterPeer(srv.peer("peer1", s)))
+	select {
+	case <-client.applied:
+		t.Fatal("snapshot is applied by a single peer")
+	case <-time.After(5 * recheckInterval):
+	}
+
+	assertar.NoError(s.RegisterPeer(srv.peer("peer2", s)))
+	var info *Info
+	select {
+	case info = <-client.applied:
+	case <-time.After(10 * time.Second):
+		t.Fatal("snapshot isn't applied")
+	}
+	assertar.Equal(srv.info.Hash(), info.Hash())
+
+	// check the downloaded data
+	statedb, err := state.New(info.Block.Root, state.NewDatabase(client.stateDb))
+	if !assertar.NoError(err) {
+		return
+	}
+	for i := 0; i < 100; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		assertar.Equal(big.NewInt(int64(i*1000)), statedb.GetBalance(addr))
+		assertar.Equal(uint64(i), statedb.GetNonce(addr))
+		if i%10 == 0 {
+			assertar.Equal([]byte(fmt.Sprintf("code%d", i)), statedb.GetCode(addr))
+			assertar.Equal(common.BigToHash(big.NewInt(int64(i+1))), statedb.GetState(addr, common.BigToHash(big.NewInt(int64(i)))))
+		}
+	}
+	for _, expected := range srv.info.Sections {
+		got, err := SectionOf(expected.ID, table.New(client.sections, []byte{expected.ID}))
+		assertar.NoError(err)
+		assertar.Equal(expected, got)
+	}
+}
+
+func TestSyncerCorruptedSection(t *testing.T) {
+	assertar := assert.New(t)
+
+	srv := newTestServer(t, 10, 20)
+	srv.corrupt = true
+	client := newTestClient()
+
+	s := New(Config{
+		Trusted:         srv.info.Hash(),
+		MinPeers:        1,
+		MinEpochsBehind: 3,
+	}, client.callback())
+	s.Start()
+	defer s.Stop()
+
+	assertar.NoError(s.RegisterPeer(srv.peer("peer1", s)))
+	select {
+	case peer := <-client.dropped:
+		assertar.Equal("peer1", peer)
+	case <-time.After(10 * time.Second):
+		t.Fatal("malicious peer isn't dropped")
+	}
+	select {
+	case <-client.applied:
+		t.Fatal("corrupted snapshot is applied")
+	case <-time.After(5 * recheckInterval):
+	}
+}
+
+func TestSyncerSyncedUp(t *testing.T) {
+	srv := newTestServer(t, 10, 20)
+	client := newTestClient()
+	client.epoch = 8
+
+	s := New(Config{
+		Trusted:         srv.info.Hash(),
+		MinPeers:        1,
+		MinEpochsBehind: 3,
+	}, client.callback())
+	s.Start()
+	defer s.Stop()
+
+	_ = s.RegisterPeer(srv.peer("peer1", s))
+	select {
+	case <-client.applied:
+		t.Fatal("snapshot is applied, while the node is close to it")
+	case <-time.After(5 * recheckInterval):
+	}
+}
+
+func TestSyncerUntrusted(t *testing.T) {
+	srv := newTestServer(t, 10, 20)
+	trusted := newTestServer(t, 10, 21)
+	client := newTestClient()
+
+	s := New(Config{
+		Trusted:         trusted.info.Hash(),
+		MinPeers:        1,
+		MinEpochsBehind: 3,
+	}, client.callback())
+	s.Start()
+	defer s.Stop()
+
+	_ = s.RegisterPeer(srv.peer("peer1", s))
+	_ = s.RegisterPeer(srv.peer("peer2", s))
+	select {
+	case <-client.applied:
+		t.Fatal("untrusted snapshot is applied")
+	case <-time.After(5 * recheckInterval):
+	}
+}
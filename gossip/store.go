@@ -6,17 +6,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rlp"
-	"github.com/hashicorp/golang-lru"
 
 	"github.com/Fantom-foundation/go-lachesis/common/bigendian"
 	"github.com/Fantom-foundation/go-lachesis/gossip/temporary"
+	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/compressed"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/metered"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 )
 
 // Store is a node persistent storage working over physical key-value database.
@@ -37,7 +41,8 @@ type Store struct {
 		PacksNum  kvdb.KeyValueStore `table:"n"`
 
 		// general economy tables
-		EpochStats kvdb.KeyValueStore `table:"E"`
+		EpochStats        kvdb.KeyValueStore `table:"E"`
+		EpochSealingStats kvdb.KeyValueStore `table:"S"`
 
 		// gas power economy tables
 		LastEpochHeaders kvdb.KeyValueStore `table:"l"`
@@ -48,19 +53,28 @@ type Store struct {
 		DecisiveEvents  kvdb.KeyValueStore `table:"9"`
 		EventLocalTimes kvdb.KeyValueStore `table:"!"`
 
+		// slashing tables
+		ForkEvidences kvdb.KeyValueStore `table:"F"`
+
+		// snapshot sync tables
+		SnapshotInfo kvdb.KeyValueStore `table:"Y"`
+		SnapshotData kvdb.KeyValueStore `table:"y"`
+
 		TmpDbs kvdb.KeyValueStore `table:"T"`
+
+		Migrations kvdb.KeyValueStore `table:"_"`
 	}
 
 	EpochDbs *temporary.Dbs
 
 	cache struct {
-		Events        *lru.Cache `cache:"-"` // store by pointer
-		EventsHeaders *lru.Cache `cache:"-"` // store by pointer
-		Blocks        *lru.Cache `cache:"-"` // store by pointer
-		PackInfos     *lru.Cache `cache:"-"` // store by value
-		EpochStats    *lru.Cache `cache:"-"` // store by value
-		TxPositions   *lru.Cache `cache:"-"` // store by pointer
-		BlockHashes   *lru.Cache `cache:"-"` // store by pointer
+		Events        *sharedcache.Cache `cache:"-"` // store by pointer
+		EventsHeaders *sharedcache.Cache `cache:"-"` // store by pointer
+		Blocks        *sharedcache.Cache `cache:"-"` // store by pointer
+		PackInfos     *sharedcache.Cache `cache:"-"` // store by value
+		EpochStats    *sharedcache.Cache `cache:"-"` // store by value
+		TxPositions   *sharedcache.Cache `cache:"-"` // store by pointer
+		BlockHashes   *sharedcache.Cache `cache:"-"` // store by pointer
 	}
 
 	mutex struct {
@@ -89,8 +103,19 @@ func NewStore(dbs *flushable.SyncedPool, cfg StoreConfig) *Store {
 	}
 
 	table.MigrateTables(&s.table, s.mainDb)
+	metered.WrapTables(&s.table, "gossip")
+	// the table values are RLP lists, so they never collide with compression headers
+	s.table.Events = compressed.Wrap(s.table.Events, s.cfg.CompressionThreshold)
+
+	s.initEpochDbs()
+
+	s.initCache()
+
+	return s
+}
 
-	s.EpochDbs = s.newTmpDbs("epoch", func(ver uint64) (
+func (s *Store) initEpochDbs() {
+	s.EpochDbs = s.newTmpDbs("epoch", 'e', func(ver uint64) (
 		db kvdb.KeyValueStore,
 		tables interface{},
 	) {
@@ -98,17 +123,10 @@ func NewStore(dbs *flushable.SyncedPool, cfg StoreConfig) *Store {
 		tables = newEpochStore(db)
 		return
 	})
-
-	s.initCache()
-
-	// for compability with db before commit 591ede6
-	s.rmPrefix(s.table.PackInfos, "serverPool")
-
-	return s
 }
 
-func (s *Store) newTmpDbs(name string, maker temporary.DbMaker) *temporary.Dbs {
-	t := table.New(s.table.TmpDbs, []byte(name))
+func (s *Store) newTmpDbs(name string, id byte, maker temporary.DbMaker) *temporary.Dbs {
+	t := table.New(s.table.TmpDbs, []byte{id})
 	dbs := temporary.NewDbs(t, maker)
 	dbs.SetName(name)
 
@@ -116,13 +134,23 @@ func (s *Store) newTmpDbs(name string, maker temporary.DbMaker) *temporary.Dbs {
 }
 
 func (s *Store) initCache() {
-	s.cache.Events = s.makeCache(s.cfg.EventsCacheSize)
-	s.cache.EventsHeaders = s.makeCache(s.cfg.EventsHeadersCacheSize)
-	s.cache.Blocks = s.makeCache(s.cfg.BlockCacheSize)
-	s.cache.PackInfos = s.makeCache(s.cfg.PackInfosCacheSize)
-	s.cache.EpochStats = s.makeCache(s.cfg.EpochStatsCacheSize)
-	s.cache.TxPositions = s.makeCache(s.cfg.TxPositionsCacheSize)
-	s.cache.BlockHashes = s.makeCache(s.cfg.BlockCacheSize)
+	s.cache.Events = s.makeCache("gossip/events", func(v interface{}) int {
+		return v.(*inter.Event).Size()
+	})
+	s.cache.EventsHeaders = s.makeCache("gossip/event_headers", func(v interface{}) int {
+		e := v.(*inter.EventHeaderData)
+		return 256 + len(e.Parents)*32 + len(e.Extra)
+	})
+	s.cache.Blocks = s.makeCache("gossip/blocks", func(v interface{}) int {
+		b := v.(*inter.Block)
+		return 256 + len(b.Events)*32 + len(b.SkippedTxs)*8
+	})
+	s.cache.PackInfos = s.makeCache("gossip/pack_infos", func(v interface{}) int {
+		return 64 + len(v.(PackInfo).Heads)*32
+	})
+	s.cache.EpochStats = s.makeCache("gossip/epoch_stats", sharedcache.Fixed(256))
+	s.cache.TxPositions = s.makeCache("gossip/tx_positions", sharedcache.Fixed(128))
+	s.cache.BlockHashes = s.makeCache("gossip/block_hashes", sharedcache.Fixed(8))
 }
 
 // Close leaves underlying database.
@@ -132,6 +160,7 @@ func (s *Store) Close() {
 	}
 
 	table.MigrateTables(&s.table, nil)
+	s.purgeCaches()
 	table.MigrateCaches(&s.cache, setnil)
 
 	s.mainDb.Close()
@@ -203,6 +232,52 @@ func (s *Store) rmPrefix(t kvdb.KeyValueStore, prefix string) {
 	s.dropTable(it, t)
 }
 
+// movePrefix renames keys with the prefix from into the prefix to.
+// Keys are moved by bounded batches, the progress is flushed when it's needed.
+// It's safe because a key is renamed within a batch, so the move continues from the rest if interrupted.
+func (s *Store) movePrefix(t kvdb.KeyValueStore, from, to string) error {
+	const part = 500
+
+	for {
+		keys := make([][]byte, 0, part) // don't write during iteration
+		vals := make([][]byte, 0, part)
+		it := t.NewIteratorWithPrefix([]byte(from))
+		for len(keys) < part && it.Next() {
+			keys = append(keys, common.CopyBytes(it.Key()))
+			vals = append(vals, common.CopyBytes(it.Value()))
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		batch := t.NewBatch()
+		for i, key := range keys {
+			moved := append([]byte(to), key[len(from):]...)
+			if err := batch.Put(moved, vals[i]); err != nil {
+				return err
+			}
+			if err := batch.Delete(key); err != nil {
+				return err
+			}
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+
+		if s.dbs.IsFlushNeeded() {
+			// partial progress is flushed with its own ID, as the state differs from the flushed one
+			if err := s.Commit(nil, true); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (s *Store) dropTable(it ethdb.Iterator, t kvdb.KeyValueStore) {
 	keys := make([][]byte, 0, 500) // don't write during iteration
 
@@ -218,15 +293,22 @@ func (s *Store) dropTable(it ethdb.Iterator, t kvdb.KeyValueStore) {
 	}
 }
 
-func (s *Store) makeCache(size int) *lru.Cache {
-	if size <= 0 {
-		return nil
-	}
+// purgeCaches releases the shared cache budget.
+func (s *Store) purgeCaches() {
+	s.cache.Events.Purge()
+	s.cache.EventsHeaders.Purge()
+	s.cache.Blocks.Purge()
+	s.cache.PackInfos.Purge()
+	s.cache.EpochStats.Purge()
+	s.cache.TxPositions.Purge()
+	s.cache.BlockHashes.Purge()
+}
 
-	cache, err := lru.New(size)
-	if err != nil {
-		s.Log.Crit("Error create LRU cache", "err", err)
-		return nil
+func (s *Store) makeCache(name string, weight sharedcache.Weigher) *sharedcache.Cache {
+	if s.cfg.Cache == nil {
+		// caching is disabled
+		return sharedcache.New(0).NewCache(name, weight)
 	}
-	return cache
+
+	return s.cfg.Cache.NewCache(name, weight)
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
 
+	"github.com/Fantom-foundation/go-lachesis/kvdb/metered"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/skiperrors"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 )
@@ -27,6 +28,7 @@ type (
 func newEpochStore(db kvdb.KeyValueStore) *epochStore {
 	es := &epochStore{}
 	table.MigrateTables(es, db)
+	metered.WrapTables(es, "gossip_epoch")
 
 	err := errors.New("database closed")
 
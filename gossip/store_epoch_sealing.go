@@ -0,0 +1,33 @@
+package gossip
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+// EpochSealingStats is an app-level data of not sealed epoch, gathered for the epoch sealing rules.
+type EpochSealingStats struct {
+	Gas      uint64 // gas used by the epoch's blocks
+	Signaled bool   // sealing signal is emitted
+}
+
+// GetEpochSealingStats returns EpochSealingStats of not sealed epoch.
+func (s *Store) GetEpochSealingStats(epoch idx.Epoch) *EpochSealingStats {
+	w, _ := s.get(s.table.EpochSealingStats, epoch.Bytes(), &EpochSealingStats{}).(*EpochSealingStats)
+	if w == nil {
+		return &EpochSealingStats{}
+	}
+	return w
+}
+
+// SetEpochSealingStats stores EpochSealingStats of not sealed epoch.
+func (s *Store) SetEpochSealingStats(epoch idx.Epoch, value *EpochSealingStats) {
+	s.set(s.table.EpochSealingStats, epoch.Bytes(), value)
+}
+
+// DelEpochSealingStats removes EpochSealingStats of sealed epoch.
+func (s *Store) DelEpochSealingStats(epoch idx.Epoch) {
+	err := s.table.EpochSealingStats.Delete(epoch.Bytes())
+	if err != nil {
+		s.Log.Crit("Failed to erase EpochSealingStats", "err", err)
+	}
+}
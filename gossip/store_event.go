@@ -14,6 +14,9 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 )
 
+// eventsTableID is ID of the events table, see Store.table.
+const eventsTableID = 'e'
+
 // DeleteEvent deletes event.
 func (s *Store) DeleteEvent(epoch idx.Epoch, id hash.Event) {
 	key := id.Bytes()
@@ -68,10 +71,27 @@ func (s *Store) GetEvent(id hash.Event) *inter.Event {
 	return w
 }
 
+// GetEventByKey returns stored event by its key in the main DB, or nil if the key isn't of the events table.
+func (s *Store) GetEventByKey(key []byte) *inter.Event {
+	if len(key) != 1+len(hash.ZeroEvent) || key[0] != eventsTableID {
+		return nil
+	}
+	return s.GetEvent(hash.BytesToEvent(key[1:]))
+}
+
 func (s *Store) ForEachEvent(epoch idx.Epoch, onEvent func(event *inter.Event) bool) {
-	it := s.table.Events.NewIteratorWithPrefix(epoch.Bytes())
+	s.ForEachEventFrom(epoch, 0, onEvent)
+}
+
+// ForEachEventFrom iterates the epoch events in Lamport order, starting from Lamport from.
+func (s *Store) ForEachEventFrom(epoch idx.Epoch, from idx.Lamport, onEvent func(event *inter.Event) bool) {
+	prefix := epoch.Bytes()
+	it := s.table.Events.NewIteratorWithStart(append(prefix, from.Bytes()...))
 	defer it.Release()
 	for it.Next() {
+		if !bytes.HasPrefix(it.Key(), prefix) {
+			return
+		}
 		event := &inter.Event{}
 		err := rlp.DecodeBytes(it.Value(), event)
 		if err != nil {
@@ -0,0 +1,37 @@
+package gossip
+
+import (
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+// SetForkEvidence stores proof of a double-sign, by the fork event (the second one).
+func (s *Store) SetForkEvidence(f *inter.ForkEvidence) {
+	// event ID starts with epoch, so evidences are grouped by epochs
+	key := f.B.Hash().Bytes()
+
+	s.set(s.table.ForkEvidences, key, f)
+}
+
+// GetForkEvidences returns all the stored double-sign proofs of the epoch.
+func (s *Store) GetForkEvidences(epoch idx.Epoch) []*inter.ForkEvidence {
+	res := make([]*inter.ForkEvidence, 0)
+
+	it := s.table.ForkEvidences.NewIteratorWithPrefix(epoch.Bytes())
+	defer it.Release()
+	for it.Next() {
+		f := &inter.ForkEvidence{}
+		err := rlp.DecodeBytes(it.Value(), f)
+		if err != nil {
+			s.Log.Crit("Failed to decode fork evidence", "err", err)
+		}
+		res = append(res, f)
+	}
+	if it.Error() != nil {
+		s.Log.Crit("Failed to iterate fork evidences", "err", it.Error())
+	}
+
+	return res
+}
@@ -0,0 +1,47 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+func TestStoreForkEvidences(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	store := cachedStore()
+
+	fork := func(epoch idx.Epoch) *inter.ForkEvidence {
+		a, b := fakeEvent(), fakeEvent()
+		a.Epoch, b.Epoch = epoch, epoch
+		a.RecacheHash()
+		b.RecacheHash()
+		return &inter.ForkEvidence{
+			A: a.EventHeader,
+			B: b.EventHeader,
+		}
+	}
+
+	expect := []*inter.ForkEvidence{fork(2), fork(2)}
+	for _, f := range expect {
+		store.SetForkEvidence(f)
+	}
+	store.SetForkEvidence(fork(3))
+
+	got := map[hash.Event]hash.Event{}
+	for _, f := range store.GetForkEvidences(2) {
+		got[f.B.Hash()] = f.A.Hash()
+	}
+	assertar.Len(got, len(expect))
+	for _, f := range expect {
+		assertar.Equal(f.A.Hash(), got[f.B.Hash()])
+	}
+	assertar.Len(store.GetForkEvidences(3), 1)
+	assertar.Empty(store.GetForkEvidences(1))
+}
@@ -0,0 +1,32 @@
+package gossip
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/utils/migration"
+)
+
+// Migrations returns the manager of store schema migrations.
+// NOTE: append new migrations to the end of the list only.
+func (s *Store) Migrations() *migration.Manager {
+	return migration.New("gossip-main", s.table.Migrations,
+		migration.Migration{
+			// for compability with db before commit 591ede6
+			Name: "remove serverPool records from PackInfos",
+			Exec: func() error {
+				s.rmPrefix(s.table.PackInfos, "serverPool")
+				return nil
+			},
+		},
+		migration.Migration{
+			Name: "fixed-width ID of epoch temporary DBs table",
+			Exec: func() error {
+				err := s.movePrefix(s.table.TmpDbs, "epoch", "e")
+				if err != nil {
+					return err
+				}
+				// reload state of the moved table
+				s.initEpochDbs()
+				return nil
+			},
+		},
+	)
+}
@@ -0,0 +1,59 @@
+package gossip
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/app"
+)
+
+func TestMovePrefix(t *testing.T) {
+	assertar := assert.New(t)
+
+	s := cachedStore()
+	const count = 1234 // more than a batch
+	for i := 0; i < count; i++ {
+		key := fmt.Sprintf("epoch%04d", i)
+		assertar.NoError(s.table.TmpDbs.Put([]byte(key), []byte(key)))
+	}
+	assertar.NoError(s.table.TmpDbs.Put([]byte("other"), []byte("other")))
+
+	assertar.NoError(s.movePrefix(s.table.TmpDbs, "epoch", "e"))
+
+	for i := 0; i < count; i++ {
+		key := fmt.Sprintf("epoch%04d", i)
+		old, err := s.table.TmpDbs.Get([]byte(key))
+		assertar.NoError(err)
+		assertar.Nil(old, key)
+		moved, err := s.table.TmpDbs.Get([]byte(fmt.Sprintf("e%04d", i)))
+		assertar.NoError(err)
+		assertar.Equal(key, string(moved))
+	}
+	other, err := s.table.TmpDbs.Get([]byte("other"))
+	assertar.NoError(err)
+	assertar.Equal("other", string(other))
+}
+
+// TestAppTablesDontCollide checks table IDs of the stores which share gossip-main.
+func TestAppTablesDontCollide(t *testing.T) {
+	assertar := assert.New(t)
+
+	ids := make(map[string]string)
+	for _, store := range []interface{}{cachedStore(), app.NewMemStore()} {
+		tables, _ := reflect.TypeOf(store).Elem().FieldByName("table")
+		for i := 0; i < tables.Type.NumField(); i++ {
+			field := tables.Type.Field(i)
+			id := field.Tag.Get("table")
+			if id == "" || id == "-" {
+				continue
+			}
+			if prev, ok := ids[id]; ok {
+				assertar.Fail("table ID is used twice", "'%s' of %s and %s", id, prev, field.Name)
+			}
+			ids[id] = field.Name
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gossip
+
+import (
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip/snapsync"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
+)
+
+const (
+	// snapshot sections
+	appSection    = 'a'
+	gossipSection = 'g'
+
+	// snapshotTableIDs are IDs of the gossip tables which are copied into snapshots of sealed epochs.
+	snapshotTableIDs = "lE"
+
+	// prefixes of the sections data
+	servedPrefix   = 's'
+	downloadPrefix = 'd'
+
+	// servedSlots are slots of the served snapshot data. A new snapshot is written into the slot
+	// which isn't served, so the served snapshot is replaced at once.
+	servedSlots = "12"
+)
+
+var (
+	snapshotInfoKey = []byte("s")
+	snapshotSlotKey = []byte("l")
+)
+
+// SetSnapshotInfo stores info of the latest snapshot, and the slot of its data.
+func (s *Store) SetSnapshotInfo(info *snapsync.Info, slot byte) {
+	s.set(s.table.SnapshotInfo, snapshotInfoKey, info)
+	if err := s.table.SnapshotInfo.Put(snapshotSlotKey, []byte{slot}); err != nil {
+		s.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
+
+// GetSnapshotInfo returns info of the latest snapshot, or nil if there is no snapshot.
+func (s *Store) GetSnapshotInfo() *snapsync.Info {
+	info, _ := s.get(s.table.SnapshotInfo, snapshotInfoKey, &snapsync.Info{}).(*snapsync.Info)
+	return info
+}
+
+// GetSnapshotSlot returns the slot of the latest snapshot data.
+func (s *Store) GetSnapshotSlot() byte {
+	slot, err := s.table.SnapshotInfo.Get(snapshotSlotKey)
+	if err != nil {
+		s.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if len(slot) == 0 {
+		return servedSlots[len(servedSlots)-1]
+	}
+	return slot[0]
+}
+
+// GetFreeSnapshotSlot returns the slot which isn't served, for a new snapshot data.
+func (s *Store) GetFreeSnapshotSlot() byte {
+	if s.GetSnapshotSlot() == servedSlots[0] {
+		return servedSlots[1]
+	}
+	return servedSlots[0]
+}
+
+// GetSnapshotSection returns the table of the snapshot section data in the slot.
+func (s *Store) GetSnapshotSection(slot, id byte) kvdb.KeyValueStore {
+	return table.New(table.New(table.New(s.table.SnapshotData, []byte{servedPrefix}), []byte{slot}), []byte{id})
+}
+
+// DelSnapshot erases the snapshot data in the slot.
+func (s *Store) DelSnapshot(slot byte) {
+	it := s.table.SnapshotData.NewIteratorWithPrefix([]byte{servedPrefix, slot})
+	defer it.Release()
+	s.dropTable(it, s.table.SnapshotData)
+}
+
+// GetDownloadSection returns the table of the downloaded snapshot section data.
+func (s *Store) GetDownloadSection(id byte) kvdb.KeyValueStore {
+	return table.New(table.New(s.table.SnapshotData, []byte{downloadPrefix}), []byte{id})
+}
+
+// DelDownload erases the downloaded snapshot data.
+func (s *Store) DelDownload() {
+	it := s.table.SnapshotData.NewIteratorWithPrefix([]byte{downloadPrefix})
+	defer it.Release()
+	s.dropTable(it, s.table.SnapshotData)
+}
+
+// ExportSnapshot copies the flushed snapshot tables into dst. The DBs pool must be pinned.
+func (s *Store) ExportSnapshot(dst ethdb.KeyValueWriter) error {
+	return table.CopyTables(dst, s.dbs.GetFlushedDb("gossip-main"), []byte(snapshotTableIDs))
+}
+
+// ImportSnapshot replaces the snapshot tables with the tables from src.
+func (s *Store) ImportSnapshot(src ethdb.Iteratee) error {
+	ids := []byte(snapshotTableIDs)
+	if err := table.DropTables(s.mainDb, ids); err != nil {
+		return err
+	}
+	if err := table.CopyTables(s.mainDb, src, ids); err != nil {
+		return err
+	}
+	s.purgeCaches()
+	return nil
+}
@@ -0,0 +1,74 @@
+package gossip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip/snapsync"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+func TestStoreSnapshot(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	store := cachedStore()
+	assertar.Nil(store.GetSnapshotInfo())
+
+	// export of the flushed data
+	store.AddLastHeader(2, &inter.EventHeaderData{Creator: 1, Seq: 5})
+	assertar.NoError(store.Commit(nil, true))
+	store.AddLastHeader(2, &inter.EventHeaderData{Creator: 2, Seq: 1})
+	unpin := store.dbs.Pin()
+	slot := store.GetFreeSnapshotSlot()
+	served := store.GetSnapshotSection(slot, gossipSection)
+	assertar.NoError(store.ExportSnapshot(served))
+	unpin()
+	section, err := snapsync.SectionOf(gossipSection, served)
+	assertar.NoError(err)
+	assertar.Equal(uint64(1), section.Num)
+
+	info := &snapsync.Info{
+		Epoch:     3,
+		Block:     fakeBlock(),
+		Consensus: []byte{1, 2, 3},
+		Sections:  []snapsync.Section{section},
+	}
+	store.SetSnapshotInfo(info, slot)
+	assertar.Equal(info.Hash(), store.GetSnapshotInfo().Hash())
+	assertar.Equal(slot, store.GetSnapshotSlot())
+	assertar.NotEqual(slot, store.GetFreeSnapshotSlot())
+
+	// import into another store
+	other := cachedStore()
+	download := other.GetDownloadSection(gossipSection)
+	keys, vals, last, err := snapsync.ReadRange(served, nil, 100, 1024)
+	assertar.NoError(err)
+	assertar.True(last)
+	for i, key := range keys {
+		assertar.NoError(download.Put(key, vals[i]))
+	}
+	assertar.NoError(other.ImportSnapshot(download))
+	assertar.Equal(1, len(other.GetLastHeaders(2)))
+
+	// served snapshot and downloaded data are erased separately
+	other.SetSnapshotInfo(info, slot)
+	other.DelDownload()
+	assertar.NotNil(other.GetSnapshotInfo())
+	section, err = snapsync.SectionOf(gossipSection, other.GetDownloadSection(gossipSection))
+	assertar.NoError(err)
+	assertar.Equal(uint64(0), section.Num)
+
+	// slots are erased separately
+	free := store.GetSnapshotSection(store.GetFreeSnapshotSlot(), gossipSection)
+	assertar.NoError(free.Put([]byte("k"), []byte("v")))
+	store.DelSnapshot(slot)
+	section, err = snapsync.SectionOf(gossipSection, served)
+	assertar.NoError(err)
+	assertar.Equal(uint64(0), section.Num)
+	section, err = snapsync.SectionOf(gossipSection, free)
+	assertar.NoError(err)
+	assertar.Equal(uint64(1), section.Num)
+}
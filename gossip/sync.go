@@ -50,12 +50,21 @@ func (pm *ProtocolManager) txsyncLoop() {
 	// send starts a sending a pack of transactions from the sync.
 	send := func(s *txsync) {
 		// Fill pack with transactions up to the target size.
+		// Only hashes are sent if the peer supports transactions announcements.
+		announce := s.p.version >= lachesis64
 		size := common.StorageSize(0)
 		pack.p = s.p
 		pack.txs = pack.txs[:0]
 		for i := 0; i < len(s.txs) && size < txsyncPackSize; i++ {
+			if announce && len(pack.txs) >= softLimitItems {
+				break
+			}
 			pack.txs = append(pack.txs, s.txs[i])
-			size += s.txs[i].Size()
+			if announce {
+				size += common.HashLength
+			} else {
+				size += s.txs[i].Size()
+			}
 		}
 		// Remove the transactions that will be sent.
 		s.txs = s.txs[:copy(s.txs, s.txs[len(pack.txs):])]
@@ -65,7 +74,15 @@ func (pm *ProtocolManager) txsyncLoop() {
 		// Send the pack in the background.
 		s.p.Log().Trace("Sending batch of transactions", "count", len(pack.txs), "bytes", size)
 		sending = true
-		go func() { done <- pack.p.SendTransactions(pack.txs) }()
+		if announce {
+			hashes := make([]common.Hash, len(pack.txs))
+			for i, tx := range pack.txs {
+				hashes[i] = tx.Hash()
+			}
+			go func() { done <- pack.p.SendTxHashes(hashes) }()
+		} else {
+			go func() { done <- pack.p.SendTransactions(pack.txs) }()
+		}
 	}
 
 	// pick chooses the next pending sync.
@@ -110,9 +127,16 @@ func (pm *ProtocolManager) txsyncLoop() {
 // downloading hashes and events as well as handling the announcement handler.
 func (pm *ProtocolManager) syncer() {
 	// Start and ensure cleanup of sync mechanisms
+	defer pm.buffer.Stop()
 	pm.fetcher.Start()
 	defer pm.fetcher.Stop()
+	pm.txFetcher.Start()
+	defer pm.txFetcher.Stop()
 	defer pm.downloader.Terminate()
+	if pm.snapsyncer != nil {
+		pm.snapsyncer.Start()
+		defer pm.snapsyncer.Stop()
+	}
 
 	for {
 		select {
@@ -0,0 +1,12 @@
+package txfetcher
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	propAnnounceInMeter  = metrics.NewRegisteredGauge("txfetcher/prop/announces/in", nil)
+	propAnnounceDOSMeter = metrics.NewRegisteredGauge("txfetcher/prop/announces/dos", nil)
+
+	txFetchMeter = metrics.NewRegisteredGauge("txfetcher/fetch/txs", nil)
+)
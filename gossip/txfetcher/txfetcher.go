@@ -0,0 +1,281 @@
+package txfetcher
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/utils"
+)
+
+/*
+ * TxFetcher is a network agent, which handles hash-based transactions propagation.
+ * The core mechanic is the same as in the events fetcher: interested hash arrived => request it.
+ * An announced transaction is requested only if it didn't arrive by a direct broadcast in time,
+ * and a not arrived transaction is re-requested from another peer which announced it.
+ * Number of outstanding announces is limited per peer and in total, to protect the node against DoS.
+ */
+
+const (
+	forgetTimeout = 1 * time.Minute         // Time before an announced transaction is forgotten
+	arriveTimeout = 1000 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	gatherSlack   = 100 * time.Millisecond  // Interval used to collate almost-expired announces with fetches
+	hashLimit     = 4096                    // Maximum number of unique transactions a peer may have announced
+
+	totalHashLimit = 16 * hashLimit // Maximum number of unique transactions announced by all the peers
+
+	maxAnnounceBatch = 256 // Maximum number of hashes in an announce batch (batch is divided if exceeded)
+
+	// maxQueuedAnns is the maximum number of announce batches to queue up before
+	// dropping incoming hashes.
+	maxQueuedAnns = 128
+)
+
+var (
+	errTerminated = errors.New("terminated")
+)
+
+// FilterInterestedFn returns only transactions which may be requested.
+type FilterInterestedFn func(hashes []common.Hash) []common.Hash
+
+// TxsRequesterFn is a callback type for sending a transactions retrieval request.
+type TxsRequesterFn func(hashes []common.Hash) error
+
+// announcesBatch is the hash notification of the availability of new transactions in the network.
+type announcesBatch struct {
+	hashes []common.Hash // Hashes of the transactions being announced
+	time   time.Time     // Timestamp of the announcement
+
+	peer string // Identifier of the peer originating the notification
+
+	fetchTxs TxsRequesterFn
+}
+
+// Callback is a set of TxFetcher's callbacks.
+type Callback struct {
+	OnlyInterested FilterInterestedFn
+}
+
+// TxFetcher is responsible for accumulating transaction announcements from various peers
+// and scheduling them for retrieval.
+type TxFetcher struct {
+	notify chan *announcesBatch
+	quit   chan struct{}
+
+	callback Callback
+
+	// Announce states
+	stateMu   utils.SpinLock                    // Protects announces and announced
+	announces map[string]int                    // Per peer announce counts to prevent memory exhaustion
+	announced map[common.Hash][]*announcesBatch // Announced transactions, scheduled for fetching
+	fetching  map[common.Hash]time.Time         // Time of the first announce or the last request of transactions
+
+	logger.Periodic
+}
+
+// New creates a transactions fetcher to retrieve transactions based on hash announcements.
+func New(callback Callback) *TxFetcher {
+	loggerInstance := logger.MakeInstance()
+	return &TxFetcher{
+		notify:    make(chan *announcesBatch, maxQueuedAnns),
+		quit:      make(chan struct{}),
+		callback:  callback,
+		announces: make(map[string]int),
+		announced: make(map[common.Hash][]*announcesBatch),
+		fetching:  make(map[common.Hash]time.Time),
+
+		Periodic: logger.Periodic{Instance: loggerInstance},
+	}
+}
+
+// Start boots up the announcement based synchroniser.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based synchroniser, canceling all pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Overloaded returns true if too much transactions are being requested.
+func (f *TxFetcher) Overloaded() bool {
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+	return f.overloaded()
+}
+
+func (f *TxFetcher) overloaded() bool {
+	return len(f.notify) > maxQueuedAnns*3/4 ||
+		len(f.announced) > totalHashLimit // protected by stateMu
+}
+
+// OverloadedPeer returns true if too much transactions are being requested, or announced by the peer.
+func (f *TxFetcher) OverloadedPeer(peer string) bool {
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+	return f.overloaded() || f.announces[peer] >= hashLimit // protected by stateMu
+}
+
+// Notify announces the fetcher of the potential availability of new transactions in the network.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash, time time.Time, fetchTxs TxsRequesterFn) error {
+	// divide big batch into smaller ones
+	for start := 0; start < len(hashes); start += maxAnnounceBatch {
+		end := len(hashes)
+		if end > start+maxAnnounceBatch {
+			end = start + maxAnnounceBatch
+		}
+		op := &announcesBatch{
+			hashes:   hashes[start:end],
+			time:     time,
+			peer:     peer,
+			fetchTxs: fetchTxs,
+		}
+		select {
+		case f.notify <- op:
+			continue
+		case <-f.quit:
+			return errTerminated
+		}
+	}
+	return nil
+}
+
+// Loop is the main fetcher loop, checking and processing various notifications
+func (f *TxFetcher) loop() {
+	fetchTimer := time.NewTimer(0)
+	defer fetchTimer.Stop()
+
+	for {
+		select {
+		case <-f.quit:
+			// Fetcher terminating, abort all operations
+			return
+
+		case notification := <-f.notify:
+			// Transactions were announced, make sure the peer isn't DOSing us
+			propAnnounceInMeter.Update(int64(len(notification.hashes)))
+
+			count := f.announces[notification.peer]
+			if count+len(notification.hashes) > hashLimit {
+				f.Periodic.Debug(time.Second, "Peer exceeded outstanding tx announces", "peer", notification.peer, "limit", hashLimit)
+				propAnnounceDOSMeter.Update(1)
+				break
+			}
+
+			first := len(f.fetching) == 0
+
+			// filter only not known
+			notification.hashes = f.callback.OnlyInterested(notification.hashes)
+			if len(notification.hashes) == 0 {
+				break
+			}
+
+			f.stateMu.Lock()
+			for _, h := range notification.hashes {
+				// other peers may already have announced it, so it's an array
+				f.announced[h] = append(f.announced[h], notification)
+				count++ // f.announced and f.announces must be synced!
+				// if it wasn't announced before, then wait for a direct broadcast before fetching
+				if _, ok := f.fetching[h]; !ok {
+					f.fetching[h] = notification.time
+				}
+			}
+			f.announces[notification.peer] = count
+			f.stateMu.Unlock()
+
+			if first && len(f.fetching) != 0 {
+				f.rescheduleFetch(fetchTimer)
+			}
+
+		case now := <-fetchTimer.C:
+			// At least one transaction's timer ran out, check for needing retrieval
+			request := make(map[string][]common.Hash)
+			requesters := make(map[string]TxsRequesterFn)
+
+			all := make([]common.Hash, 0, len(f.announced))
+			for h := range f.announced {
+				all = append(all, h)
+			}
+			notArrived := f.callback.OnlyInterested(all)
+			notArrivedM := make(map[common.Hash]bool, len(notArrived))
+
+			for _, h := range notArrived {
+				notArrivedM[h] = true
+				announces := f.announced[h]
+
+				oldest := announces[0] // first is the oldest
+				if now.Sub(oldest.time) > forgetTimeout {
+					// Forget too old announces
+					f.forgetHash(h)
+				} else if now.Sub(f.fetching[h]) > arriveTimeout-gatherSlack {
+					// The transaction still didn't arrive, queue for fetching from a random peer
+					announce := announces[rand.Intn(len(announces))]
+					request[announce.peer] = append(request[announce.peer], h)
+					requesters[announce.peer] = announce.fetchTxs
+					f.stateMu.Lock()
+					f.fetching[h] = now
+					f.stateMu.Unlock()
+				}
+			}
+
+			// Forget arrived transactions
+			for _, h := range all {
+				if !notArrivedM[h] {
+					f.forgetHash(h)
+				}
+			}
+
+			// Send out all transactions requests
+			for peer, hashes := range request {
+				f.Log.Trace("Fetching scheduled transactions", "peer", peer, "count", len(hashes))
+
+				fetchTxs, hashes := requesters[peer], hashes
+				go func(peer string) {
+					txFetchMeter.Update(int64(len(hashes)))
+					err := fetchTxs(hashes)
+					if err != nil {
+						f.Periodic.Warn(time.Second, "Transactions request error", "peer", peer, "err", err)
+					}
+				}(peer)
+			}
+			// Schedule the next fetch if transactions are still pending
+			f.rescheduleFetch(fetchTimer)
+		}
+	}
+}
+
+// rescheduleFetch resets the specified fetch timer to the next announce timeout.
+func (f *TxFetcher) rescheduleFetch(fetch *time.Timer) {
+	// Short circuit if no transactions are announced
+	if len(f.announced) == 0 {
+		return
+	}
+	// Otherwise find the earliest expiring announcement
+	earliest := time.Now()
+	for _, t := range f.fetching {
+		if earliest.After(t) {
+			earliest = t
+		}
+	}
+	fetch.Reset(arriveTimeout - time.Since(earliest))
+}
+
+// forgetHash removes all traces of a transaction announcement from the fetcher's internal state.
+func (f *TxFetcher) forgetHash(h common.Hash) {
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+
+	// Remove all pending announces and decrement DOS counters
+	for _, announce := range f.announced[h] {
+		f.announces[announce.peer]--
+		if f.announces[announce.peer] <= 0 {
+			delete(f.announces, announce.peer)
+		}
+	}
+	delete(f.announced, h)
+	delete(f.fetching, h)
+}
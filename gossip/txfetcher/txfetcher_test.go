@@ -0,0 +1,192 @@
+package txfetcher
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPool struct {
+	known map[common.Hash]bool
+	mu    sync.Mutex
+}
+
+func (p *testPool) onlyInterested(hashes []common.Hash) []common.Hash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := make([]common.Hash, 0, len(hashes))
+	for _, h := range hashes {
+		if !p.known[h] {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+func (p *testPool) add(hashes []common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, h := range hashes {
+		p.known[h] = true
+	}
+}
+
+func testHashes(from, num int) []common.Hash {
+	hashes := make([]common.Hash, num)
+	for i := range hashes {
+		hashes[i] = common.BigToHash(big.NewInt(int64(from + i)))
+	}
+	return hashes
+}
+
+func TestTxFetcher(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := &testPool{known: make(map[common.Hash]bool)}
+	f := New(Callback{
+		OnlyInterested: pool.onlyInterested,
+	})
+	f.Start()
+	defer f.Stop()
+
+	requests := make(chan []common.Hash, 10)
+	requester := func(hashes []common.Hash) error {
+		requests <- hashes
+		return nil
+	}
+	// the second peer never answers
+	silentRequests := make(chan []common.Hash, 10)
+	silent := func(hashes []common.Hash) error {
+		silentRequests <- hashes
+		return nil
+	}
+
+	known := testHashes(0, 2)
+	pool.add(known)
+
+	// only unknown txs are requested, after a time allowance for a direct broadcast
+	assertar.NoError(f.Notify("peer1", append(known, testHashes(10, 3)...), time.Now(), silent))
+	select {
+	case <-silentRequests:
+		t.Fatal("txs are requested before a direct broadcast")
+	case <-time.After(arriveTimeout / 2):
+	}
+	select {
+	case got := <-silentRequests:
+		assertar.ElementsMatch(testHashes(10, 3), got)
+	case <-time.After(arriveTimeout):
+		t.Fatal("txs aren't requested")
+	}
+
+	// announced txs aren't requested twice at once
+	assertar.NoError(f.Notify("peer2", testHashes(10, 3), time.Now(), requester))
+	select {
+	case <-requests:
+		t.Fatal("txs are requested twice")
+	case <-time.After(arriveTimeout / 2):
+	}
+
+	// not arrived txs are requested again
+	var got []common.Hash
+	for len(got) < 3 {
+		select {
+		case hashes := <-requests:
+			got = append(got, hashes...)
+		case hashes := <-silentRequests:
+			got = append(got, hashes...)
+		case <-time.After(3 * arriveTimeout):
+			t.Fatal("txs aren't requested again")
+		}
+	}
+	assertar.ElementsMatch(testHashes(10, 3), got)
+
+	// arrived txs are forgotten
+	pool.add(testHashes(10, 3))
+	time.Sleep(2 * arriveTimeout)
+	assertar.False(f.Overloaded())
+	f.stateMu.Lock()
+	assertar.Equal(0, len(f.announced))
+	assertar.Equal(0, len(f.announces))
+	f.stateMu.Unlock()
+}
+
+func TestTxFetcherDoS(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := &testPool{known: make(map[common.Hash]bool)}
+	f := New(Callback{
+		OnlyInterested: pool.onlyInterested,
+	})
+	f.Start()
+	defer f.Stop()
+
+	requested := 0
+	var mu sync.Mutex
+	requester := func(hashes []common.Hash) error {
+		mu.Lock()
+		defer mu.Unlock()
+		requested += len(hashes)
+		return nil
+	}
+
+	// the peer exceeds the limit of outstanding announces
+	assertar.NoError(f.Notify("peer1", testHashes(0, hashLimit), time.Now(), requester))
+	assertar.NoError(f.Notify("peer1", testHashes(hashLimit, maxAnnounceBatch), time.Now(), requester))
+	time.Sleep(2 * arriveTimeout)
+
+	mu.Lock()
+	assertar.Equal(hashLimit, requested)
+	mu.Unlock()
+	f.stateMu.Lock()
+	assertar.Equal(hashLimit, f.announces["peer1"])
+	f.stateMu.Unlock()
+
+	// the limit is per peer
+	assertar.True(f.OverloadedPeer("peer1"))
+	assertar.False(f.OverloadedPeer("peer2"))
+	assertar.False(f.Overloaded())
+
+	// and in total
+	for i := 1; i <= totalHashLimit/hashLimit; i++ {
+		assertar.NoError(f.Notify(fmt.Sprintf("peer%d", i+1), testHashes(i*hashLimit, hashLimit), time.Now(), requester))
+	}
+	time.Sleep(arriveTimeout / 2)
+	assertar.True(f.Overloaded())
+	assertar.True(f.OverloadedPeer("peer2"))
+}
+
+func TestTxFetcherBroadcast(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := &testPool{known: make(map[common.Hash]bool)}
+	f := New(Callback{
+		OnlyInterested: pool.onlyInterested,
+	})
+	f.Start()
+	defer f.Stop()
+
+	requests := make(chan []common.Hash, 10)
+	requester := func(hashes []common.Hash) error {
+		requests <- hashes
+		return nil
+	}
+
+	// announced txs arrive by a direct broadcast, so they aren't requested
+	assertar.NoError(f.Notify("peer1", testHashes(0, 3), time.Now(), requester))
+	time.Sleep(arriveTimeout / 2)
+	pool.add(testHashes(0, 2))
+
+	select {
+	case got := <-requests:
+		assertar.Equal(testHashes(2, 1), got)
+	case <-time.After(arriveTimeout):
+		t.Fatal("not arrived tx isn't requested")
+	}
+}
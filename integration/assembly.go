@@ -2,6 +2,7 @@ package integration
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -11,57 +12,181 @@ import (
 
 	"github.com/Fantom-foundation/go-lachesis/app"
 	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
 	"github.com/Fantom-foundation/go-lachesis/poset"
+	"github.com/Fantom-foundation/go-lachesis/utils/migration"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 )
 
 // MakeEngine makes consensus engine from config.
-func MakeEngine(dataDir string, gossipCfg *gossip.Config) (*poset.Poset, *app.Store, *gossip.Store) {
-	dbs := flushable.NewSyncedPool(dbProducer(dataDir))
+// Databases which aren't synced after a crash are rebuilt from the stored events.
+func MakeEngine(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config) (*poset.Poset, *app.Store, *gossip.Store) {
+	dbs, err := openDbs(dataDir, dbCfg, gossipCfg)
+	if err != nil {
+		utils.Fatalf("Failed to open databases: %v", err)
+	}
+	return makeEngineWith(dbs, dbCfg, gossipCfg)
+}
 
-	appStoreConfig := app.StoreConfig{
-		ReceiptsCacheSize:   gossipCfg.ReceiptsCacheSize,
-		DelegatorsCacheSize: gossipCfg.DelegatorsCacheSize,
-		StakersCacheSize:    gossipCfg.StakersCacheSize,
+func makeEngine(producer kvdb.DbProducer, dbCfg DbConfig, gossipCfg *gossip.Config) (*poset.Poset, *app.Store, *gossip.Store) {
+	return makeEngineWith(flushable.NewSyncedPool(producer), dbCfg, gossipCfg)
+}
+
+func makeEngineWith(dbs *flushable.SyncedPool, dbCfg DbConfig, gossipCfg *gossip.Config) (*poset.Poset, *app.Store, *gossip.Store) {
+	adb, gdb, cdb := makeStoresWith(dbs, dbCfg, gossipCfg)
+
+	err := migrate(gdb, false, storesMigrations(adb, gdb, cdb)...)
+	if err != nil {
+		utils.Fatalf("Failed to migrate databases: %v", err)
 	}
-	adb := app.NewStore(dbs, appStoreConfig)
-	gdb := gossip.NewStore(dbs, gossipCfg.StoreConfig)
-	cdb := poset.NewStore(dbs, poset.DefaultStoreConfig())
 
 	// write genesis
+	isNew, err := applyGenesis(dbs, adb, gdb, cdb, &gossipCfg.Net)
+	if err != nil {
+		utils.Fatalf("Failed to write genesis state: %v", err)
+	}
 
-	// TODO: replace first block with DB-migrations
-	firstBlock := gdb.GetBlock(0)
-	state, _, err := adb.ApplyGenesis(&gossipCfg.Net, firstBlock)
+	if isNew {
+		log.Info("Applied genesis state", "hash", cdb.GetGenesisHash().String())
+	} else {
+		log.Info("Genesis state is already written", "hash", cdb.GetGenesisHash().String())
+	}
+
+	// create consensus
+	engine := poset.New(gossipCfg.Net.Dag, cdb, gdb)
+
+	return engine, adb, gdb
+}
+
+// applyGenesis writes genesis state into all the stores and flushes it.
+func applyGenesis(dbs *flushable.SyncedPool, adb *app.Store, gdb *gossip.Store, cdb *poset.Store, net *lachesis.Config) (isNew bool, err error) {
+	state, _, err := adb.ApplyGenesis(net)
 	if err != nil {
-		utils.Fatalf("Failed to write App genesis state: %v", err)
+		return false, fmt.Errorf("app: %w", err)
 	}
 
-	genesisAtropos, genesisState, isNew, err := gdb.ApplyGenesis(&gossipCfg.Net, state)
+	genesisAtropos, genesisState, isNew, err := gdb.ApplyGenesis(net, state)
 	if err != nil {
-		utils.Fatalf("Failed to write Gossip genesis state: %v", err)
+		return false, fmt.Errorf("gossip: %w", err)
 	}
 
-	err = cdb.ApplyGenesis(&gossipCfg.Net.Genesis, genesisAtropos, genesisState)
+	err = cdb.ApplyGenesis(&net.Genesis, genesisAtropos, genesisState)
 	if err != nil {
-		utils.Fatalf("Failed to write Poset genesis state: %v", err)
+		return false, fmt.Errorf("poset: %w", err)
 	}
 
 	err = dbs.Flush(genesisAtropos.Bytes())
 	if err != nil {
-		utils.Fatalf("Failed to flush genesis state: %v", err)
+		return false, fmt.Errorf("flush: %w", err)
 	}
 
-	if isNew {
-		log.Info("Applied genesis state", "hash", cdb.GetGenesisHash().String())
+	return isNew, nil
+}
+
+// MigrateDbs applies pending migrations of the databases.
+// In dry-run mode it only logs them and the databases are opened read-only.
+func MigrateDbs(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config, dryRun bool) error {
+	var dbs *flushable.SyncedPool
+	if dryRun {
+		dbs = flushable.NewReadOnlySyncedPool(readOnlyDbProducer(dataDir, dbCfg))
 	} else {
-		log.Info("Genesis state is already written", "hash", cdb.GetGenesisHash().String())
+		var err error
+		dbs, err = openDbs(dataDir, dbCfg, gossipCfg)
+		if err != nil {
+			return err
+		}
 	}
+	defer dbs.Close()
+	adb, gdb, cdb := makeStoresWith(dbs, dbCfg, gossipCfg)
 
-	// create consensus
-	engine := poset.New(gossipCfg.Net.Dag, cdb, gdb)
+	return migrate(gdb, dryRun, storesMigrations(adb, gdb, cdb)...)
+}
 
-	return engine, adb, gdb
+// OpenReadOnlyStores opens the existing databases in read-only mode, e.g. to inspect a datadir.
+// The DBs are opened with a shared lock, which conflicts with the exclusive lock of a running node,
+// so the node must be stopped. Other read-only openers aren't blocked.
+// Changes of the stores are never written, Commit fails. Migrations aren't applied,
+// so the stores data may be outdated if the node hasn't migrated it yet.
+func OpenReadOnlyStores(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config) (*flushable.SyncedPool, *app.Store, *gossip.Store, *poset.Store, error) {
+	dbs := flushable.NewReadOnlySyncedPool(readOnlyDbProducer(dataDir, dbCfg))
+	adb, gdb, cdb := makeStoresWith(dbs, dbCfg, gossipCfg)
+
+	for _, m := range storesMigrations(adb, gdb, cdb) {
+		pending, err := m.Pending()
+		if err != nil {
+			_ = dbs.Close()
+			return nil, nil, nil, nil, err
+		}
+		if len(pending) != 0 {
+			log.Warn("Database isn't migrated yet", "db", m.Name(), "pending", len(pending))
+		}
+	}
+
+	return dbs, adb, gdb, cdb, nil
+}
+
+func makeStores(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config) (*flushable.SyncedPool, *app.Store, *gossip.Store, *poset.Store) {
+	dbs := flushable.NewSyncedPool(dbProducer(dataDir, dbCfg))
+	adb, gdb, cdb := makeStoresWith(dbs, dbCfg, gossipCfg)
+	return dbs, adb, gdb, cdb
+}
+
+func makeStoresWith(dbs *flushable.SyncedPool, dbCfg DbConfig, gossipCfg *gossip.Config) (*app.Store, *gossip.Store, *poset.Store) {
+	// all the stores share the same cache budget
+	cache := sharedcache.New(dbCfg.Cache * 1024 * 1024)
+
+	appStoreConfig := app.StoreConfig{
+		Cache:                cache,
+		CompressionThreshold: gossipCfg.CompressionThreshold,
+	}
+	gossipStoreConfig := gossipCfg.StoreConfig
+	gossipStoreConfig.Cache = cache
+	posetStoreConfig := poset.DefaultStoreConfig()
+	posetStoreConfig.Cache = cache
+
+	adb := app.NewStore(dbs, appStoreConfig)
+	gdb := gossip.NewStore(dbs, gossipStoreConfig)
+	cdb := poset.NewStore(dbs, posetStoreConfig)
+
+	return adb, gdb, cdb
+}
+
+// storesMigrations returns migrations managers of the stores, in the order they're applied.
+func storesMigrations(adb *app.Store, gdb *gossip.Store, cdb *poset.Store) []*migration.Manager {
+	return []*migration.Manager{
+		gdb.Migrations(),
+		adb.Migrations(func() *inter.Block {
+			return gdb.GetBlock(0)
+		}),
+		cdb.Migrations(),
+	}
+}
+
+// migrate applies pending migrations of all the stores and flushes the result.
+// Nothing is applied if any of stores has unknown schema.
+func migrate(gdb *gossip.Store, dryRun bool, managers ...*migration.Manager) error {
+	for _, m := range managers {
+		if _, err := m.Pending(); err != nil {
+			return err
+		}
+	}
+
+	applied := 0
+	for _, m := range managers {
+		n, err := m.Exec(dryRun)
+		applied += n
+		if err != nil {
+			return err
+		}
+	}
+
+	if dryRun || applied == 0 {
+		return nil
+	}
+	return gdb.Commit(nil, true)
 }
 
 // SetAccountKey sets key into accounts manager and unlocks it with pswd.
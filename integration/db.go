@@ -1,15 +1,78 @@
 package integration
 
 import (
+	"github.com/ethereum/go-ethereum/cmd/utils"
+
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/bboltdb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/leveldb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
 )
 
-func dbProducer(dbdir string) kvdb.DbProducer {
+const (
+	// LevelDbEngine is a default on-disk key-value engine.
+	LevelDbEngine = "leveldb"
+	// BboltEngine is a pure Go on-disk key-value engine.
+	BboltEngine = "bbolt"
+)
+
+// DbConfig is a config for node databases.
+type DbConfig struct {
+	// Engine of on-disk databases (leveldb or bbolt).
+	Engine string
+	// Cache is a memory budget (in megabytes) shared by the stores caches.
+	Cache int
+}
+
+// DefaultDbConfig returns the default databases config.
+func DefaultDbConfig() DbConfig {
+	return DbConfig{
+		Engine: LevelDbEngine,
+		Cache:  512,
+	}
+}
+
+func dbProducer(dbdir string, cfg DbConfig) kvdb.DbProducer {
 	if dbdir == "inmemory" || dbdir == "" {
 		return memorydb.NewProducer("")
 	}
 
-	return leveldb.NewProducer(dbdir)
+	return selectProducer(dbdir, cfg, map[string]kvdb.DbProducer{
+		LevelDbEngine: leveldb.NewProducer(dbdir),
+		BboltEngine:   bboltdb.NewProducer(dbdir),
+	})
+}
+
+// readOnlyDbProducer opens only existing DBs and rejects any changes.
+func readOnlyDbProducer(dbdir string, cfg DbConfig) kvdb.DbProducer {
+	if dbdir == "inmemory" || dbdir == "" {
+		return memorydb.NewProducer("")
+	}
+
+	return selectProducer(dbdir, cfg, map[string]kvdb.DbProducer{
+		LevelDbEngine: leveldb.NewReadOnlyProducer(dbdir),
+		BboltEngine:   bboltdb.NewReadOnlyProducer(dbdir),
+	})
+}
+
+func selectProducer(dbdir string, cfg DbConfig, producers map[string]kvdb.DbProducer) kvdb.DbProducer {
+	engine := cfg.Engine
+	if engine == "" {
+		engine = LevelDbEngine
+	}
+	producer, ok := producers[engine]
+	if !ok {
+		utils.Fatalf("Unknown database engine %q", engine)
+	}
+
+	// refuse to start from scratch if datadir is created by another engine
+	if len(producer.Names()) == 0 {
+		for other, p := range producers {
+			if other != engine && len(p.Names()) != 0 {
+				utils.Fatalf("Datadir %s contains %s databases, but %s engine is selected", dbdir, other, engine)
+			}
+		}
+	}
+
+	return producer
 }
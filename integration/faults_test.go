@@ -0,0 +1,174 @@
+package integration
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/fallible"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/poset"
+)
+
+// faultsTestNode is a consensus engine with stores, which processes events by gossip.Processor.
+type faultsTestNode struct {
+	engine *poset.Poset
+	proc   *gossip.Processor
+	gdb    *gossip.Store
+
+	// decided blocks, it survives node restarts
+	blocks map[idx.Block]hash.Event
+	forks  int
+}
+
+func (n *faultsTestNode) start(producer kvdb.DbProducer, gossipCfg *gossip.Config) {
+	engine, adb, gdb := makeEngine(producer, DbConfig{}, gossipCfg)
+	proc := gossip.NewProcessor(gossipCfg, gdb, engine, adb, gossip.ProcessorHooks{
+		OnNewBlock: func(block *inter.Block, cheaters inter.Cheaters) {
+			if atropos, ok := n.blocks[block.Index]; ok && atropos != block.Atropos {
+				n.forks++
+			}
+			n.blocks[block.Index] = block.Atropos
+		},
+	})
+
+	n.engine = engine
+	n.proc = proc
+	n.gdb = gdb
+}
+
+// process event and flush the result.
+func (n *faultsTestNode) process(e *inter.Event) error {
+	if n.gdb.HasEvent(e.Hash()) {
+		// processed before the restart
+		return nil
+	}
+
+	err := n.proc.Engine().ProcessEvent(e)
+	if err != nil {
+		return err
+	}
+
+	return n.gdb.Commit(e.Hash().Bytes(), true)
+}
+
+func TestFlakyDisk(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	network := lachesis.FakeNetConfig(genesis.FakeValidators(5, big.NewInt(0), pos.StakeToBalance(10000)))
+	gossipCfg := gossip.DefaultConfig(network)
+
+	// NOTE: silent bit flips aren't injected, the stores have no checksums to detect them
+	faults := fallible.NewInjector(fallible.Faults{
+		Seed:          1,
+		ReadErrRate:   0.0002,
+		WriteErrRate:  0.001,
+		TornBatchRate: 0.01,
+		Crash:         true,
+	})
+	namespace := fmt.Sprintf("integration.TestFlakyDisk-%d", rand.Int())
+	flakyProducer := memorydb.NewProducer(namespace, func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return fallible.WrapWithFaults(db, faults)
+	})
+
+	expected := &faultsTestNode{blocks: map[idx.Block]hash.Event{}}
+	expected.start(memorydb.NewProducer(""), &gossipCfg)
+
+	flaky := &faultsTestNode{blocks: map[idx.Block]hash.Event{}}
+	restarts := 0
+	crashed := func(f func()) (crashed bool) {
+		defer func() {
+			if r := recover(); r != nil {
+				require.Equal(fallible.ErrInjected, r)
+				crashed = true
+			}
+		}()
+		f()
+		return
+	}
+	restart := func() {
+		for crashed(func() {
+			flaky.start(flakyProducer, &gossipCfg)
+		}) {
+			log.Info("Node crashed on start")
+		}
+		restarts++
+	}
+	restart()
+
+	var nodes []idx.StakerID
+	for _, v := range network.Genesis.Alloc.Validators {
+		nodes = append(nodes, v.ID)
+	}
+
+	var ordered inter.Events
+	inter.ForEachRandEvent(nodes, 100, 3, rand.New(rand.NewSource(0)), inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			ordered = append(ordered, e)
+			require.NoError(expected.process(e))
+		},
+		Build: func(e *inter.Event, name string) *inter.Event {
+			e.Epoch = 1
+			return expected.engine.Prepare(e)
+		},
+	})
+	require.NotEmpty(expected.blocks)
+
+	for _, e := range ordered {
+		// invalid event is rejected by engine and erased
+		if e.Seq == 1 {
+			invalid := &inter.Event{}
+			raw, err := rlp.EncodeToBytes(e)
+			require.NoError(err)
+			require.NoError(rlp.DecodeBytes(raw, invalid))
+			invalid.PrevEpochHash = common.Hash{0xff}
+			invalid.RecacheHash()
+
+			for crashed(func() {
+				assertar.Error(flaky.process(invalid))
+				assertar.False(flaky.gdb.HasEvent(invalid.Hash()))
+			}) {
+				restart()
+			}
+		}
+
+		for crashed(func() {
+			require.NoError(flaky.process(e))
+		}) {
+			restart()
+		}
+	}
+
+	t.Logf("restarts: %d", restarts)
+	reads, writes := faults.Counters()
+	t.Logf("operations: %d reads, %d writes", reads, writes)
+	assertar.True(restarts > 1, "no fault is injected")
+
+	// compare state after a clean restart
+	faults.Disable()
+	restart()
+	assertar.Equal(0, flaky.forks)
+	assertar.Equal(expected.blocks, flaky.blocks)
+	assertar.Equal(expected.engine.Checkpoint, flaky.engine.Checkpoint)
+	for _, e := range ordered {
+		assertar.True(flaky.gdb.HasEvent(e.Hash()))
+	}
+}
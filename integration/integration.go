@@ -13,7 +13,7 @@ import (
 func NewIntegration(ctx *adapters.ServiceContext, network lachesis.Config) *gossip.Service {
 	gossipCfg := gossip.DefaultConfig(network)
 
-	engine, adb, gdb := MakeEngine(ctx.Config.DataDir, &gossipCfg)
+	engine, adb, gdb := MakeEngine(ctx.Config.DataDir, DefaultDbConfig(), &gossipCfg)
 
 	coinbase := SetAccountKey(
 		ctx.NodeContext.AccountManager,
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/readonly"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+)
+
+func TestOpenReadOnlyStores(t *testing.T) {
+	for _, engine := range []string{LevelDbEngine, BboltEngine} {
+		t.Run(engine, func(t *testing.T) {
+			testOpenReadOnlyStores(t, DbConfig{Engine: engine})
+		})
+	}
+}
+
+func testOpenReadOnlyStores(t *testing.T, dbCfg DbConfig) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	network := lachesis.FakeNetConfig(genesis.FakeValidators(3, big.NewInt(0), pos.StakeToBalance(10000)))
+	gossipCfg := gossip.DefaultConfig(network)
+
+	dir, err := ioutil.TempDir("", "readonly")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// genesis state
+	dbs, adb, gdb, cdb := makeStores(dir, dbCfg, &gossipCfg)
+	_, err = applyGenesis(dbs, adb, gdb, cdb, &gossipCfg.Net)
+	require.NoError(err)
+	genesisHash := cdb.GetGenesisHash()
+	flushID, err := dbs.FlushID()
+	require.NoError(err)
+	names := dbs.Names()
+	require.NoError(dbs.Close())
+
+	dbs, _, gdb, cdb, err = OpenReadOnlyStores(dir, dbCfg, &gossipCfg)
+	require.NoError(err)
+	assertar.True(dbs.IsReadOnly())
+	assertar.Equal(genesisHash, cdb.GetGenesisHash())
+	assertar.NotNil(gdb.GetBlock(0))
+
+	// changes aren't written
+	gdb.SetBlock(&inter.Block{Index: 1})
+	assertar.Equal(readonly.ErrReadOnly, gdb.Commit(nil, true))
+	require.NoError(dbs.Close())
+
+	dbs, _, gdb, _ = makeStores(dir, dbCfg, &gossipCfg)
+	defer dbs.Close()
+	id, err := dbs.FlushID()
+	require.NoError(err)
+	assertar.Equal(flushID, id)
+	assertar.Equal(names, dbs.Names())
+	assertar.Nil(gdb.GetBlock(1))
+}
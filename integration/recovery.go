@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
+)
+
+// openDbs opens the datadir databases. If they aren't synced and it can't be fixed by the flush journal
+// (e.g. a DB lost the last writes after a power loss), then they're brought to the last common flush:
+//  1. the events which gossip-main stored after the common flush are read into memory;
+//  2. the DBs which are ahead are rolled back to the common flush by their undo journals;
+//  3. the events are replayed by gossip.Processor, so all the DBs are rolled forward by the same events.
+//
+// If the node is killed before the events are replayed, the rolled back events are downloaded again.
+// Consensus is restarted from poset.Checkpoint of the common flush.
+func openDbs(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config) (*flushable.SyncedPool, error) {
+	producer := dbProducer(dataDir, dbCfg)
+
+	dbs, err := flushable.OpenSyncedPool(producer)
+	if !errors.Is(err, flushable.ErrNotSynced) {
+		return dbs, err
+	}
+	log.Warn("Databases aren't synced", "err", err)
+
+	events, err := rollbackDbs(dbs, gossipCfg)
+	if err != nil {
+		_ = dbs.Close()
+		return nil, fmt.Errorf("failed to roll back databases: %w", err)
+	}
+	err = replayEvents(dbs, events, dbCfg, gossipCfg)
+	if cerr := dbs.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay events: %w", err)
+	}
+	log.Info("Databases are recovered", "events", len(events))
+
+	return flushable.OpenSyncedPool(producer)
+}
+
+// rollbackDbs rolls the DBs back to the last common flush, and returns the rolled back events in the replay order.
+func rollbackDbs(dbs *flushable.SyncedPool, gossipCfg *gossip.Config) (inter.Events, error) {
+	ids, err := dbs.LastFlushIDs()
+	if err != nil {
+		return nil, err
+	}
+	for name, id := range ids {
+		log.Info("Last flush", "db", name, "id", fmt.Sprintf("%x", id))
+	}
+
+	id, err := dbs.RollbackID()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := dbs.UndoneKeys("gossip-main", id)
+	if err != nil {
+		return nil, err
+	}
+	gdb := gossip.NewStore(dbs, gossipCfg.StoreConfig)
+	var events inter.Events
+	for _, key := range keys {
+		// deleted events and other keys are skipped
+		if e := gdb.GetEventByKey(key); e != nil {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Epoch != b.Epoch {
+			return a.Epoch < b.Epoch
+		}
+		if a.Lamport != b.Lamport {
+			return a.Lamport < b.Lamport
+		}
+		return bytes.Compare(a.Hash().Bytes(), b.Hash().Bytes()) < 0
+	})
+
+	log.Warn("Rolling back databases", "id", fmt.Sprintf("%x", id), "events", len(events))
+	return events, dbs.Rollback(id)
+}
+
+// replayEvents processes the rolled back events again. Any error is fatal,
+// as the events were processed already before the rollback.
+func replayEvents(dbs *flushable.SyncedPool, events inter.Events, dbCfg DbConfig, gossipCfg *gossip.Config) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	cfg := *gossipCfg // copy data
+	// snapshot of the last epoch will be made when the next epoch is sealed
+	cfg.Snapshot.Serve = false
+
+	engine, adb, gdb := makeEngineWith(dbs, dbCfg, &cfg)
+	proc := gossip.NewProcessor(&cfg, gdb, engine, adb, gossip.ProcessorHooks{})
+	for _, e := range events {
+		if gdb.HasEvent(e.Hash()) {
+			continue
+		}
+		err := proc.Engine().ProcessEvent(e)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", e.Hash().String(), err)
+		}
+	}
+
+	return gdb.Commit(events[len(events)-1].Hash().Bytes(), true)
+}
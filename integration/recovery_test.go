@@ -0,0 +1,203 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/poset"
+)
+
+func TestRecoverUnsyncedDbs(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		stale string
+	}{
+		{name: "gossip is behind", stale: "gossip-main"},
+		{name: "poset is behind the epoch seal", stale: "poset-main"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			testRecoverUnsyncedDbs(t, c.stale)
+		})
+	}
+}
+
+// testRecoverUnsyncedDbs kills the node between flushes of the DBs, i.e. the stale DB isn't flushed
+// with the others, and checks that the node restarts from the rolled back DBs.
+func testRecoverUnsyncedDbs(t *testing.T, stale string) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	network := lachesis.FakeNetConfig(genesis.FakeValidators(5, big.NewInt(0), pos.StakeToBalance(10000)))
+	network.Dag.MaxEpochBlocks = 3
+	gossipCfg := gossip.DefaultConfig(network)
+	dbCfg := DbConfig{}
+
+	dir, err := ioutil.TempDir("", "recovery")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	backup, err := ioutil.TempDir("", "recovery-backup")
+	require.NoError(err)
+	defer os.RemoveAll(backup)
+
+	// generate events of 2 epochs, and remember the expected state at the flushes
+	expected := &faultsTestNode{blocks: map[idx.Block]hash.Event{}}
+	expected.start(memorydb.NewProducer(""), &gossipCfg)
+
+	var nodes []idx.StakerID
+	for _, v := range network.Genesis.Alloc.Validators {
+		nodes = append(nodes, v.ID)
+	}
+	var ordered inter.Events
+	checkpoints := make(map[int]poset.Checkpoint)
+	epochs := make(map[int]idx.Epoch)
+	sealed := 0
+	for epoch := idx.Epoch(1); epoch <= 2; epoch++ {
+		inter.ForEachRandEvent(nodes, 60, 3, rand.New(rand.NewSource(int64(epoch))), inter.ForEachEvent{
+			Process: func(e *inter.Event, name string) {
+				ordered = append(ordered, e)
+				require.NoError(expected.process(e))
+				checkpoints[len(ordered)] = *expected.engine.Checkpoint
+				epochs[len(ordered)] = expected.engine.GetEpoch()
+			},
+			Build: func(e *inter.Event, name string) *inter.Event {
+				if expected.engine.GetEpoch() != epoch {
+					// epoch is sealed
+					return nil
+				}
+				e.Epoch = epoch
+				return expected.engine.Prepare(e)
+			},
+		})
+		if epoch == 1 {
+			sealed = len(ordered)
+		}
+	}
+	flush1, flush2 := sealed/2, sealed+(len(ordered)-sealed)/2
+	require.Equal(idx.Epoch(1), epochs[flush1])
+	require.Equal(idx.Epoch(2), epochs[flush2], "epoch isn't sealed")
+
+	type node struct {
+		dbs    *flushable.SyncedPool
+		engine *poset.Poset
+		proc   *gossip.Processor
+		gdb    *gossip.Store
+	}
+	start := func() *node {
+		dbs, err := openDbs(dir, dbCfg, &gossipCfg)
+		require.NoError(err)
+		engine, adb, gdb := makeEngineWith(dbs, dbCfg, &gossipCfg)
+		proc := gossip.NewProcessor(&gossipCfg, gdb, engine, adb, gossip.ProcessorHooks{})
+		return &node{dbs, engine, proc, gdb}
+	}
+	process := func(n *node, events inter.Events) {
+		for _, e := range events {
+			if n.gdb.HasEvent(e.Hash()) || e.Epoch != n.engine.GetEpoch() {
+				continue
+			}
+			require.NoError(n.proc.Engine().ProcessEvent(e))
+		}
+	}
+
+	n := start()
+	process(n, ordered[:flush1])
+	require.NoError(n.gdb.Commit(ordered[flush1-1].Hash().Bytes(), true))
+	require.NoError(n.dbs.Close())
+	staleDir := filepath.Join(dir, stale+"-ldb")
+	require.NoError(copyDir(staleDir, filepath.Join(backup, stale+"-ldb")))
+
+	n = start()
+	process(n, ordered[flush1:flush2])
+	require.NoError(n.gdb.Commit(ordered[flush2-1].Hash().Bytes(), true))
+	// node is killed before the next flush
+	process(n, ordered[flush2:])
+	require.NoError(n.dbs.Close())
+	// epoch seal is flushed by processor
+	dbs := flushable.NewSyncedPool(dbProducer(dir, dbCfg))
+	id, err := dbs.FlushID()
+	require.NoError(err)
+	require.NoError(dbs.Close())
+	last := flush2
+	for i := flush2; i <= len(ordered); i++ {
+		if bytes.Equal(id, ordered[i-1].Hash().Bytes()) {
+			last = i
+		}
+	}
+
+	// the stale DB isn't flushed with the others
+	require.NoError(os.RemoveAll(staleDir))
+	require.NoError(copyDir(filepath.Join(backup, stale+"-ldb"), staleDir))
+	dbs, err = flushable.OpenSyncedPool(dbProducer(dir, dbCfg))
+	require.True(errors.Is(err, flushable.ErrNotSynced), err)
+	require.NoError(dbs.Close())
+
+	n = start()
+	// the events after the last flush of gossip-main are lost
+	if stale == "gossip-main" {
+		assertar.Equal(checkpoints[flush1], *n.engine.Checkpoint)
+	} else {
+		assertar.Equal(checkpoints[last], *n.engine.Checkpoint)
+	}
+
+	// node continues from the recovered state
+	process(n, ordered)
+	assertar.Equal(checkpoints[len(ordered)], *n.engine.Checkpoint)
+	for i, atropos := range expected.blocks {
+		block := n.gdb.GetBlock(i)
+		if assertar.NotNil(block, i) {
+			assertar.Equal(atropos, block.Atropos, i)
+		}
+	}
+	require.NoError(n.dbs.Close())
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, in)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
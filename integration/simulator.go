@@ -0,0 +1,293 @@
+package integration
+
+import (
+	"container/heap"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+)
+
+// SimConfig is a configuration of the network simulator.
+type SimConfig struct {
+	// Seed of all the randomness, the same config always gives the same simulation
+	Seed int64
+	// Stakes of the validators, one per validator
+	Stakes []pos.Stake
+	// Byzantine behaviours of the validators, by validator index
+	Byzantine map[int]SimByzantine
+
+	Dag     lachesis.DagConfig
+	Emitter gossip.EmitterConfig
+	// EmitTick is a period of emitter polling
+	EmitTick time.Duration
+
+	// Latency of a message delivery
+	Latency LatencyDistribution
+	// Loss is a probability of a message loss, a lost message is resent after RetryDelay
+	Loss       float64
+	RetryDelay time.Duration
+	// Partitions of the network
+	Partitions []SimPartition
+}
+
+// SimByzantine is a faulty behaviour of a validator.
+type SimByzantine struct {
+	// ForkEvery makes validator to create a conflicting copy of every n-th own event.
+	// The event and its copy are sent to different halves of the peers.
+	ForkEvery int
+	// Withhold delays sending of own events
+	Withhold time.Duration
+}
+
+// DefaultSimConfig returns config of the network of honest validators with equal stakes.
+func DefaultSimConfig(validators int) SimConfig {
+	stakes := make([]pos.Stake, validators)
+	for i := range stakes {
+		stakes[i] = 1
+	}
+
+	emitter := gossip.FakeEmitterConfig()
+	emitter.EmitIntervals.Max = time.Second
+	emitter.EmitIntervals.SelfForkProtection = 0
+
+	return SimConfig{
+		Seed:       1,
+		Stakes:     stakes,
+		Dag:        lachesis.FakeNetDagConfig(),
+		Emitter:    emitter,
+		EmitTick:   emitter.EmitIntervals.Min,
+		Latency:    UniformLatency{Min: 10 * time.Millisecond, Max: 100 * time.Millisecond},
+		RetryDelay: 500 * time.Millisecond,
+	}
+}
+
+// SimBlock is a block decided by a simulated node.
+type SimBlock struct {
+	Index    idx.Block
+	Atropos  hash.Event
+	Events   hash.Events
+	Cheaters inter.Cheaters
+	// DecidedAt is a virtual time of the decision
+	DecidedAt time.Time
+}
+
+// Simulator runs full poset and emitter instances of the validators
+// against a virtual clock and a virtual network, in a single goroutine.
+// Simulation is deterministic: it depends only on the config.
+type Simulator struct {
+	cfg SimConfig
+	net lachesis.Config
+
+	rand  *rand.Rand
+	start time.Time
+	now   time.Time
+	queue simQueue
+	seq   uint64
+
+	nodes []*simNode
+}
+
+// NewSimulator creates the validators nodes with a common genesis.
+func NewSimulator(cfg SimConfig) (*Simulator, error) {
+	if len(cfg.Stakes) == 0 {
+		return nil, errors.New("no validators")
+	}
+	if cfg.Loss < 0 || cfg.Loss >= 1 {
+		return nil, fmt.Errorf("loss probability %f isn't in [0, 1)", cfg.Loss)
+	}
+	if cfg.Latency == nil {
+		cfg.Latency = FixedLatency(0)
+	}
+	if cfg.EmitTick <= 0 {
+		return nil, errors.New("emit tick should be positive")
+	}
+
+	s := &Simulator{
+		cfg:  cfg,
+		rand: rand.New(rand.NewSource(cfg.Seed)),
+	}
+	s.net = lachesis.FakeNetConfig(s.makeValidators())
+	s.net.Dag = cfg.Dag
+	s.start = s.net.Genesis.Time.Time()
+	s.now = s.start
+
+	for i := range cfg.Stakes {
+		n, err := newSimNode(s, i)
+		if err != nil {
+			return nil, err
+		}
+		s.nodes = append(s.nodes, n)
+	}
+	// start emitters not simultaneously
+	for _, n := range s.nodes {
+		s.schedule(time.Duration(s.rand.Int63n(int64(cfg.EmitTick))), n.tick)
+	}
+
+	return s, nil
+}
+
+// makeValidators generates validators accounts from the seed.
+func (s *Simulator) makeValidators() genesis.VAccounts {
+	accs := make(genesis.Accounts, len(s.cfg.Stakes))
+	validators := make(pos.GValidators, 0, len(s.cfg.Stakes))
+
+	for i, stake := range s.cfg.Stakes {
+		var key *ecdsa.PrivateKey
+		for key == nil {
+			seed := make([]byte, 32)
+			s.rand.Read(seed)
+			key, _ = crypto.ToECDSA(seed)
+		}
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		accs[addr] = genesis.Account{
+			Balance:    big.NewInt(0),
+			PrivateKey: key,
+		}
+		validators = append(validators, pos.GenesisValidator{
+			ID:      idx.StakerID(i + 1),
+			Address: addr,
+			Stake:   pos.StakeToBalance(stake),
+		})
+	}
+
+	return genesis.VAccounts{Accounts: accs, Validators: validators, SfcContractAdmin: validators[0].Address}
+}
+
+// Run processes the scheduled actions for the duration of virtual time.
+func (s *Simulator) Run(duration time.Duration) {
+	end := s.now.Add(duration)
+	for len(s.queue) != 0 && !s.queue[0].at.After(end) {
+		action := heap.Pop(&s.queue).(*simAction)
+		s.now = action.at
+		action.do()
+	}
+	s.now = end
+}
+
+// Now returns current virtual time.
+func (s *Simulator) Now() time.Time {
+	return s.now
+}
+
+// Blocks returns the blocks decided by the validator.
+func (s *Simulator) Blocks(validator int) []SimBlock {
+	return s.nodes[validator].blocks
+}
+
+// CheckSafety returns an error if any two validators have decided different blocks with the same index.
+func (s *Simulator) CheckSafety() error {
+	for i, a := range s.nodes {
+		for _, b := range s.nodes[i+1:] {
+			for n := 0; n < len(a.blocks) && n < len(b.blocks); n++ {
+				if err := compareSimBlocks(&a.blocks[n], &b.blocks[n]); err != nil {
+					return fmt.Errorf("validators %d and %d: %v", a.index, b.index, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func compareSimBlocks(a, b *SimBlock) error {
+	if a.Index != b.Index {
+		return fmt.Errorf("block index %d != %d", a.Index, b.Index)
+	}
+	if a.Atropos != b.Atropos {
+		return fmt.Errorf("block %d atropos %s != %s", a.Index, a.Atropos.String(), b.Atropos.String())
+	}
+	if len(a.Events) != len(b.Events) {
+		return fmt.Errorf("block %d events count %d != %d", a.Index, len(a.Events), len(b.Events))
+	}
+	for i := range a.Events {
+		if a.Events[i] != b.Events[i] {
+			return fmt.Errorf("block %d events[%d] %s != %s", a.Index, i, a.Events[i].String(), b.Events[i].String())
+		}
+	}
+	if len(a.Cheaters) != len(b.Cheaters) {
+		return fmt.Errorf("block %d cheaters %v != %v", a.Index, a.Cheaters, b.Cheaters)
+	}
+	for i := range a.Cheaters {
+		if a.Cheaters[i] != b.Cheaters[i] {
+			return fmt.Errorf("block %d cheaters %v != %v", a.Index, a.Cheaters, b.Cheaters)
+		}
+	}
+	return nil
+}
+
+// CheckLiveness returns an error if any validator hasn't decided a block during a period longer than bound.
+func (s *Simulator) CheckLiveness(bound time.Duration) error {
+	for _, n := range s.nodes {
+		prev := s.start
+		for _, b := range n.blocks {
+			if b.DecidedAt.Sub(prev) > bound {
+				return fmt.Errorf("validator %d: block %d is decided in %s after previous one", n.index, b.Index, b.DecidedAt.Sub(prev))
+			}
+			prev = b.DecidedAt
+		}
+		if s.now.Sub(prev) > bound {
+			return fmt.Errorf("validator %d: no blocks for %s", n.index, s.now.Sub(prev))
+		}
+	}
+	return nil
+}
+
+// schedule calls action after delay of virtual time.
+func (s *Simulator) schedule(delay time.Duration, do func()) {
+	s.scheduleAt(s.now.Add(delay), do)
+}
+
+func (s *Simulator) scheduleAt(at time.Time, do func()) {
+	s.seq++
+	heap.Push(&s.queue, &simAction{
+		at:  at,
+		seq: s.seq,
+		do:  do,
+	})
+}
+
+// simAction is a scheduled action, actions with the same time are ordered by scheduling sequence.
+type simAction struct {
+	at  time.Time
+	seq uint64
+	do  func()
+}
+
+// simQueue implements heap.Interface.
+type simQueue []*simAction
+
+func (q simQueue) Len() int { return len(q) }
+
+func (q simQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q simQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *simQueue) Push(x interface{}) {
+	*q = append(*q, x.(*simAction))
+}
+
+func (q *simQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return x
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+)
+
+// LatencyDistribution is a distribution of a message delivery time.
+type LatencyDistribution interface {
+	Delay(r *rand.Rand) time.Duration
+}
+
+// FixedLatency is the same delivery time of all the messages.
+type FixedLatency time.Duration
+
+// Delay implements LatencyDistribution.
+func (l FixedLatency) Delay(r *rand.Rand) time.Duration {
+	return time.Duration(l)
+}
+
+// UniformLatency is uniformly distributed in [Min, Max).
+type UniformLatency struct {
+	Min, Max time.Duration
+}
+
+// Delay implements LatencyDistribution.
+func (l UniformLatency) Delay(r *rand.Rand) time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(r.Int63n(int64(l.Max-l.Min)))
+}
+
+// NormalLatency is normally distributed, negative values are truncated to zero.
+type NormalLatency struct {
+	Mean, StdDev time.Duration
+}
+
+// Delay implements LatencyDistribution.
+func (l NormalLatency) Delay(r *rand.Rand) time.Duration {
+	d := l.Mean + time.Duration(r.NormFloat64()*float64(l.StdDev))
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// SimPartition splits the network into isolated groups of validators
+// from Start to End of simulation time. The validators which aren't listed make one more group.
+// Messages sent between the groups are delivered after the partition is healed.
+type SimPartition struct {
+	Start, End time.Duration
+	Groups     [][]int
+}
+
+// group returns group number of validator.
+func (p *SimPartition) group(validator int) int {
+	for i, group := range p.Groups {
+		for _, v := range group {
+			if v == validator {
+				return i
+			}
+		}
+	}
+	return len(p.Groups)
+}
+
+// isolates returns true if validators can't communicate during the partition.
+func (p *SimPartition) isolates(a, b int) bool {
+	return p.group(a) != p.group(b)
+}
+
+// send delivers event from one validator to another after the network delay.
+func (s *Simulator) send(from, to *simNode, e *inter.Event, delay time.Duration) {
+	sent := s.now.Add(delay)
+
+	// lost messages are resent
+	for s.cfg.Loss > 0 && s.rand.Float64() < s.cfg.Loss {
+		sent = sent.Add(s.cfg.RetryDelay)
+	}
+	// cross-partition messages are resent after healing
+	for _, p := range s.cfg.Partitions {
+		start, end := s.start.Add(p.Start), s.start.Add(p.End)
+		if !sent.Before(start) && sent.Before(end) && p.isolates(from.index, to.index) {
+			sent = end
+		}
+	}
+
+	s.scheduleAt(sent.Add(s.cfg.Latency.Delay(s.rand)), func() {
+		to.receive(from, e)
+	})
+}
+
+// broadcast sends event to all the validators except the excluded ones.
+func (s *Simulator) broadcast(from *simNode, e *inter.Event, delay time.Duration, except ...*simNode) {
+	for _, to := range s.nodes {
+		if to == from || contains(except, to) {
+			continue
+		}
+		s.send(from, to, e, delay)
+	}
+}
+
+func contains(nodes []*simNode, n *simNode) bool {
+	for _, it := range nodes {
+		if it == n {
+			return true
+		}
+	}
+	return false
+}
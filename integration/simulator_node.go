@@ -0,0 +1,279 @@
+package integration
+
+import (
+	"crypto/ecdsa"
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	notify "github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/Fantom-foundation/go-lachesis/app"
+	"github.com/Fantom-foundation/go-lachesis/eventcheck/basiccheck"
+	"github.com/Fantom-foundation/go-lachesis/evmcore"
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/gossip/occuredtxs"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+	"github.com/Fantom-foundation/go-lachesis/poset"
+)
+
+// simNode is a validator of the simulated network.
+// It processes events and blocks by gossip.Processor, the same way as gossip.Service does.
+type simNode struct {
+	sim       *Simulator
+	index     int
+	byzantine SimByzantine
+	key       *ecdsa.PrivateKey
+
+	dbs *flushable.SyncedPool
+	adb *app.Store
+	gdb *gossip.Store
+
+	proc    *gossip.Processor
+	engine  gossip.Consensus
+	emitter *gossip.Emitter
+
+	// received events which can't be connected yet
+	pending    []simPendingEvent
+	pendingIDs map[hash.Event]struct{}
+
+	emitted int
+	blocks  []SimBlock
+}
+
+type simPendingEvent struct {
+	event *inter.Event
+	from  *simNode
+}
+
+func newSimNode(s *Simulator, index int) (*simNode, error) {
+	validator := s.net.Genesis.Alloc.Validators[index]
+
+	n := &simNode{
+		sim:        s,
+		index:      index,
+		byzantine:  s.cfg.Byzantine[index],
+		key:        s.net.Genesis.Alloc.Accounts[validator.Address].PrivateKey,
+		dbs:        flushable.NewSyncedPool(memorydb.NewProducer("")),
+		pendingIDs: make(map[hash.Event]struct{}),
+	}
+
+	gossipCfg := gossip.DefaultConfig(s.net)
+	adb, gdb, cdb := makeStoresWith(n.dbs, DbConfig{}, &gossipCfg)
+	_, err := applyGenesis(n.dbs, adb, gdb, cdb, &s.net)
+	if err != nil {
+		return nil, err
+	}
+	n.adb, n.gdb = adb, gdb
+
+	n.proc = gossip.NewProcessor(&gossipCfg, gdb, poset.New(s.net.Dag, cdb, gdb), adb, gossip.ProcessorHooks{
+		OnNewEvent: func(e *inter.Event) {
+			n.emitter.OnNewEvent(e)
+		},
+		OnNewBlock: n.onNewBlock,
+		OnNewEpoch: func(newValidators *pos.Validators, newEpoch idx.Epoch) {
+			n.emitter.OnNewEpoch(newValidators, newEpoch)
+		},
+	})
+	n.engine = n.proc.Engine()
+
+	r := rand.New(rand.NewSource(s.rand.Int63()))
+	emitterCfg := s.cfg.Emitter // copy data
+	emitterCfg.EmitIntervals = *emitterCfg.EmitIntervals.RandomizeEmitTime(r)
+
+	n.emitter = gossip.NewEmitter(&s.net, &emitterCfg,
+		gossip.EmitterWorld{
+			Store:    n.gdb,
+			App:      n.adb,
+			Engine:   n.engine,
+			EngineMu: n.proc.EngineMu(),
+			Txpool:   &simTxPool{},
+			Am: accounts.NewManager(
+				&accounts.Config{InsecureUnlockAllowed: true},
+				genesis.NewAccountsBackend(s.net.Genesis.Alloc.Accounts, validator.Address),
+			),
+			OccurredTxs: occuredtxs.New(1024, types.NewEIP155Signer(s.net.EvmChainConfig().ChainID)),
+			Checkers:    n.proc.Checkers(),
+			OnEmitted:   n.onEmitted,
+			IsSynced: func() bool {
+				return true
+			},
+			PeersNum: func() int {
+				return len(s.cfg.Stakes) - 1
+			},
+			Clock: s.Now,
+			Rand:  r,
+		},
+	)
+	n.emitter.SetValidator(validator.Address)
+
+	return n, nil
+}
+
+// tick polls emitter. Events are emitted only by ticks,
+// so EmitTick not less than EmitIntervals.Min keeps the minimal interval between events.
+func (n *simNode) tick() {
+	n.emitter.EmitEvent()
+	n.sim.schedule(n.sim.cfg.EmitTick, n.tick)
+}
+
+// onEmitted connects own event and sends it to the peers.
+func (n *simNode) onEmitted(e *inter.Event) {
+	// n.engineMu is locked here
+
+	if err := n.connect(e, false); err != nil {
+		log.Error("Emitted event connection failed", "validator", n.index, "event", e.Hash().String(), "err", err)
+		return
+	}
+	n.emitted++
+	// own event may have sealed the epoch, so the buffered events of new epoch may be connected
+	n.sim.schedule(0, n.connectPending)
+
+	if n.byzantine.ForkEvery <= 0 || n.emitted%n.byzantine.ForkEvery != 0 {
+		n.sim.broadcast(n, e, n.byzantine.Withhold)
+		return
+	}
+
+	// send the event to one half of the peers, and the conflicting one to another
+	fork := n.forkOf(e)
+	var peers []*simNode
+	for _, peer := range n.sim.nodes {
+		if peer != n {
+			peers = append(peers, peer)
+		}
+	}
+	half := len(peers) / 2
+	for _, peer := range peers[:half] {
+		n.sim.send(n, peer, e, n.byzantine.Withhold)
+	}
+	for _, peer := range peers[half:] {
+		n.sim.send(n, peer, fork, n.byzantine.Withhold)
+	}
+}
+
+// forkOf returns an event with the same creator and seq, but different ID.
+func (n *simNode) forkOf(e *inter.Event) *inter.Event {
+	fork := inter.NewEvent()
+	fork.EventHeaderData = e.EventHeaderData
+	fork.Extra = append(append([]byte{}, e.Extra...), []byte("fork")...)
+	fork.Transactions = e.Transactions
+	// extra bytes consume gas power
+	fork.GasPowerUsed = basiccheck.CalcGasPowerUsed(fork, &n.sim.net.Dag)
+	fork.GasPowerLeft.Add(e.GasPowerUsed)
+	fork.GasPowerLeft.Sub(fork.GasPowerUsed)
+	if err := fork.SignBy(n.key); err != nil {
+		log.Crit("Failed to sign fork", "err", err)
+	}
+	fork.RecacheHash()
+	fork.RecacheSize()
+	return fork
+}
+
+// receive buffers the event until its parents are connected.
+func (n *simNode) receive(from *simNode, e *inter.Event) {
+	if _, ok := n.pendingIDs[e.Hash()]; ok || n.gdb.HasEvent(e.Hash()) {
+		return
+	}
+	n.pending = append(n.pending, simPendingEvent{
+		event: e,
+		from:  from,
+	})
+	n.pendingIDs[e.Hash()] = struct{}{}
+
+	n.connectPending()
+}
+
+// connectPending connects and relays all the events which are ready to be connected.
+func (n *simNode) connectPending() {
+	for i := 0; i < len(n.pending); {
+		it := n.pending[i]
+		epoch := n.engine.GetEpoch()
+		if it.event.Epoch > epoch || (it.event.Epoch == epoch && !n.hasParents(it.event)) {
+			i++
+			continue
+		}
+
+		n.pending = append(n.pending[:i], n.pending[i+1:]...)
+		delete(n.pendingIDs, it.event.Hash())
+		if it.event.Epoch < epoch {
+			continue // not relevant anymore
+		}
+
+		n.proc.EngineMu().Lock()
+		err := n.connect(it.event, true)
+		n.proc.EngineMu().Unlock()
+		if err != nil {
+			log.Debug("Event is rejected", "validator", n.index, "event", it.event.Hash().String(), "err", err)
+			continue
+		}
+		n.sim.broadcast(n, it.event, 0, it.from)
+		// connected event may be a parent of the previous ones
+		i = 0
+	}
+}
+
+func (n *simNode) hasParents(e *inter.Event) bool {
+	for _, p := range e.Parents {
+		if !n.gdb.HasEvent(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// connect checks and processes event the same way as gossip.Service does.
+func (n *simNode) connect(e *inter.Event, check bool) error {
+	// n.proc.EngineMu() is locked here
+
+	if check {
+		parents := make([]*inter.EventHeaderData, len(e.Parents))
+		for i, p := range e.Parents {
+			parents[i] = n.gdb.GetEventHeader(e.Epoch, p)
+		}
+		if err := n.proc.Checkers().Validate(e, parents); err != nil {
+			return err
+		}
+	}
+
+	return n.engine.ProcessEvent(e)
+}
+
+// onNewBlock records the applied block.
+func (n *simNode) onNewBlock(block *inter.Block, cheaters inter.Cheaters) {
+	n.blocks = append(n.blocks, SimBlock{
+		Index:     block.Index,
+		Atropos:   block.Atropos,
+		Events:    block.Events.Copy(),
+		Cheaters:  append(inter.Cheaters{}, cheaters...),
+		DecidedAt: n.sim.Now(),
+	})
+}
+
+// simTxPool is an always empty transactions pool.
+type simTxPool struct {
+	feed notify.Feed
+}
+
+func (p *simTxPool) AddRemotes(txs []*types.Transaction) []error {
+	return make([]error, len(txs))
+}
+
+func (p *simTxPool) Get(hash common.Hash) *types.Transaction {
+	return nil
+}
+
+func (p *simTxPool) Pending() (map[common.Address]types.Transactions, error) {
+	return map[common.Address]types.Transactions{}, nil
+}
+
+func (p *simTxPool) SubscribeNewTxsNotify(ch chan<- evmcore.NewTxsNotify) notify.Subscription {
+	return p.feed.Subscribe(ch)
+}
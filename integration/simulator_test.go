@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+func TestSimulatorHonest(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	cfg := DefaultSimConfig(5)
+	cfg.Stakes = []pos.Stake{1, 2, 3, 4, 5}
+	cfg.Latency = NormalLatency{Mean: 50 * time.Millisecond, StdDev: 20 * time.Millisecond}
+	cfg.Loss = 0.1
+
+	sim, err := NewSimulator(cfg)
+	require.NoError(err)
+
+	sim.Run(30 * time.Second)
+
+	assertar.NoError(sim.CheckSafety())
+	assertar.NoError(sim.CheckLiveness(10 * time.Second))
+	for i := range cfg.Stakes {
+		assertar.NotEmpty(sim.Blocks(i))
+	}
+}
+
+func TestSimulatorDeterminism(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	run := func() []SimBlock {
+		sim, err := NewSimulator(DefaultSimConfig(4))
+		require.NoError(err)
+
+		sim.Run(15 * time.Second)
+		return sim.Blocks(0)
+	}
+
+	a, b := run(), run()
+	require.NotEmpty(a)
+	require.Equal(len(a), len(b))
+	for i := range a {
+		assertar.NoError(compareSimBlocks(&a[i], &b[i]))
+		assertar.Equal(a[i].DecidedAt, b[i].DecidedAt)
+	}
+}
+
+func TestSimulatorPartition(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	cfg := DefaultSimConfig(4)
+	cfg.Partitions = []SimPartition{{
+		Start:  5 * time.Second,
+		End:    20 * time.Second,
+		Groups: [][]int{{0, 1}},
+	}}
+
+	sim, err := NewSimulator(cfg)
+	require.NoError(err)
+
+	// no quorum during partition
+	sim.Run(6 * time.Second)
+	before := len(sim.Blocks(0))
+	sim.Run(14 * time.Second)
+	assertar.True(len(sim.Blocks(0)) <= before+1, "blocks are decided without quorum")
+
+	// healed
+	sim.Run(20 * time.Second)
+	assertar.True(len(sim.Blocks(0)) > before+1, "no blocks after healing")
+	assertar.NoError(sim.CheckSafety())
+	assertar.NoError(sim.CheckLiveness(25 * time.Second))
+}
+
+func TestSimulatorByzantine(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	cfg := DefaultSimConfig(5)
+	cfg.Byzantine = map[int]SimByzantine{
+		0: {ForkEvery: 5},
+		1: {Withhold: 3 * time.Second},
+	}
+
+	sim, err := NewSimulator(cfg)
+	require.NoError(err)
+
+	sim.Run(40 * time.Second)
+
+	assertar.NoError(sim.CheckSafety())
+	assertar.NoError(sim.CheckLiveness(15 * time.Second))
+
+	// the forking validator is detected
+	cheater := sim.net.Genesis.Alloc.Validators[0].ID
+	detected := false
+	for _, b := range sim.Blocks(2) {
+		for _, c := range b.Cheaters {
+			detected = detected || c == cheater
+		}
+	}
+	assertar.True(detected, "fork isn't detected")
+
+	// the epoch is sealed by the cheater, and the cheater is excluded from validators of the next one
+	assertar.True(sim.nodes[2].engine.GetEpoch() > 1, "epoch isn't sealed")
+	assertar.False(sim.nodes[2].engine.GetValidators().Exists(cheater), "cheater isn't excluded")
+}
@@ -0,0 +1,232 @@
+package integration
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-lachesis/common/bigendian"
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+// snapshotVersion is a version of snapshot archive format.
+const snapshotVersion = 1
+
+var (
+	// ErrSnapshotCorrupted is returned if snapshot archive is truncated or malformed.
+	ErrSnapshotCorrupted = errors.New("snapshot is corrupted")
+	// ErrDatadirNotEmpty is returned if snapshot is restored into a datadir with databases.
+	ErrDatadirNotEmpty = errors.New("datadir already contains databases")
+)
+
+// SnapshotManifest describes a point-in-time snapshot of node databases.
+type SnapshotManifest struct {
+	Version     uint
+	GenesisHash common.Hash
+	Epoch       idx.Epoch
+	LastBlock   idx.Block
+	LastAtropos hash.Event
+	FlushID     []byte
+	Dbs         []string
+}
+
+// snapshotRecord is a key-value pair of a DB.
+// The last record has empty Db and the total number of pairs in Value.
+type snapshotRecord struct {
+	Db    string
+	Key   []byte
+	Value []byte
+}
+
+// CreateSnapshot writes consistent snapshot of all the node databases into w.
+// Node must be stopped.
+func CreateSnapshot(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config, w io.Writer) (*SnapshotManifest, error) {
+	// pool is synced (or recovered) at this point, so all the DBs are at the same flush ID
+	dbs, _, _, cdb := makeStores(dataDir, dbCfg, gossipCfg)
+	defer dbs.Close()
+
+	if cdb.GetGenesis() == nil {
+		return nil, errors.New("no genesis found, datadir isn't initialized")
+	}
+	flushID, err := dbs.FlushID()
+	if err != nil {
+		return nil, err
+	}
+	cp := cdb.GetCheckpoint()
+	manifest := &SnapshotManifest{
+		Version:     snapshotVersion,
+		GenesisHash: cdb.GetGenesisHash(),
+		Epoch:       cdb.GetEpoch().EpochN,
+		LastBlock:   cp.LastBlockN,
+		LastAtropos: cp.LastAtropos,
+		FlushID:     flushID,
+		Dbs:         dbs.Names(),
+	}
+
+	zw := gzip.NewWriter(w)
+	err = rlp.Encode(zw, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var total uint64
+	for _, name := range manifest.Dbs {
+		n, err := writeSnapshotDb(zw, name, dbs.GetDb(name))
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Snapshot DB is written", "name", name, "pairs", n)
+		total += n
+	}
+
+	err = rlp.Encode(zw, &snapshotRecord{
+		Value: bigendian.Int64ToBytes(total),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, zw.Close()
+}
+
+func writeSnapshotDb(w io.Writer, name string, db kvdb.KeyValueStore) (n uint64, err error) {
+	it := db.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		err = rlp.Encode(w, &snapshotRecord{
+			Db:    name,
+			Key:   it.Key(),
+			Value: it.Value(),
+		})
+		if err != nil {
+			return
+		}
+		n++
+	}
+	err = it.Error()
+	return
+}
+
+// RestoreSnapshot fills empty datadir with databases from snapshot r. Datadir is created if it doesn't exist.
+// If an error is returned, datadir may be partially filled and should be cleaned up.
+func RestoreSnapshot(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config, r io.Reader) (*SnapshotManifest, error) {
+	if dataDir != "inmemory" && dataDir != "" {
+		err := os.MkdirAll(dataDir, 0700)
+		if err != nil {
+			return nil, err
+		}
+	}
+	producer := dbProducer(dataDir, dbCfg)
+	if len(producer.Names()) != 0 {
+		return nil, ErrDatadirNotEmpty
+	}
+
+	zr, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	stream := rlp.NewStream(zr, 0)
+
+	manifest := &SnapshotManifest{}
+	err = stream.Decode(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+	}
+	if manifest.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", manifest.Version)
+	}
+	// false until DB is restored
+	restored := make(map[string]bool, len(manifest.Dbs))
+	for _, name := range manifest.Dbs {
+		restored[name] = false
+	}
+
+	var (
+		total   uint64
+		current string
+		db      kvdb.KeyValueStore
+		batch   ethdb.Batch
+	)
+	closeDb := func() error {
+		if db == nil {
+			return nil
+		}
+		err := batch.Write()
+		if err != nil {
+			return err
+		}
+		err = db.Close()
+		db = nil
+		return err
+	}
+	defer closeDb()
+
+	for {
+		rec := &snapshotRecord{}
+		err = stream.Decode(rec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupted, err)
+		}
+		if rec.Db == "" {
+			// end of snapshot
+			if len(rec.Value) != 8 || bigendian.BytesToInt64(rec.Value) != total {
+				return nil, fmt.Errorf("%w: pairs count mismatch", ErrSnapshotCorrupted)
+			}
+			break
+		}
+		if rec.Db != current {
+			if done, ok := restored[rec.Db]; !ok || done {
+				return nil, fmt.Errorf("%w: unexpected DB %s", ErrSnapshotCorrupted, rec.Db)
+			}
+			restored[rec.Db] = true
+			if err = closeDb(); err != nil {
+				return nil, err
+			}
+			current = rec.Db
+			db = producer.OpenDb(current)
+			batch = db.NewBatch()
+		}
+		err = batch.Put(rec.Key, rec.Value)
+		if err != nil {
+			return nil, err
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err = batch.Write(); err != nil {
+				return nil, err
+			}
+			batch.Reset()
+		}
+		total++
+	}
+	if err = closeDb(); err != nil {
+		return nil, err
+	}
+	for name, done := range restored {
+		if !done {
+			return nil, fmt.Errorf("%w: DB %s is missing", ErrSnapshotCorrupted, name)
+		}
+	}
+
+	// check the result, pool is checked to be synced on open
+	dbs, _, _, cdb := makeStores(dataDir, dbCfg, gossipCfg)
+	defer dbs.Close()
+
+	if cdb.GetGenesis() == nil || cdb.GetGenesisHash() != manifest.GenesisHash {
+		return nil, fmt.Errorf("%w: genesis mismatch", ErrSnapshotCorrupted)
+	}
+
+	return manifest, nil
+}
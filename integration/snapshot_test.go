@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+)
+
+func TestSnapshot(t *testing.T) {
+	for _, engine := range []string{LevelDbEngine, BboltEngine} {
+		t.Run(engine, func(t *testing.T) {
+			testSnapshot(t, DbConfig{Engine: engine})
+		})
+	}
+}
+
+func testSnapshot(t *testing.T, dbCfg DbConfig) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	network := lachesis.FakeNetConfig(genesis.FakeValidators(3, big.NewInt(0), pos.StakeToBalance(10000)))
+	gossipCfg := gossip.DefaultConfig(network)
+
+	src, err := ioutil.TempDir("", "snapshot-src")
+	require.NoError(err)
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "snapshot-dst")
+	require.NoError(err)
+	defer os.RemoveAll(dst)
+	broken, err := ioutil.TempDir("", "snapshot-broken")
+	require.NoError(err)
+	defer os.RemoveAll(broken)
+
+	// genesis state
+	dbs, adb, gdb, cdb := makeStores(src, dbCfg, &gossipCfg)
+	_, err = applyGenesis(dbs, adb, gdb, cdb, &gossipCfg.Net)
+	require.NoError(err)
+	genesisHash := cdb.GetGenesisHash()
+	require.NoError(dbs.Close())
+
+	buf := &bytes.Buffer{}
+	created, err := CreateSnapshot(src, dbCfg, &gossipCfg, buf)
+	require.NoError(err)
+	archive := buf.Bytes()
+
+	// truncated
+	_, err = RestoreSnapshot(broken, dbCfg, &gossipCfg, bytes.NewReader(archive[:len(archive)-16]))
+	assertar.True(errors.Is(err, ErrSnapshotCorrupted), err)
+
+	restored, err := RestoreSnapshot(dst, dbCfg, &gossipCfg, bytes.NewReader(archive))
+	require.NoError(err)
+	assertar.Equal(created, restored)
+	assertar.Equal(genesisHash, restored.GenesisHash)
+
+	// not existing datadir
+	restored, err = RestoreSnapshot(filepath.Join(broken, "new", "datadir"), dbCfg, &gossipCfg, bytes.NewReader(archive))
+	require.NoError(err)
+	assertar.Equal(created, restored)
+
+	// not empty
+	_, err = RestoreSnapshot(dst, dbCfg, &gossipCfg, bytes.NewReader(archive))
+	assertar.Equal(ErrDatadirNotEmpty, err)
+
+	// the same content
+	buf = &bytes.Buffer{}
+	_, err = CreateSnapshot(dst, dbCfg, &gossipCfg, buf)
+	require.NoError(err)
+	assertar.Equal(archive, buf.Bytes())
+}
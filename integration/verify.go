@@ -0,0 +1,201 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/poset"
+)
+
+// DivergenceError describes the first mismatch between a stored block and the replayed one.
+type DivergenceError struct {
+	Block    idx.Block
+	Field    string
+	Stored   string
+	Replayed string
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("block %d diverged: stored %s is %s, replayed is %s", e.Block, e.Field, e.Stored, e.Replayed)
+}
+
+// VerifyChain replays the stored events by gossip.Processor in fresh in-memory stores, started from the network genesis,
+// and compares the produced blocks of epochs [from, to] with the stored ones. It returns the last verified block.
+// The epochs before from are replayed as well, because consensus and state of an epoch depend on the previous ones.
+// Blocks are produced the same way as gossip.Service does: transactions are executed
+// and epochs are sealed by the replayed state, so block Root is compared as well.
+func VerifyChain(dataDir string, dbCfg DbConfig, gossipCfg *gossip.Config, from, to idx.Epoch) (verified idx.Block, err error) {
+	dbs, _, gdb, cdb, err := OpenReadOnlyStores(dataDir, dbCfg, gossipCfg)
+	if err != nil {
+		return 0, err
+	}
+	defer dbs.Close()
+
+	genesis := cdb.GetGenesis()
+	if genesis == nil {
+		return 0, errors.New("no genesis found, datadir isn't initialized")
+	}
+	if current := cdb.GetEpoch().EpochN; to > current {
+		to = current
+	}
+
+	r, err := newChainReplay(gossipCfg, gdb, from)
+	if err != nil {
+		return 0, err
+	}
+	defer r.dbs.Close()
+
+	for epoch := genesis.EpochN; epoch <= to && r.err == nil; epoch++ {
+		if replayed := r.engine.GetEpoch(); replayed != epoch {
+			return r.verified, fmt.Errorf("epoch %d isn't sealed by replay, replayed epoch is %d", epoch-1, replayed)
+		}
+		r.replayEpoch(epoch)
+		log.Info("Epoch is replayed", "epoch", epoch, "block", r.verified)
+	}
+	if r.err != nil {
+		return r.verified, r.err
+	}
+
+	// all the stored blocks of the epochs should be produced
+	last, _ := r.engine.LastBlock()
+	if stored := gdb.GetBlock(last + 1); stored != nil && stored.Atropos.Epoch() <= to {
+		return r.verified, &DivergenceError{
+			Block:    last + 1,
+			Field:    "block",
+			Stored:   stored.Atropos.String(),
+			Replayed: "not produced",
+		}
+	}
+
+	return r.verified, nil
+}
+
+// chainReplay feeds stored events into a fresh gossip.Processor
+// and compares the produced blocks with the stored ones.
+type chainReplay struct {
+	gdb *gossip.Store
+
+	dbs    *flushable.SyncedPool
+	engine gossip.Consensus
+
+	from     idx.Epoch
+	verified idx.Block
+	err      error
+}
+
+func newChainReplay(gossipCfg *gossip.Config, gdb *gossip.Store, from idx.Epoch) (*chainReplay, error) {
+	cfg := *gossipCfg // copy data
+	cfg.Snapshot.Serve = false
+
+	r := &chainReplay{
+		gdb:  gdb,
+		dbs:  flushable.NewSyncedPool(memorydb.NewProducer("")),
+		from: from,
+	}
+
+	adb, replayed, cdb := makeStoresWith(r.dbs, DbConfig{}, &cfg)
+	_, err := applyGenesis(r.dbs, adb, replayed, cdb, &cfg.Net)
+	if err != nil {
+		_ = r.dbs.Close()
+		return nil, err
+	}
+	if stored := gdb.GetBlock(0); stored == nil || stored.Atropos != replayed.GetBlock(0).Atropos {
+		_ = r.dbs.Close()
+		return nil, errors.New("stored genesis doesn't match the network config")
+	}
+
+	proc := gossip.NewProcessor(&cfg, replayed, poset.New(cfg.Net.Dag, cdb, replayed), adb, gossip.ProcessorHooks{
+		OnNewBlock: r.onNewBlock,
+	})
+	r.engine = proc.Engine()
+
+	return r, nil
+}
+
+// replayEpoch processes the stored events of epoch in Lamport order.
+func (r *chainReplay) replayEpoch(epoch idx.Epoch) {
+	r.gdb.ForEachEvent(epoch, func(e *inter.Event) bool {
+		if e.Epoch != r.engine.GetEpoch() {
+			// epoch is sealed by replay
+			return false
+		}
+
+		err := r.engine.ProcessEvent(e)
+		if err != nil {
+			r.fail(fmt.Errorf("event %s is rejected by replay: %v", e.Hash().String(), err))
+			return false
+		}
+		return r.err == nil
+	})
+}
+
+// onNewBlock compares the replayed block with the stored one.
+func (r *chainReplay) onNewBlock(block *inter.Block, cheaters inter.Cheaters) {
+	stored := r.gdb.GetBlock(block.Index)
+	if stored == nil {
+		r.fail(&DivergenceError{
+			Block:    block.Index,
+			Field:    "block",
+			Stored:   "not found",
+			Replayed: block.Atropos.String(),
+		})
+		return
+	}
+
+	if block.Atropos.Epoch() >= r.from {
+		r.compareBlock(stored, block)
+	}
+}
+
+func (r *chainReplay) compareBlock(stored, replayed *inter.Block) {
+	diverged := func(field string, a, b interface{}) *DivergenceError {
+		return &DivergenceError{
+			Block:    replayed.Index,
+			Field:    field,
+			Stored:   fmt.Sprint(a),
+			Replayed: fmt.Sprint(b),
+		}
+	}
+
+	switch {
+	case stored.Atropos != replayed.Atropos:
+		r.fail(diverged("atropos", stored.Atropos, replayed.Atropos))
+	case stored.Time != replayed.Time:
+		r.fail(diverged("time", stored.Time, replayed.Time))
+	case stored.PrevHash != replayed.PrevHash:
+		r.fail(diverged("prevHash", stored.PrevHash, replayed.PrevHash))
+	case len(stored.Events) != len(replayed.Events):
+		r.fail(diverged("events count", len(stored.Events), len(replayed.Events)))
+	case stored.TxHash != replayed.TxHash:
+		r.fail(diverged("txHash", stored.TxHash.String(), replayed.TxHash.String()))
+	case fmt.Sprint(stored.SkippedTxs) != fmt.Sprint(replayed.SkippedTxs):
+		r.fail(diverged("skippedTxs", stored.SkippedTxs, replayed.SkippedTxs))
+	case stored.GasUsed != replayed.GasUsed:
+		r.fail(diverged("gasUsed", stored.GasUsed, replayed.GasUsed))
+	case stored.Root != replayed.Root:
+		r.fail(diverged("root", stored.Root.String(), replayed.Root.String()))
+	}
+	for i := 0; i < len(stored.Events) && r.err == nil; i++ {
+		if stored.Events[i] != replayed.Events[i] {
+			r.fail(diverged(fmt.Sprintf("events[%d]", i), stored.Events[i], replayed.Events[i]))
+		}
+	}
+
+	if r.err == nil {
+		r.verified = replayed.Index
+	}
+}
+
+// fail remembers the first divergence.
+func (r *chainReplay) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
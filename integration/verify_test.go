@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/gossip"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/genesis"
+	"github.com/Fantom-foundation/go-lachesis/lachesis/params"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/poset"
+	"github.com/Fantom-foundation/go-lachesis/utils"
+)
+
+func TestVerifyChain(t *testing.T) {
+	logger.SetTestMode(t)
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	network := lachesis.FakeNetConfig(genesis.FakeValidators(5, utils.ToFtm(1000000), pos.StakeToBalance(10000)))
+	network.Dag.MaxEpochBlocks = 5
+	// second epoch is sealed by gas, which is gathered from the executed blocks
+	network.Dag.EpochSealing = []lachesis.EpochSealingConfig{{
+		FromEpoch:   2,
+		MaxEpochGas: 2 * params.TxGas,
+	}}
+	gossipCfg := gossip.DefaultConfig(network)
+	dbCfg := DbConfig{}
+
+	dir, err := ioutil.TempDir("", "verify")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// write chain by gossip.Processor, the same way as gossip.Service does
+	dbs, adb, gdb, cdb := makeStores(dir, dbCfg, &gossipCfg)
+	_, err = applyGenesis(dbs, adb, gdb, cdb, &gossipCfg.Net)
+	require.NoError(err)
+	engine := gossip.NewProcessor(&gossipCfg, gdb, poset.New(gossipCfg.Net.Dag, cdb, gdb), adb, gossip.ProcessorHooks{}).Engine()
+
+	var nodes []idx.StakerID
+	keys := make(map[idx.StakerID]*ecdsa.PrivateKey)
+	for _, v := range network.Genesis.Alloc.Validators {
+		nodes = append(nodes, v.ID)
+		keys[v.ID] = network.Genesis.Alloc.Accounts[v.Address].PrivateKey
+	}
+	signer := types.NewEIP155Signer(network.EvmChainConfig().ChainID)
+	nonces := make(map[idx.StakerID]uint64)
+
+	for epoch := idx.Epoch(1); epoch <= 2; epoch++ {
+		require.Equal(epoch, engine.GetEpoch())
+		// txs of not confirmed events are lost when epoch is sealed
+		last, _ := engine.LastBlock()
+		statedb := adb.StateDB(gdb.GetBlock(last).Root)
+		for _, v := range network.Genesis.Alloc.Validators {
+			nonces[v.ID] = statedb.GetNonce(v.Address)
+		}
+		inter.ForEachRandEvent(nodes, 50, 3, rand.New(rand.NewSource(int64(epoch))), inter.ForEachEvent{
+			Process: func(e *inter.Event, name string) {
+				require.NoError(engine.ProcessEvent(e))
+			},
+			Build: func(e *inter.Event, name string) *inter.Event {
+				if engine.GetEpoch() != epoch {
+					// epoch is sealed
+					return nil
+				}
+				e.Epoch = epoch
+				e.ClaimedTime = network.Genesis.Time + inter.Timestamp(epoch-1)*inter.Timestamp(5*time.Minute) + inter.Timestamp(e.Lamport)*inter.Timestamp(time.Second)
+				if e.Seq%2 != 0 {
+					tx := types.NewTransaction(nonces[e.Creator], common.Address{1}, big.NewInt(1), params.TxGas, params.MinGasPrice, nil)
+					tx, err := types.SignTx(tx, signer, keys[e.Creator])
+					require.NoError(err)
+					nonces[e.Creator]++
+					e.Transactions = append(e.Transactions, tx)
+				}
+				e.TxHash = types.DeriveSha(e.Transactions)
+				return engine.Prepare(e)
+			},
+		})
+	}
+	require.Equal(idx.Epoch(3), engine.GetEpoch(), "epochs aren't sealed")
+	last, _ := engine.LastBlock()
+	gasUsed := uint64(0)
+	for n := idx.Block(1); n <= last; n++ {
+		gasUsed += gdb.GetBlock(n).GasUsed
+	}
+	require.NotZero(gasUsed, "no transactions are executed")
+	require.True(last < 10, "second epoch isn't sealed by gas")
+	require.NoError(adb.Commit(nil, true))
+	require.NoError(dbs.Close())
+
+	verified, err := VerifyChain(dir, dbCfg, &gossipCfg, 1, 2)
+	require.NoError(err)
+	assertar.Equal(last, verified)
+
+	// tampered block
+	tamper := func(n idx.Block, f func(block *inter.Block)) {
+		dbs, _, gdb, _ := makeStores(dir, dbCfg, &gossipCfg)
+		block := gdb.GetBlock(n)
+		f(block)
+		gdb.SetBlock(block)
+		require.NoError(gdb.Commit(nil, true))
+		require.NoError(dbs.Close())
+	}
+	checkDivergence := func(err error, n idx.Block, field string) {
+		divergence := &DivergenceError{}
+		if assertar.True(errors.As(err, &divergence), err) {
+			assertar.Equal(n, divergence.Block)
+			assertar.Equal(field, divergence.Field)
+		}
+	}
+
+	tamper(2, func(block *inter.Block) {
+		block.Events = block.Events[1:]
+	})
+	verified, err = VerifyChain(dir, dbCfg, &gossipCfg, 1, 2)
+	checkDivergence(err, 2, "events count")
+	assertar.Equal(idx.Block(1), verified)
+
+	// blocks of epochs before from aren't compared
+	verified, err = VerifyChain(dir, dbCfg, &gossipCfg, 2, 2)
+	assertar.NoError(err)
+	assertar.Equal(last, verified)
+
+	// tampered state of the next epoch
+	next := last
+	for dbs, _, gdb, _ := makeStores(dir, dbCfg, &gossipCfg); ; next-- {
+		if gdb.GetBlock(next-1).Atropos.Epoch() == 1 {
+			require.NoError(dbs.Close())
+			break
+		}
+	}
+	tamper(next, func(block *inter.Block) {
+		block.Root = common.Hash{1}
+	})
+	verified, err = VerifyChain(dir, dbCfg, &gossipCfg, 2, 2)
+	checkDivergence(err, next, "root")
+	assertar.Equal(idx.Block(0), verified)
+}
@@ -33,7 +33,10 @@ func FindBestParents(max int, options hash.Events, selfParent *hash.Event, strat
 	strategy.Init(selfParent)
 
 	for len(parents) < max && len(optionsSet) > 0 {
-		best := strategy.Find(optionsSet.Slice())
+		// sort options to make the choice independent of the set iteration order
+		options := optionsSet.Slice()
+		sort.Sort(hash.OrderedEvents(options))
+		best := strategy.Find(options)
 		parents = append(parents, best)
 		optionsSet.Erase(best)
 	}
@@ -215,6 +215,9 @@ func ASCIIschemeToDAG(
 }
 
 // DAGtoASCIIscheme builds ASCII-scheme of events for debug purpose.
+// Events may be a part of a DAG (e.g. the last frames of an epoch): the parents
+// which aren't in events are omitted, so ASCIIschemeToDAG parses the result
+// into the same graph with the cut-off history.
 func DAGtoASCIIscheme(events Events) (string, error) {
 	events = events.ByParents()
 
@@ -223,6 +226,7 @@ func DAGtoASCIIscheme(events Events) (string, error) {
 
 		processed = make(map[hash.Event]*Event)
 		nodeCols  = make(map[idx.StakerID]int)
+		names     = make(map[string]struct{})
 		ok        bool
 
 		eventIndex       = make(map[idx.StakerID]map[hash.Event]int)
@@ -264,16 +268,27 @@ func DAGtoASCIIscheme(events Events) (string, error) {
 			}
 			r.Name = fmt.Sprintf("%s%03d", r.Name, e.Seq)
 		}
+		// forks have the same seq, but names must be unique
+		for n, name := 1, r.Name; ; n++ {
+			if _, exists := names[r.Name]; !exists {
+				break
+			}
+			r.Name = fmt.Sprintf("%s.%d", name, n)
+		}
+		names[r.Name] = struct{}{}
 		if w := len([]rune(r.Name)); scheme.ColWidth < w {
 			scheme.ColWidth = w
 		}
 		// parents
 		r.Refs = make([]int, len(nodeCols))
 		selfRefs := 0
+		cutOff := false
 		for _, p := range e.Parents {
 			parent := processed[p]
 			if parent == nil {
-				return "", fmt.Errorf("parent %s of %s not found", p.String(), ehash.String())
+				// isn't in events (parents in events are ordered first by ByParents)
+				cutOff = true
+				continue
 			}
 			if parent.Creator == e.Creator {
 				selfRefs++
@@ -293,7 +308,8 @@ func DAGtoASCIIscheme(events Events) (string, error) {
 
 			r.Refs[refCol] = creatorLastIndex[parent.Creator] - eventIndex[parent.Creator][parent.Hash()] + shift
 		}
-		if (e.Seq <= 1 && selfRefs != 0) || (e.Seq > 1 && selfRefs != 1) {
+		// self-parent may be cut off
+		if selfRefs > 1 || (e.Seq <= 1 && selfRefs != 0) || (e.Seq > 1 && selfRefs != 1 && !cutOff) {
 			return "", fmt.Errorf("self-parents count of %s is %d", ehash, selfRefs)
 		}
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/utils"
 )
 
@@ -104,6 +105,73 @@ func TestDAGtoASCIIschemeRand(t *testing.T) {
 	}
 }
 
+func TestDAGtoASCIIschemeSubgraph(t *testing.T) {
+	assertar := assert.New(t)
+
+	nodes := GenNodes(5)
+	ee := GenRandEvents(nodes, 10, 3, nil)
+
+	// cut off the first half of each node events
+	var src Events
+	inSrc := hash.EventsSet{}
+	for _, node := range nodes {
+		for _, e := range ee[node][len(ee[node])/2:] {
+			src = append(src, e)
+			inSrc.Add(e.Hash())
+		}
+	}
+
+	scheme, err := DAGtoASCIIscheme(src)
+	if !assertar.NoError(err) {
+		return
+	}
+
+	_, _, names := ASCIIschemeToDAG(scheme)
+	if !assertar.Equal(len(src), len(names), "event count") {
+		return
+	}
+
+	for _, e0 := range src {
+		n := e0.Hash().String()
+		e1 := names[n]
+		if !assertar.NotNil(e1, "event "+n) {
+			return
+		}
+
+		parents0 := make(map[string]struct{}, len(e0.Parents))
+		for _, p := range e0.Parents {
+			if inSrc.Contains(p) {
+				parents0[p.String()] = struct{}{}
+			}
+		}
+		if !assertar.EqualValues(parents0, edges2text(e1), "at event "+n) {
+			t.Log(scheme)
+			return
+		}
+	}
+}
+
+func TestDAGtoASCIIschemeForkNames(t *testing.T) {
+	assertar := assert.New(t)
+
+	// two events with the same creator and seq
+	a1, a2 := NewEvent(), NewEvent()
+	for i, e := range []*Event{a1, a2} {
+		e.Creator = 1
+		e.Seq = 1
+		e.Lamport = 1
+		e.Extra = []byte{byte(i)}
+		e.RecacheHash()
+	}
+
+	scheme, err := DAGtoASCIIscheme(Events{a1, a2})
+	if !assertar.NoError(err) {
+		return
+	}
+	_, _, names := ASCIIschemeToDAG(scheme)
+	assertar.Equal(2, len(names), "fork events must have unique names")
+}
+
 func TestDAGtoASCIIschemeOptimisation(t *testing.T) {
 
 	t.Run("Simple", func(t *testing.T) {
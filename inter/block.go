@@ -18,6 +18,8 @@ type ConsensusCallbacks struct {
 	OnEventConfirmed func(event *EventHeaderData, seqDepth idx.Event)
 	// IsEventAllowedIntoBlock is callback type to check is event may be within block or not
 	IsEventAllowedIntoBlock func(event *EventHeaderData, seqDepth idx.Event) bool
+	// OnForkDetected is callback type to notify about a new fork event, and an event with the same creator and seq.
+	OnForkDetected func(fork *EventHeaderData, conflicting hash.Event)
 }
 
 // Block is a "chain" block.
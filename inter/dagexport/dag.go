@@ -0,0 +1,161 @@
+package dagexport
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+// Formats of exported DAG.
+const (
+	DOT   = "dot"
+	JSON  = "json"
+	ASCII = "ascii"
+)
+
+// Source provides the epoch data to export.
+type Source struct {
+	// ForEachEvent iterates the epoch events, parents first
+	ForEachEvent func(onEvent func(e *inter.Event) bool)
+	// IsAtropos returns true if event is an Atropos of a block
+	IsAtropos func(id hash.Event) bool
+	// ConfirmedOn returns frame which event is confirmed on, 0 if event isn't confirmed
+	ConfirmedOn func(id hash.Event) idx.Frame
+	// Cheaters are the validators which have created forks in the epoch
+	Cheaters inter.Cheaters
+	// Limit is the max number of exported events, 0 means no limit.
+	// It may be exceeded to export all the events of the last Lamport.
+	Limit int
+}
+
+// Dag is an exported epoch.
+type Dag struct {
+	Epoch    idx.Epoch      `json:"epoch"`
+	Cheaters inter.Cheaters `json:"cheaters"`
+	Events   []*Event       `json:"events"`
+	// Next is Lamport of the first not exported event, if the export is limited, or 0 otherwise
+	Next idx.Lamport `json:"next,omitempty"`
+
+	// original events, for ASCII-scheme
+	events inter.Events
+}
+
+// Event is an exported event.
+type Event struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Creator     idx.StakerID `json:"creator"`
+	Seq         idx.Event    `json:"seq"`
+	Lamport     idx.Lamport  `json:"lamport"`
+	Frame       idx.Frame    `json:"frame"`
+	IsRoot      bool         `json:"isRoot"`
+	IsAtropos   bool         `json:"isAtropos"`
+	ConfirmedOn idx.Frame    `json:"confirmedOn"`
+	Cheater     bool         `json:"cheater"`
+	Parents     []string     `json:"parents"`
+}
+
+// Build collects the epoch events from source.
+// Source should iterate the events in Lamport order, if the export is limited.
+func Build(epoch idx.Epoch, src *Source) *Dag {
+	cheaters := src.Cheaters.Set()
+
+	dag := &Dag{
+		Epoch:    epoch,
+		Cheaters: src.Cheaters,
+		Events:   []*Event{},
+	}
+	src.ForEachEvent(func(e *inter.Event) bool {
+		if src.Limit > 0 && len(dag.Events) >= src.Limit && dag.events[len(dag.events)-1].Lamport != e.Lamport {
+			dag.Next = e.Lamport
+			return false
+		}
+		id := e.Hash()
+		_, cheater := cheaters[e.Creator]
+
+		exported := &Event{
+			ID:          id.Hex(),
+			Name:        id.String(),
+			Creator:     e.Creator,
+			Seq:         e.Seq,
+			Lamport:     e.Lamport,
+			Frame:       e.Frame,
+			IsRoot:      e.IsRoot,
+			IsAtropos:   src.IsAtropos(id),
+			ConfirmedOn: src.ConfirmedOn(id),
+			Cheater:     cheater,
+			Parents:     make([]string, len(e.Parents)),
+		}
+		for i, p := range e.Parents {
+			exported.Parents[i] = p.Hex()
+		}
+
+		dag.Events = append(dag.Events, exported)
+		dag.events = append(dag.events, e)
+		return true
+	})
+
+	return dag
+}
+
+// Marshal returns the DAG in the format.
+func (dag *Dag) Marshal(format string) ([]byte, error) {
+	switch format {
+	case DOT:
+		return dag.MarshalDOT(), nil
+	case JSON:
+		return dag.MarshalJSON()
+	case ASCII:
+		scheme, err := inter.DAGtoASCIIscheme(dag.events)
+		return []byte(scheme), err
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected %q, %q or %q", format, DOT, JSON, ASCII)
+	}
+}
+
+// LastFrames returns the DAG part with events of the last n frames.
+// The parents of the first frame are cut off.
+func (dag *Dag) LastFrames(n idx.Frame) *Dag {
+	var last idx.Frame
+	for _, e := range dag.Events {
+		if last < e.Frame {
+			last = e.Frame
+		}
+	}
+	if last < n {
+		return dag
+	}
+
+	part := &Dag{
+		Epoch:    dag.Epoch,
+		Cheaters: dag.Cheaters,
+		Events:   []*Event{},
+	}
+	for i, e := range dag.Events {
+		if e.Frame > last-n {
+			part.Events = append(part.Events, e)
+			part.events = append(part.events, dag.events[i])
+		}
+	}
+	return part
+}
+
+// creators returns the events creators in ascending order.
+func (dag *Dag) creators() []idx.StakerID {
+	set := map[idx.StakerID]struct{}{}
+	for _, e := range dag.Events {
+		set[e.Creator] = struct{}{}
+	}
+
+	creators := make([]idx.StakerID, 0, len(set))
+	for creator := range set {
+		creators = append(creators, creator)
+	}
+	sort.Slice(creators, func(i, j int) bool {
+		return creators[i] < creators[j]
+	})
+	return creators
+}
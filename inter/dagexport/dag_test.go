@@ -0,0 +1,169 @@
+package dagexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+func TestDagExport(t *testing.T) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	nodes, events, named := inter.ASCIIschemeToDAG(`
+a1.1  b1.1  c1.1
+║     ║     ║
+a1.2 ─╫─ ─ ─╣
+║     ║     ║
+║     b2.1 ─╣
+║     ║     ║
+`)
+	var all inter.Events
+	for _, n := range nodes {
+		for _, e := range events[n] {
+			e.Frame = 1
+			all = append(all, e)
+		}
+	}
+	named["b2.1"].Frame = 2
+	for _, name := range []string{"a1.1", "b1.1", "c1.1", "b2.1"} {
+		named[name].IsRoot = true
+	}
+	all = all.ByParents()
+
+	atropos := named["a1.1"].Hash()
+	src := &Source{
+		ForEachEvent: func(onEvent func(e *inter.Event) bool) {
+			for _, e := range all {
+				if !onEvent(e) {
+					return
+				}
+			}
+		},
+		IsAtropos: func(id hash.Event) bool {
+			return id == atropos
+		},
+		ConfirmedOn: func(id hash.Event) idx.Frame {
+			if id == named["b2.1"].Hash() {
+				return 0
+			}
+			return 2
+		},
+		Cheaters: inter.Cheaters{named["c1.1"].Creator},
+	}
+
+	dag := Build(1, src)
+	require.Equal(len(all), len(dag.Events))
+
+	byName := map[string]*Event{}
+	for i, e := range dag.Events {
+		assertar.Equal(all[i].Hash().Hex(), e.ID)
+		byName[all[i].Hash().String()] = e
+	}
+	a11 := byName[named["a1.1"].Hash().String()]
+	assertar.True(a11.IsAtropos)
+	assertar.True(a11.IsRoot)
+	a12 := byName[named["a1.2"].Hash().String()]
+	assertar.False(a12.IsRoot)
+	assertar.Equal([]string{named["a1.1"].Hash().Hex(), named["c1.1"].Hash().Hex()}, a12.Parents)
+	assertar.True(byName[named["c1.1"].Hash().String()].Cheater)
+	assertar.Equal(idx.Frame(0), byName[named["b2.1"].Hash().String()].ConfirmedOn)
+
+	t.Run("dot", func(t *testing.T) {
+		assertar := assert.New(t)
+
+		out, err := dag.Marshal(DOT)
+		require.NoError(err)
+		dot := string(out)
+
+		assertar.True(strings.HasPrefix(dot, "digraph \"epoch 1\" {"))
+		for _, n := range nodes {
+			assertar.Contains(dot, fmt.Sprintf("subgraph \"cluster_%d\"", n))
+		}
+		for _, e := range all {
+			for _, p := range e.Parents {
+				assertar.Contains(dot, "\""+e.Hash().Hex()+"\" -> \""+p.Hex()+"\";")
+			}
+		}
+		assertar.Contains(dot, "color=red, penwidth=3")
+		assertar.Contains(dot, "shape=octagon")
+		assertar.Contains(dot, "style=\"filled,dashed\", fillcolor="+frameColors[2])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assertar := assert.New(t)
+
+		out, err := dag.Marshal(JSON)
+		require.NoError(err)
+
+		got := &Dag{}
+		require.NoError(json.Unmarshal(out, got))
+		assertar.Equal(dag.Epoch, got.Epoch)
+		assertar.Equal(dag.Cheaters, got.Cheaters)
+		assertar.Equal(dag.Events, got.Events)
+	})
+
+	t.Run("ascii", func(t *testing.T) {
+		assertar := assert.New(t)
+
+		out, err := dag.LastFrames(1).Marshal(ASCII)
+		require.NoError(err)
+
+		_, _, got := inter.ASCIIschemeToDAG(string(out))
+		assertar.Equal(1, len(got))
+		assertar.Contains(got, "b2.1")
+	})
+
+	t.Run("last frames", func(t *testing.T) {
+		assertar := assert.New(t)
+
+		assertar.Equal(dag, dag.LastFrames(2))
+
+		part := dag.LastFrames(1)
+		require.Equal(1, len(part.Events))
+		assertar.Equal(named["b2.1"].Hash().Hex(), part.Events[0].ID)
+		assertar.NotContains(string(part.MarshalDOT()), "->")
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		assertar := assert.New(t)
+
+		byLamport := append(inter.Events{}, all...)
+		sort.SliceStable(byLamport, func(i, j int) bool {
+			return byLamport[i].Lamport < byLamport[j].Lamport
+		})
+		limited := *src
+		limited.ForEachEvent = func(onEvent func(e *inter.Event) bool) {
+			for _, e := range byLamport {
+				if !onEvent(e) {
+					return
+				}
+			}
+		}
+
+		// all the events of the last Lamport are exported
+		limited.Limit = 2
+		part := Build(1, &limited)
+		assertar.Equal(3, len(part.Events))
+		assertar.Equal(idx.Lamport(2), part.Next)
+
+		limited.Limit = len(all)
+		part = Build(1, &limited)
+		assertar.Equal(len(all), len(part.Events))
+		assertar.Equal(idx.Lamport(0), part.Next)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := dag.Marshal("svg")
+		assert.Error(t, err)
+	})
+}
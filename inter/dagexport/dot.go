@@ -0,0 +1,80 @@
+package dagexport
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// frameColors are the fill colors of roots, by frame.
+var frameColors = []string{
+	"lightblue",
+	"palegreen",
+	"gold",
+	"pink",
+	"orange",
+	"plum",
+	"aquamarine",
+	"khaki",
+}
+
+// MarshalDOT renders the DAG in GraphViz DOT language, one cluster per validator.
+// Roots are filled with a color of their frame, Atroposes have red bold border,
+// events of cheaters are octagons, not confirmed events have dashed border.
+func (dag *Dag) MarshalDOT() []byte {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "digraph \"epoch %d\" {\n", dag.Epoch)
+	fmt.Fprintf(buf, "\trankdir=BT;\n")
+	fmt.Fprintf(buf, "\tnode [shape=box, style=filled, fillcolor=white];\n")
+
+	for _, creator := range dag.creators() {
+		fmt.Fprintf(buf, "\tsubgraph \"cluster_%d\" {\n", creator)
+		fmt.Fprintf(buf, "\t\tlabel=\"validator %d\";\n", creator)
+		for _, e := range dag.Events {
+			if e.Creator == creator {
+				fmt.Fprintf(buf, "\t\t%q [%s];\n", e.ID, e.dotAttrs())
+			}
+		}
+		fmt.Fprintf(buf, "\t}\n")
+	}
+
+	exported := make(map[string]struct{}, len(dag.Events))
+	for _, e := range dag.Events {
+		exported[e.ID] = struct{}{}
+	}
+	for _, e := range dag.Events {
+		for _, p := range e.Parents {
+			// parents may be cut off by LastFrames
+			if _, ok := exported[p]; ok {
+				fmt.Fprintf(buf, "\t%q -> %q;\n", e.ID, p)
+			}
+		}
+	}
+
+	fmt.Fprintf(buf, "}\n")
+	return buf.Bytes()
+}
+
+func (e *Event) dotAttrs() string {
+	label := fmt.Sprintf("%s\\nseq=%d frame=%d", e.Name, e.Seq, e.Frame)
+	if e.ConfirmedOn != 0 {
+		label += fmt.Sprintf("\\nconfirmed on %d", e.ConfirmedOn)
+	}
+
+	style := "filled"
+	if e.ConfirmedOn == 0 {
+		style = "\"filled,dashed\""
+	}
+	attrs := fmt.Sprintf("label=\"%s\", style=%s", label, style)
+
+	if e.IsRoot {
+		attrs += fmt.Sprintf(", fillcolor=%s", frameColors[int(e.Frame)%len(frameColors)])
+	}
+	if e.IsAtropos {
+		attrs += ", color=red, penwidth=3"
+	}
+	if e.Cheater {
+		attrs += ", shape=octagon"
+	}
+	return attrs
+}
@@ -0,0 +1,11 @@
+package dagexport
+
+import (
+	"encoding/json"
+)
+
+// MarshalJSON returns the indented JSON of the DAG.
+func (dag *Dag) MarshalJSON() ([]byte, error) {
+	type plain Dag // prevents recursion
+	return json.MarshalIndent((*plain)(dag), "", "  ")
+}
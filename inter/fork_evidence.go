@@ -0,0 +1,42 @@
+package inter
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+var (
+	// ErrNotFork is returned if events of evidence aren't a fork.
+	ErrNotFork = errors.New("events aren't a fork")
+	// ErrForkWrongSig is returned if events of evidence aren't signed by the creator.
+	ErrForkWrongSig = errors.New("fork event has wrong signature")
+)
+
+// ForkEvidence is a proof of a double-sign: two different events
+// of the same creator with the same epoch and sequence number.
+type ForkEvidence struct {
+	A EventHeader
+	B EventHeader
+}
+
+// Creator of the forked events.
+func (f *ForkEvidence) Creator() idx.StakerID {
+	return f.A.Creator
+}
+
+// Verify checks that evidence proves a fork of creator with the address.
+func (f *ForkEvidence) Verify(address common.Address) error {
+	if f.A.Creator != f.B.Creator ||
+		f.A.Epoch != f.B.Epoch ||
+		f.A.Seq != f.B.Seq ||
+		f.A.Hash() == f.B.Hash() {
+		return ErrNotFork
+	}
+	if !f.A.VerifySignature(address) || !f.B.VerifySignature(address) {
+		return ErrForkWrongSig
+	}
+	return nil
+}
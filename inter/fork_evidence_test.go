@@ -0,0 +1,66 @@
+package inter
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+)
+
+func TestForkEvidenceVerify(t *testing.T) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	fork := func(extra string) *Event {
+		e := NewEvent()
+		e.Epoch = 2
+		e.Seq = 5
+		e.Creator = 1
+		e.Parents = hash.Events{hash.FakeEvent()}
+		e.Extra = []byte(extra)
+		require.NoError(e.SignBy(key))
+		return e
+	}
+	a, b := fork("a"), fork("b")
+
+	f := &ForkEvidence{
+		A: a.EventHeader,
+		B: b.EventHeader,
+	}
+	assertar.NoError(f.Verify(address))
+	assertar.Equal(a.Creator, f.Creator())
+
+	// encoding
+	raw, err := rlp.EncodeToBytes(f)
+	require.NoError(err)
+	decoded := &ForkEvidence{}
+	require.NoError(rlp.DecodeBytes(raw, decoded))
+	assertar.NoError(decoded.Verify(address))
+
+	// other creator's key
+	other, err := crypto.GenerateKey()
+	require.NoError(err)
+	assertar.Equal(ErrForkWrongSig, f.Verify(crypto.PubkeyToAddress(other.PublicKey)))
+
+	// the same event
+	assertar.Equal(ErrNotFork, (&ForkEvidence{A: a.EventHeader, B: a.EventHeader}).Verify(address))
+
+	// different seq
+	c := fork("c")
+	c.Seq++
+	require.NoError(c.SignBy(key))
+	assertar.Equal(ErrNotFork, (&ForkEvidence{A: a.EventHeader, B: c.EventHeader}).Verify(address))
+
+	// modified after signing
+	b.Extra = []byte("bb")
+	b.RecacheHash()
+	assertar.Equal(ErrForkWrongSig, (&ForkEvidence{A: a.EventHeader, B: b.EventHeader}).Verify(address))
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+)
+
+// maxQueuedJobs is the maximum number of Precalc jobs waiting for a free worker.
+const maxQueuedJobs = 1024
+
+// Workers is a persistent pool of Precalc workers, which may be shared by Run calls.
+type Workers struct {
+	jobs chan func()
+	quit chan struct{}
+}
+
+// NewWorkers starts the pool of n workers.
+func NewWorkers(n int) *Workers {
+	w := &Workers{
+		jobs: make(chan func(), maxQueuedJobs),
+		quit: make(chan struct{}),
+	}
+	for i := 0; i < n; i++ {
+		go w.loop()
+	}
+	return w
+}
+
+// Stop terminates the workers. Jobs of the further Run calls are done by the caller.
+func (w *Workers) Stop() {
+	close(w.quit)
+}
+
+func (w *Workers) loop() {
+	for {
+		select {
+		case job := <-w.jobs:
+			job()
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// do passes the job to a worker, or does it if the workers are stopped.
+func (w *Workers) do(job func()) {
+	select {
+	case w.jobs <- job:
+	case <-w.quit:
+		job()
+	}
+}
+
+// Callback is a set of Run()'s args.
+type Callback struct {
+	// Check is called one by one for the events, whose parents are processed, before Precalc (optional).
+	// If it returns an error, the event and its descendants aren't processed.
+	Check func(e *inter.Event) error
+	// Precalc is called concurrently for the checked events (optional)
+	Precalc func(e *inter.Event)
+	// Process is called one by one for the events, whose parents are processed.
+	// If it returns an error, the event's descendants aren't processed.
+	Process func(e *inter.Event) error
+	// Exists returns true if event is processed before
+	Exists func(hash.Event) bool
+}
+
+// Run processes the events, which may be in any order. An event is checked and passed to Precalc workers
+// as soon as all its parents are processed, while Process calls go serially in the order of readiness.
+// So Precalc of next events may overlap with Process of previous ones, unless the callbacks exclude each other.
+// Precalc is called by the caller if workers is nil.
+// Returns the events, which weren't processed because of not processed parents. Duplicates are ignored.
+func Run(workers *Workers, events inter.Events, callback Callback) (incomplete inter.Events) {
+	var (
+		positions = make(map[hash.Event]int, len(events))
+		waiting   = make([]int, len(events)) // number of not processed parents in the batch
+		missing   = make([]bool, len(events))
+		children  = make([][]int, len(events))
+		processed = make([]bool, len(events))
+	)
+	for i, e := range events {
+		if _, ok := positions[e.Hash()]; ok {
+			// ignore duplicate
+			missing[i] = true
+			processed[i] = true
+			continue
+		}
+		positions[e.Hash()] = i
+	}
+	for i, e := range events {
+		if processed[i] {
+			continue
+		}
+		for _, p := range e.Parents {
+			if j, ok := positions[p]; ok {
+				waiting[i]++
+				children[j] = append(children[j], i)
+			} else if !callback.Exists(p) {
+				missing[i] = true
+			}
+		}
+	}
+
+	done := make([]chan struct{}, len(events))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	ready := make([]int, 0, len(events))
+	push := func(i int) {
+		if callback.Check != nil {
+			if err := callback.Check(events[i]); err != nil {
+				processed[i] = true
+				return
+			}
+		}
+		ready = append(ready, i)
+		switch {
+		case callback.Precalc == nil:
+			close(done[i])
+		case workers == nil:
+			callback.Precalc(events[i])
+			close(done[i])
+		default:
+			workers.do(func() {
+				callback.Precalc(events[i])
+				close(done[i])
+			})
+		}
+	}
+	for i := range events {
+		if waiting[i] == 0 && !missing[i] {
+			push(i)
+		}
+	}
+
+	for n := 0; n < len(ready); n++ {
+		i := ready[n]
+		<-done[i]
+
+		processed[i] = true
+		if err := callback.Process(events[i]); err != nil {
+			continue
+		}
+		for _, child := range children[i] {
+			waiting[child]--
+			if waiting[child] == 0 && !missing[child] {
+				push(child)
+			}
+		}
+	}
+
+	for i, e := range events {
+		if !processed[i] {
+			incomplete = append(incomplete, e)
+		}
+	}
+	return incomplete
+}
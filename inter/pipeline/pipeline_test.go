@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+)
+
+func TestRun(t *testing.T) {
+	assertar := assert.New(t)
+
+	nodes := inter.GenNodes(5)
+	var ordered inter.Events
+	_ = inter.ForEachRandEvent(nodes, 20, 3, rand.New(rand.NewSource(0)), inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			ordered = append(ordered, e)
+		},
+	})
+
+	for _, n := range []int{0, 1, 4} {
+		var workers *Workers
+		if n != 0 {
+			workers = NewWorkers(n)
+		}
+		// the first event is known before, the last one is a duplicate
+		known := hash.EventsSet{ordered[0].Hash(): struct{}{}}
+		unordered := make(inter.Events, len(ordered)-1)
+		for i, j := range rand.Perm(len(ordered) - 1) {
+			unordered[j] = ordered[i+1]
+		}
+		unordered = append(unordered, unordered[0])
+		// the first event of the second node is rejected, and the first event of the third node doesn't pass the check
+		var rejected, unchecked *inter.Event
+		for _, e := range ordered[1:] {
+			if e.Creator == nodes[1] && rejected == nil {
+				rejected = e
+			}
+			if e.Creator == nodes[2] && unchecked == nil {
+				unchecked = e
+			}
+		}
+
+		var (
+			mu        sync.Mutex
+			precalced = hash.EventsSet{}
+			processed = hash.EventsSet{}
+			failed    = 0
+		)
+		incomplete := Run(workers, unordered, Callback{
+			Check: func(e *inter.Event) error {
+				for _, p := range e.Parents {
+					assertar.True(known.Contains(p) || processed.Contains(p), "parents first")
+				}
+				if e == unchecked {
+					failed++
+					return errors.New("unchecked")
+				}
+				return nil
+			},
+			Precalc: func(e *inter.Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				assertar.NotEqual(unchecked, e, "check first")
+				precalced.Add(e.Hash())
+			},
+			Process: func(e *inter.Event) error {
+				for _, p := range e.Parents {
+					assertar.True(known.Contains(p) || processed.Contains(p), "parents first")
+				}
+				if workers != nil {
+					mu.Lock()
+					assertar.True(precalced.Contains(e.Hash()), "precalc first")
+					mu.Unlock()
+				}
+				if e == rejected {
+					failed++
+					return errors.New("rejected")
+				}
+				processed.Add(e.Hash())
+				return nil
+			},
+			Exists: known.Contains,
+		})
+
+		// only descendants of the rejected event aren't processed
+		for _, e := range incomplete {
+			assertar.False(processed.Contains(e.Hash()))
+			connected := true
+			for _, p := range e.Parents {
+				connected = connected && (processed.Contains(p) || known.Contains(p))
+			}
+			assertar.False(connected, e.String())
+		}
+		assertar.NotEmpty(incomplete)
+		// the rejected and unchecked events and the duplicate aren't returned
+		assertar.NotZero(failed)
+		assertar.Equal(len(unordered)-1-failed, len(processed)+len(incomplete))
+
+		if workers != nil {
+			workers.Stop()
+		}
+	}
+}
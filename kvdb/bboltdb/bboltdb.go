@@ -0,0 +1,446 @@
+// Package bboltdb implements the key-value database layer based on bbolt,
+// a pure Go B+tree storage engine.
+package bboltdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// openTimeout is the amount of time to wait to obtain a file lock.
+	openTimeout = 10 * time.Second
+
+	// initialMmapSize is the initial size of the memory map. Writers have to wait for
+	// all the read transactions to be closed on each remap, so it's preallocated generously.
+	initialMmapSize = 256 * 1024 * 1024
+
+	// iteratorChunk is the number of key-value pairs an iterator copies out within one
+	// read transaction. Iterators don't keep transactions open between chunks,
+	// because a long-living iterator would block a remap, and so every writer.
+	iteratorChunk = 256
+)
+
+var (
+	// bucket is the only bucket, all the key-value pairs are stored in.
+	bucket = []byte("kv")
+
+	// errClosed is returned if a database was already closed at the
+	// invocation of a data access operation.
+	errClosed = errors.New("database closed")
+)
+
+// Database is a persistent key-value store. Apart from basic data storage
+// functionality it also supports batch writes and iterating over the keyspace in
+// binary-alphabetical order.
+type Database struct {
+	fn string   // filename for reporting
+	db *bolt.DB // bbolt instance
+
+	lock sync.RWMutex // protects db from being closed while in use
+
+	log log.Logger // Contextual logger tracking the database path
+
+	onClose func() error
+	onDrop  func()
+}
+
+// New returns a wrapped bbolt object.
+func New(path string, close func() error, drop func()) (*Database, error) {
+	return open(path, false, close, drop)
+}
+
+// NewReadOnly opens existing bbolt DB in read-only mode. It takes a shared lock of the DB file,
+// so it fails after openTimeout if the DB is opened for writing (e.g. by a running node).
+func NewReadOnly(path string) (*Database, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return open(path, true, nil, nil)
+}
+
+func open(path string, readonly bool, close func() error, drop func()) (*Database, error) {
+	logger := log.New("database", path)
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout:         openTimeout,
+		InitialMmapSize: initialMmapSize,
+		NoFreelistSync:  true,
+		FreelistType:    bolt.FreelistMapType,
+		ReadOnly:        readonly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if readonly {
+		err = db.View(func(tx *bolt.Tx) error {
+			if tx.Bucket(bucket) == nil {
+				return bolt.ErrBucketNotFound
+			}
+			return nil
+		})
+	} else {
+		err = db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			return err
+		})
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	logger.Info("Opened bbolt database", "readonly", readonly)
+
+	return &Database{
+		fn:      path,
+		db:      db,
+		log:     logger,
+		onClose: close,
+		onDrop:  drop,
+	}, nil
+}
+
+// Close flushes any pending data to disk and closes
+// all io accesses to the underlying key-value store.
+func (db *Database) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		panic("already closed")
+	}
+
+	bdb := db.db
+	db.db = nil
+
+	if db.onClose != nil {
+		if err := db.onClose(); err != nil {
+			return err
+		}
+		db.onClose = nil
+	}
+	return bdb.Close()
+}
+
+// Drop whole database.
+func (db *Database) Drop() {
+	if db.db != nil {
+		panic("Close database first!")
+	}
+	if db.onDrop != nil {
+		db.onDrop()
+	}
+}
+
+// view executes fn within read-only transaction.
+func (db *Database) view(fn func(b *bolt.Bucket) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return errClosed
+	}
+	return db.db.View(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(bucket))
+	})
+}
+
+// update executes fn within read-write transaction.
+func (db *Database) update(fn func(b *bolt.Bucket) error) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return errClosed
+	}
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(bucket))
+	})
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *Database) Has(key []byte) (has bool, err error) {
+	err = db.view(func(b *bolt.Bucket) error {
+		k, _ := b.Cursor().Seek(key)
+		has = k != nil && bytes.Equal(k, key)
+		return nil
+	})
+	return
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *Database) Get(key []byte) (val []byte, err error) {
+	err = db.view(func(b *bolt.Bucket) error {
+		k, v := b.Cursor().Seek(key)
+		if k != nil && bytes.Equal(k, key) {
+			val = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return
+}
+
+// Put inserts the given value into the key-value store.
+func (db *Database) Put(key []byte, value []byte) error {
+	return db.update(func(b *bolt.Bucket) error {
+		return b.Put(key, value)
+	})
+}
+
+// Delete removes the key from the key-value store.
+func (db *Database) Delete(key []byte) error {
+	return db.update(func(b *bolt.Bucket) error {
+		return b.Delete(key)
+	})
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called.
+func (db *Database) NewBatch() ethdb.Batch {
+	return &batch{
+		db: db,
+	}
+}
+
+// NewIterator creates a binary-alphabetical iterator over the entire keyspace
+// contained within the bbolt database.
+func (db *Database) NewIterator() ethdb.Iterator {
+	return db.newIterator(nil, nil)
+}
+
+// NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
+// database content starting at a particular initial key (or after, if it does
+// not exist).
+func (db *Database) NewIteratorWithStart(start []byte) ethdb.Iterator {
+	return db.newIterator(start, nil)
+}
+
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix.
+func (db *Database) NewIteratorWithPrefix(prefix []byte) ethdb.Iterator {
+	return db.newIterator(prefix, prefix)
+}
+
+func (db *Database) newIterator(start, prefix []byte) ethdb.Iterator {
+	it := &iterator{
+		db:     db,
+		seek:   common.CopyBytes(start),
+		prefix: common.CopyBytes(prefix),
+	}
+	// the first chunk is taken right away, so a short iteration sees the data as of the iterator creation
+	it.fill()
+	return it
+}
+
+// Stat returns a particular internal stat of the database.
+func (db *Database) Stat(property string) (stat string, err error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return "", errClosed
+	}
+	switch property {
+	case "bbolt.stats":
+		return fmt.Sprintf("%+v", db.db.Stats()), nil
+	default:
+		return "", errors.New("unknown property")
+	}
+}
+
+// Compact is not supported by bbolt: B+tree pages are reused in place.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Path returns the path to the database file.
+func (db *Database) Path() string {
+	return db.fn
+}
+
+// keyvalue is a key-value tuple tagged with a deletion field to allow creating
+// write batches.
+type keyvalue struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// batch is a write-only bbolt batch that commits changes to its host database
+// within a single transaction when Write is called. A batch cannot be used concurrently.
+type batch struct {
+	db     *Database
+	writes []keyvalue
+	size   int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts the a key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), nil, true})
+	b.size++
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to disk.
+func (b *batch) Write() error {
+	return b.db.update(func(bucket *bolt.Bucket) error {
+		for _, kv := range b.writes {
+			var err error
+			if kv.delete {
+				err = bucket.Delete(kv.key)
+			} else {
+				err = bucket.Put(kv.key, kv.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, kv := range b.writes {
+		if kv.delete {
+			if err := w.Delete(kv.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(kv.key, kv.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator walks over the (potentially partial) keyspace of a bbolt database.
+// It copies out the key-value pairs chunk by chunk, each chunk within a separate
+// read-only transaction. So unlike the snapshot of leveldb iterator, it may observe
+// the writes which are done during the iteration, after the first chunk.
+type iterator struct {
+	db     *Database
+	seek   []byte // the first key of the next chunk
+	prefix []byte
+
+	chunk     []keyvalue
+	pos       int
+	exhausted bool
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	if it.pos >= len(it.chunk) {
+		if it.exhausted || it.err != nil {
+			it.Release()
+			return false
+		}
+		it.fill()
+		if len(it.chunk) == 0 {
+			it.Release()
+			return false
+		}
+	}
+	it.key = it.chunk[it.pos].key
+	it.value = it.chunk[it.pos].value
+	it.pos++
+	return true
+}
+
+// fill copies out the next chunk of key-value pairs.
+func (it *iterator) fill() {
+	it.chunk = it.chunk[:0]
+	it.pos = 0
+
+	it.err = it.db.view(func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		var k, v []byte
+		if it.seek != nil {
+			k, v = c.Seek(it.seek)
+		} else {
+			k, v = c.First()
+		}
+		for ; k != nil && len(it.chunk) < iteratorChunk; k, v = c.Next() {
+			if it.prefix != nil && !bytes.HasPrefix(k, it.prefix) {
+				k = nil
+				break
+			}
+			// bbolt slices are valid only during the transaction and point to read-only mmap
+			it.chunk = append(it.chunk, keyvalue{
+				key:   common.CopyBytes(k),
+				value: append([]byte{}, v...),
+			})
+		}
+		if k == nil || (it.prefix != nil && !bytes.HasPrefix(k, it.prefix)) {
+			it.exhausted = true
+		} else {
+			it.seek = common.CopyBytes(k)
+		}
+		return nil
+	})
+	if it.err != nil {
+		it.chunk = it.chunk[:0]
+	}
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done. The caller
+// should not modify the contents of the returned slice, and its contents may
+// change on the next call to Next.
+func (it *iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key/value pair, or nil if done. The
+// caller should not modify the contents of the returned slice, and its contents
+// may change on the next call to Next.
+func (it *iterator) Value() []byte {
+	return it.value
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (it *iterator) Release() {
+	it.chunk = nil
+	it.pos = 0
+	it.exhausted = true
+	it.key, it.value = nil, nil
+}
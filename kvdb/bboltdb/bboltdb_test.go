@@ -0,0 +1,171 @@
+package bboltdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempDB(t *testing.T) *Database {
+	dir, err := ioutil.TempDir("", "bboltdb-test")
+	if err != nil {
+		t.Fatalf("can't create temporary directory: %v", err)
+	}
+
+	drop := func() {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	db, err := New(filepath.Join(dir, filename), nil, drop)
+	if err != nil {
+		t.Fatalf("can't create temporary database: %v", err)
+	}
+	return db
+}
+
+// Tests that key-value iteration on top of a bbolt database works.
+func TestBboltDBIterator(t *testing.T) {
+	tests := []struct {
+		content map[string]string
+		prefix  string
+		order   []string
+	}{
+		// Empty databases should be iterable
+		{map[string]string{}, "", nil},
+		{map[string]string{}, "non-existent-prefix", nil},
+
+		// Single-item databases should be iterable
+		{map[string]string{"key": "val"}, "", []string{"key"}},
+		{map[string]string{"key": "val"}, "k", []string{"key"}},
+		{map[string]string{"key": "val"}, "l", nil},
+
+		// Multi-item databases should be fully iterable
+		{
+			map[string]string{"k1": "v1", "k5": "v5", "k2": "v2", "k4": "v4", "k3": "v3"},
+			"",
+			[]string{"k1", "k2", "k3", "k4", "k5"},
+		},
+		{
+			map[string]string{"k1": "v1", "k5": "v5", "k2": "v2", "k4": "v4", "k3": "v3"},
+			"l",
+			nil,
+		},
+		// Multi-item databases should be prefix-iterable
+		{
+			map[string]string{
+				"ka1": "va1", "ka5": "va5", "ka2": "va2", "ka4": "va4", "ka3": "va3",
+				"kb1": "vb1", "kb5": "vb5", "kb2": "vb2", "kb4": "vb4", "kb3": "vb3",
+			},
+			"ka",
+			[]string{"ka1", "ka2", "ka3", "ka4", "ka5"},
+		},
+		{
+			map[string]string{
+				"ka1": "va1", "ka5": "va5", "ka2": "va2", "ka4": "va4", "ka3": "va3",
+				"kb1": "vb1", "kb5": "vb5", "kb2": "vb2", "kb4": "vb4", "kb3": "vb3",
+			},
+			"kc",
+			nil,
+		},
+	}
+	for i, tt := range tests {
+		// Create the key-value data store
+		db := tempDB(t)
+		for key, val := range tt.content {
+			if err := db.Put([]byte(key), []byte(val)); err != nil {
+				t.Fatalf("test %d: failed to insert item %s:%s into database: %v", i, key, val, err)
+			}
+		}
+		// Iterate over the database with the given configs and verify the results
+		it, idx := db.NewIteratorWithPrefix([]byte(tt.prefix)), 0
+		for it.Next() {
+			if !bytes.Equal(it.Key(), []byte(tt.order[idx])) {
+				t.Errorf("test %d: item %d: key mismatch: have %s, want %s", i, idx, string(it.Key()), tt.order[idx])
+			}
+			if !bytes.Equal(it.Value(), []byte(tt.content[tt.order[idx]])) {
+				t.Errorf("test %d: item %d: value mismatch: have %s, want %s", i, idx, string(it.Value()), tt.content[tt.order[idx]])
+			}
+			idx++
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("test %d: iteration failed: %v", i, err)
+		}
+		if idx != len(tt.order) {
+			t.Errorf("test %d: iteration terminated prematurely: have %d, want %d", i, idx, len(tt.order))
+		}
+		it.Release()
+
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+		db.Drop()
+	}
+}
+
+// Tests that iterators don't keep read transactions open, so writers aren't blocked.
+func TestBboltDBIteratorChunks(t *testing.T) {
+	db := tempDB(t)
+	defer db.Drop()
+	defer db.Close()
+
+	const num = iteratorChunk*3 + 7
+	for i := 0; i < num; i++ {
+		if err := db.Put(bigendian(i), bigendian(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	it, idx := db.NewIteratorWithPrefix([]byte{0}), 0
+	for it.Next() {
+		if !bytes.Equal(it.Key(), bigendian(idx)) || !bytes.Equal(it.Value(), bigendian(idx)) {
+			t.Fatalf("item %d: mismatch: have %x", idx, it.Key())
+		}
+		if open := db.db.Stats().OpenTxN; open != 0 {
+			t.Fatalf("item %d: %d read transactions are open", idx, open)
+		}
+		// writes aren't blocked by the iterator
+		if err := db.Put(append([]byte{0xff}, bigendian(idx)...), nil); err != nil {
+			t.Fatal(err)
+		}
+		idx++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if idx != num {
+		t.Fatalf("iteration terminated prematurely: have %d, want %d", idx, num)
+	}
+}
+
+func bigendian(i int) []byte {
+	return []byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)}
+}
+
+// Tests that empty values are distinguishable from missing keys.
+func TestBboltDBEmptyValue(t *testing.T) {
+	db := tempDB(t)
+	defer db.Drop()
+	defer db.Close()
+
+	if err := db.Put([]byte("key"), []byte{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if has, err := db.Has([]byte("key")); err != nil || !has {
+		t.Fatalf("existing key: have %v (%v), want true", has, err)
+	}
+	if val, err := db.Get([]byte("key")); err != nil || val == nil || len(val) != 0 {
+		t.Fatalf("existing key: have %v (%v), want empty value", val, err)
+	}
+	if has, err := db.Has([]byte("ke")); err != nil || has {
+		t.Fatalf("missing key: have %v (%v), want false", has, err)
+	}
+	if val, err := db.Get([]byte("kez")); err != nil || val != nil {
+		t.Fatalf("missing key: have %v (%v), want nil", val, err)
+	}
+}
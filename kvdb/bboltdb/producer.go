@@ -0,0 +1,92 @@
+package bboltdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/readonly"
+)
+
+const (
+	dirSuffix = "-bbolt"
+	filename  = "data.db"
+)
+
+type producer struct {
+	datadir  string
+	readonly bool
+}
+
+// NewProducer of bbolt db.
+func NewProducer(datadir string) kvdb.DbProducer {
+	return &producer{
+		datadir: datadir,
+	}
+}
+
+// NewReadOnlyProducer of bbolt db. It opens only existing DBs by NewReadOnly, i.e. with a shared
+// lock, and rejects any changes. DBs can't be opened while a running node holds the exclusive lock.
+func NewReadOnlyProducer(datadir string) kvdb.DbProducer {
+	return &producer{
+		datadir:  datadir,
+		readonly: true,
+	}
+}
+
+// Names of existing databases.
+func (p *producer) Names() []string {
+	var names []string
+
+	files, err := ioutil.ReadDir(p.datadir)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		dirname := f.Name()
+		if strings.HasSuffix(dirname, dirSuffix) {
+			name := strings.TrimSuffix(dirname, dirSuffix)
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// OpenDb or create db with name.
+func (p *producer) OpenDb(name string) kvdb.KeyValueStore {
+	dir := name + dirSuffix
+	path := filepath.Join(p.datadir, dir)
+
+	if p.readonly {
+		db, err := NewReadOnly(filepath.Join(path, filename))
+		if err != nil {
+			panic(err)
+		}
+		return readonly.Wrap(db)
+	}
+
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		panic(err)
+	}
+
+	onDrop := func() {
+		err := os.RemoveAll(path)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	db, err := New(filepath.Join(path, filename), nil, onDrop)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
@@ -0,0 +1,163 @@
+// Package compressed implements key-value store wrapper which transparently
+// compresses values with snappy.
+//
+// Stored values are prefixed with a header byte. Values without a known header
+// are legacy (written before the wrapper) and are read as is, so the wrapper
+// should be applied only to tables which values never start with header bytes
+// (e.g. tables of RLP lists).
+package compressed
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/golang/snappy"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+const (
+	// headerRaw prefixes uncompressed values which first byte collides with a header.
+	headerRaw byte = 0x00
+	// headerSnappy prefixes snappy-compressed values.
+	headerSnappy byte = 0x01
+)
+
+// ErrCorrupted is returned if compressed value can't be decoded.
+var ErrCorrupted = errors.New("compressed value is corrupted")
+
+// Store is a compressing wrapper of kvdb.KeyValueStore.
+type Store struct {
+	kvdb.KeyValueStore
+	threshold int
+}
+
+// Wrap db with compression of values not smaller than threshold bytes.
+// Non-positive threshold disables compression of new values, but compressed
+// values are still read correctly.
+func Wrap(db kvdb.KeyValueStore, threshold int) *Store {
+	return &Store{
+		KeyValueStore: db,
+		threshold:     threshold,
+	}
+}
+
+// encode value for storing.
+func (s *Store) encode(value []byte) []byte {
+	if s.threshold > 0 && len(value) >= s.threshold {
+		enc := make([]byte, 1+snappy.MaxEncodedLen(len(value)))
+		enc[0] = headerSnappy
+		enc = enc[:1+len(snappy.Encode(enc[1:], value))]
+		// keep it uncompressed if compression isn't worth it
+		if len(enc) < len(value) {
+			return enc
+		}
+	}
+
+	if len(value) != 0 && (value[0] == headerRaw || value[0] == headerSnappy) {
+		return append([]byte{headerRaw}, value...)
+	}
+	return value
+}
+
+// decode stored value.
+func decode(value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+
+	switch value[0] {
+	case headerRaw:
+		return value[1:], nil
+	case headerSnappy:
+		dec, err := snappy.Decode(nil, value[1:])
+		if err != nil {
+			return nil, ErrCorrupted
+		}
+		return dec, nil
+	default:
+		// legacy value
+		return value, nil
+	}
+}
+
+// Get retrieves the given key if it's present in the key-value data store.
+func (s *Store) Get(key []byte) ([]byte, error) {
+	val, err := s.KeyValueStore.Get(key)
+	if err != nil || val == nil {
+		return val, err
+	}
+	return decode(val)
+}
+
+// Put inserts the given value into the key-value data store.
+func (s *Store) Put(key []byte, value []byte) error {
+	return s.KeyValueStore.Put(key, s.encode(value))
+}
+
+// NewBatch creates a write-only database that buffers changes to its host db
+// until a final write is called.
+func (s *Store) NewBatch() ethdb.Batch {
+	return &batch{s.KeyValueStore.NewBatch(), s}
+}
+
+// NewIterator creates a binary-alphabetical iterator over the entire keyspace.
+func (s *Store) NewIterator() ethdb.Iterator {
+	return &iterator{Iterator: s.KeyValueStore.NewIterator()}
+}
+
+// NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
+// database content starting at a particular initial key.
+func (s *Store) NewIteratorWithStart(start []byte) ethdb.Iterator {
+	return &iterator{Iterator: s.KeyValueStore.NewIteratorWithStart(start)}
+}
+
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix.
+func (s *Store) NewIteratorWithPrefix(prefix []byte) ethdb.Iterator {
+	return &iterator{Iterator: s.KeyValueStore.NewIteratorWithPrefix(prefix)}
+}
+
+/*
+ * Batch
+ */
+
+type batch struct {
+	ethdb.Batch
+	s *Store
+}
+
+func (b *batch) Put(key, value []byte) error {
+	return b.Batch.Put(key, b.s.encode(value))
+}
+
+/*
+ * Iterator
+ */
+
+type iterator struct {
+	ethdb.Iterator
+	err error
+}
+
+func (it *iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	return it.Iterator.Next()
+}
+
+func (it *iterator) Value() []byte {
+	val, err := decode(it.Iterator.Value())
+	if err != nil {
+		it.err = err
+	}
+	return val
+}
+
+func (it *iterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.Iterator.Error()
+}
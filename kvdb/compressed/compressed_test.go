@@ -0,0 +1,96 @@
+package compressed
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+)
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	mem := memorydb.New()
+	db := Wrap(mem, 64)
+
+	pairs := map[string][]byte{
+		"empty":      {},
+		"small":      []byte("small value"),
+		"raw-header": {headerRaw, 0xff},
+		"zip-header": {headerSnappy, 0xff},
+		"big":        bytes.Repeat([]byte("compressible"), 100),
+		"big-header": append([]byte{headerSnappy}, bytes.Repeat([]byte{0xc0}, 100)...),
+		"random":     {0x63, 0xd5, 0xb2, 0x6b, 0x13, 0x08, 0x67, 0x66, 0x4f, 0x8e, 0x3c, 0xba, 0x1f, 0x45, 0x2d, 0x49, 0x22, 0x0c, 0xee, 0x0c, 0xd1, 0x6a, 0xbb, 0x9a, 0x3d, 0x72, 0x38, 0x1c, 0x28, 0x5d, 0x4b, 0xea, 0x8c, 0xf5, 0x95, 0xde, 0xac, 0x9d, 0x2a, 0x89, 0x81, 0x5d, 0x94, 0xbc, 0x4f, 0x9e, 0x6a, 0x1b, 0x86, 0x6e, 0x35, 0x30, 0x2f, 0x42, 0x65, 0x3d, 0x0f, 0x43, 0x7a, 0xaf, 0x3f, 0x5d, 0x41, 0x02},
+	}
+
+	batch := db.NewBatch()
+	for k, v := range pairs {
+		if len(k)%2 == 0 {
+			require.NoError(db.Put([]byte(k), v))
+		} else {
+			require.NoError(batch.Put([]byte(k), v))
+		}
+	}
+	require.NoError(batch.Write())
+
+	for k, v := range pairs {
+		got, err := db.Get([]byte(k))
+		require.NoError(err)
+		assertar.Equal(v, got, k)
+	}
+
+	stored, err := mem.Get([]byte("big"))
+	require.NoError(err)
+	assertar.Equal(headerSnappy, stored[0])
+	assertar.True(len(stored) < len(pairs["big"]))
+
+	stored, err = mem.Get([]byte("small"))
+	require.NoError(err)
+	assertar.Equal(pairs["small"], stored)
+
+	it := db.NewIterator()
+	defer it.Release()
+	n := 0
+	for it.Next() {
+		assertar.Equal(pairs[string(it.Key())], it.Value(), string(it.Key()))
+		n++
+	}
+	require.NoError(it.Error())
+	assertar.Equal(len(pairs), n)
+}
+
+func TestLegacyValues(t *testing.T) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	mem := memorydb.New()
+	legacy := append([]byte{0xf9}, bytes.Repeat([]byte{0xc0}, 1000)...)
+	require.NoError(mem.Put([]byte("legacy"), legacy))
+	require.NoError(mem.Put([]byte("corrupted"), []byte{headerSnappy, 0xff, 0xff}))
+
+	// compression disabled, but compressed values are readable
+	db := Wrap(mem, 0)
+	require.NoError(Wrap(mem, 1).Put([]byte("compressed"), legacy))
+
+	got, err := db.Get([]byte("legacy"))
+	require.NoError(err)
+	assertar.Equal(legacy, got)
+
+	got, err = db.Get([]byte("compressed"))
+	require.NoError(err)
+	assertar.Equal(legacy, got)
+
+	_, err = db.Get([]byte("corrupted"))
+	assertar.Equal(ErrCorrupted, err)
+
+	it := db.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		_ = it.Value()
+	}
+	assertar.Equal(ErrCorrupted, it.Error())
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sync/atomic"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
@@ -15,10 +16,12 @@ var (
 
 // Fallible is a kvdb.KeyValueStore wrapper around any kvdb.KeyValueStore.
 // It falls when write counter is full for test purpose.
+// Alternatively, it injects faults (see Faults) instead of write counting.
 type Fallible struct {
 	Underlying kvdb.KeyValueStore
 
 	writes int32
+	faults *Injector
 }
 
 // Wrap returns a wrapped kvdb.KeyValueStore with counter 0. Set it manually.
@@ -28,6 +31,15 @@ func Wrap(db kvdb.KeyValueStore) *Fallible {
 	}
 }
 
+// WrapWithFaults returns a wrapped kvdb.KeyValueStore with injected faults.
+// Write counter isn't used.
+func WrapWithFaults(db kvdb.KeyValueStore, faults *Injector) *Fallible {
+	return &Fallible{
+		Underlying: db,
+		faults:     faults,
+	}
+}
+
 // SetWriteCount to n.
 func (f *Fallible) SetWriteCount(n int) {
 	count := int32(n)
@@ -41,22 +53,59 @@ func (f *Fallible) GetWriteCount() int {
 }
 
 func (f *Fallible) count() bool {
+	if f.faults != nil {
+		return true
+	}
 	count := atomic.AddInt32(&f.writes, -1)
 	return count >= 0
 }
 
+func (f *Fallible) read() (flip bool, err error) {
+	if f.faults == nil {
+		return false, nil
+	}
+	switch f.faults.read() {
+	case ReadErr:
+		return false, f.faults.fail()
+	case BitFlip:
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *Fallible) write() error {
+	if f.faults == nil {
+		return nil
+	}
+	if f.faults.write(false) != NoFault {
+		return f.faults.fail()
+	}
+	return nil
+}
+
 /*
  * implementation:
  */
 
 // Has retrieves if a key is present in the key-value data store.
 func (f *Fallible) Has(key []byte) (bool, error) {
+	if _, err := f.read(); err != nil {
+		return false, err
+	}
 	return f.Underlying.Has(key)
 }
 
 // Get retrieves the given key if it's present in the key-value data store.
 func (f *Fallible) Get(key []byte) ([]byte, error) {
-	return f.Underlying.Get(key)
+	flip, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	val, err := f.Underlying.Get(key)
+	if flip && err == nil {
+		val = f.faults.flip(val)
+	}
+	return val, err
 }
 
 // Put inserts the given value into the key-value data store.
@@ -64,37 +113,46 @@ func (f *Fallible) Put(key []byte, value []byte) error {
 	if !f.count() {
 		panic(errWriteLimit)
 	}
+	if err := f.write(); err != nil {
+		return err
+	}
 	return f.Underlying.Put(key, value)
 }
 
 // Delete removes the key from the key-value data store.
 func (f *Fallible) Delete(key []byte) error {
+	if err := f.write(); err != nil {
+		return err
+	}
 	return f.Underlying.Delete(key)
 }
 
 // NewBatch creates a write-only database that buffers changes to its host db
 // until a final write is called.
 func (f *Fallible) NewBatch() ethdb.Batch {
-	return f.Underlying.NewBatch()
+	if f.faults == nil {
+		return f.Underlying.NewBatch()
+	}
+	return &batch{f: f}
 }
 
 // NewIterator creates a binary-alphabetical iterator over the entire keyspace
 // contained within the key-value database.
 func (f *Fallible) NewIterator() ethdb.Iterator {
-	return f.Underlying.NewIterator()
+	return f.wrapIterator(f.Underlying.NewIterator())
 }
 
 // NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
 // database content starting at a particular initial key (or after, if it does
 // not exist).
 func (f *Fallible) NewIteratorWithStart(start []byte) ethdb.Iterator {
-	return f.Underlying.NewIteratorWithStart(start)
+	return f.wrapIterator(f.Underlying.NewIteratorWithStart(start))
 }
 
 // NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
 // of database content with a particular key prefix.
 func (f *Fallible) NewIteratorWithPrefix(prefix []byte) ethdb.Iterator {
-	return f.Underlying.NewIteratorWithPrefix(prefix)
+	return f.wrapIterator(f.Underlying.NewIteratorWithPrefix(prefix))
 }
 
 // Stat returns a particular internal stat of the database.
@@ -130,3 +188,136 @@ func (f *Fallible) Drop() {
 
 	f.Underlying.Drop()
 }
+
+func (f *Fallible) wrapIterator(it ethdb.Iterator) ethdb.Iterator {
+	if f.faults == nil {
+		return it
+	}
+	return &iterator{Iterator: it, f: f}
+}
+
+/*
+ * Batch
+ */
+
+type batchOp struct {
+	key   []byte
+	value []byte
+	del   bool
+}
+
+// batch buffers operations to be able to write them partially.
+type batch struct {
+	f    *Fallible
+	ops  []batchOp
+	size int
+}
+
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, batchOp{
+		key:   common.CopyBytes(key),
+		value: common.CopyBytes(value),
+	})
+	b.size += len(value)
+	return nil
+}
+
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{
+		key: common.CopyBytes(key),
+		del: true,
+	})
+	b.size++
+	return nil
+}
+
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+func (b *batch) Write() error {
+	ops := b.ops
+	fault := b.f.faults.write(true)
+	switch fault {
+	case WriteErr:
+		return b.f.faults.fail()
+	case TornBatch:
+		ops = ops[:b.f.faults.intn(len(ops)+1)]
+	}
+
+	underlying := b.f.Underlying.NewBatch()
+	err := replay(ops, underlying)
+	if err != nil {
+		return err
+	}
+	err = underlying.Write()
+	if err != nil {
+		return err
+	}
+
+	if fault == TornBatch {
+		return b.f.faults.fail()
+	}
+	return nil
+}
+
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	return replay(b.ops, w)
+}
+
+func replay(ops []batchOp, w ethdb.KeyValueWriter) error {
+	for _, op := range ops {
+		var err error
+		if op.del {
+			err = w.Delete(op.key)
+		} else {
+			err = w.Put(op.key, op.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+ * Iterator
+ */
+
+type iterator struct {
+	ethdb.Iterator
+	f *Fallible
+
+	flip bool
+	err  error
+}
+
+func (it *iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.flip, it.err = it.f.read()
+	if it.err != nil {
+		return false
+	}
+	return it.Iterator.Next()
+}
+
+func (it *iterator) Value() []byte {
+	if it.flip {
+		return it.f.faults.flip(it.Iterator.Value())
+	}
+	return it.Iterator.Value()
+}
+
+func (it *iterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.Iterator.Error()
+}
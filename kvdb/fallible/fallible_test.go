@@ -39,3 +39,133 @@ func TestFallible(t *testing.T) {
 		err = db.Put(key, val)
 	})
 }
+
+func TestFaultsSchedule(t *testing.T) {
+	assertar := assert.New(t)
+
+	var (
+		key = []byte("test-key")
+		val = []byte("test-value")
+	)
+
+	mem := memorydb.New()
+	db := WrapWithFaults(mem, NewInjector(Faults{
+		ReadSchedule: map[uint64]Fault{
+			2: ReadErr,
+			3: BitFlip,
+		},
+		WriteSchedule: map[uint64]Fault{
+			2: WriteErr,
+			3: TornBatch,
+		},
+	}))
+
+	// writes
+	assertar.NoError(db.Put(key, val))
+	assertar.Equal(ErrInjected, db.Delete(key))
+
+	batch := db.NewBatch()
+	for i := byte(0); i < 100; i++ {
+		assertar.NoError(batch.Put([]byte{i}, val))
+	}
+	assertar.Equal(ErrInjected, batch.Write())
+	written := 0
+	for i := byte(0); i < 100; i++ {
+		if ok, _ := mem.Has([]byte{i}); ok {
+			written++
+		}
+	}
+	assertar.True(written < 100, written)
+
+	batch.Reset()
+	assertar.NoError(batch.Put([]byte{0}, val))
+	assertar.NoError(batch.Write())
+
+	// reads
+	got, err := db.Get(key)
+	assertar.NoError(err)
+	assertar.Equal(val, got)
+
+	_, err = db.Get(key)
+	assertar.Equal(ErrInjected, err)
+
+	got, err = db.Get(key)
+	assertar.NoError(err)
+	assertar.Equal(1, diffBits(val, got))
+
+	got, err = db.Get(key)
+	assertar.NoError(err)
+	assertar.Equal(val, got)
+
+	reads, writes := db.faults.Counters()
+	assertar.Equal(uint64(4), reads)
+	assertar.Equal(uint64(4), writes)
+}
+
+func TestFaultsRandom(t *testing.T) {
+	assertar := assert.New(t)
+
+	faults := Faults{
+		Seed:         1,
+		ReadErrRate:  0.1,
+		WriteErrRate: 0.1,
+	}
+
+	run := func(faults Faults) (errs []int) {
+		db := WrapWithFaults(memorydb.New(), NewInjector(faults))
+		for i := 0; i < 100; i++ {
+			if db.Put([]byte{byte(i)}, []byte{byte(i)}) != nil {
+				errs = append(errs, i)
+			}
+			if _, err := db.Get([]byte{byte(i)}); err != nil {
+				errs = append(errs, -i)
+			}
+		}
+		return
+	}
+
+	errs := run(faults)
+	assertar.NotEmpty(errs)
+	assertar.Equal(errs, run(faults), "faults aren't reproducible")
+
+	faults.Seed = 2
+	assertar.NotEqual(errs, run(faults))
+
+	faults.Crash = true
+	assertar.Panics(func() {
+		run(faults)
+	})
+}
+
+func TestFaultsIterator(t *testing.T) {
+	assertar := assert.New(t)
+
+	mem := memorydb.New()
+	for i := byte(0); i < 10; i++ {
+		assertar.NoError(mem.Put([]byte{i}, []byte{i}))
+	}
+
+	db := WrapWithFaults(mem, NewInjector(Faults{
+		ReadSchedule: map[uint64]Fault{
+			5: ReadErr,
+		},
+	}))
+
+	it := db.NewIterator()
+	defer it.Release()
+	n := 0
+	for it.Next() {
+		n++
+	}
+	assertar.Equal(4, n)
+	assertar.Equal(ErrInjected, it.Error())
+}
+
+func diffBits(a, b []byte) (n int) {
+	for i := range a {
+		for x := a[i] ^ b[i]; x != 0; x &= x - 1 {
+			n++
+		}
+	}
+	return
+}
@@ -0,0 +1,174 @@
+package fallible
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected is returned (or panicked with) on injected fault.
+var ErrInjected = errors.New("injected fault")
+
+// Fault is a kind of injected fault.
+type Fault int
+
+const (
+	// NoFault means the operation is executed as is.
+	NoFault Fault = iota
+	// ReadErr fails Get, Has or iterator step.
+	ReadErr
+	// WriteErr fails Put, Delete or batch Write.
+	WriteErr
+	// TornBatch writes only a part of the batch and fails.
+	TornBatch
+	// BitFlip silently flips a random bit of the read value.
+	BitFlip
+)
+
+// Faults is a config of injected faults.
+// Rates are probabilities of the fault per operation.
+type Faults struct {
+	// Seed of the faults, the same seed and sequence of operations give the same faults.
+	Seed int64
+
+	ReadErrRate   float64
+	WriteErrRate  float64
+	TornBatchRate float64
+	BitFlipRate   float64
+
+	// MaxLatency is a maximum of random latency added to each operation.
+	MaxLatency time.Duration
+
+	// ReadSchedule and WriteSchedule are faults by sequence number (starting from 1)
+	// of read or write operation. Scheduled faults are injected in addition to random ones.
+	ReadSchedule  map[uint64]Fault
+	WriteSchedule map[uint64]Fault
+
+	// Crash panics instead of returning injected errors.
+	// It simulates a node crash, because stores treat DB errors as fatal.
+	Crash bool
+}
+
+// Injector decides about faults. It may be shared by several DBs.
+type Injector struct {
+	cfg Faults
+
+	rand   *rand.Rand
+	reads  uint64
+	writes uint64
+
+	mu sync.Mutex
+}
+
+// NewInjector of faults.
+func NewInjector(cfg Faults) *Injector {
+	return &Injector{
+		cfg:  cfg,
+		rand: rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+// Counters returns the numbers of read and write operations passed through the injector.
+func (inj *Injector) Counters() (reads, writes uint64) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	return inj.reads, inj.writes
+}
+
+// Disable random and scheduled faults, but not the latency.
+func (inj *Injector) Disable() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	latency := inj.cfg.MaxLatency
+	inj.cfg = Faults{
+		MaxLatency: latency,
+	}
+}
+
+// read decides about a fault of the read operation.
+func (inj *Injector) read() Fault {
+	inj.mu.Lock()
+	inj.reads++
+	fault := inj.cfg.ReadSchedule[inj.reads]
+	if fault == NoFault {
+		switch {
+		case inj.happens(inj.cfg.ReadErrRate):
+			fault = ReadErr
+		case inj.happens(inj.cfg.BitFlipRate):
+			fault = BitFlip
+		}
+	}
+	latency := inj.latency()
+	inj.mu.Unlock()
+
+	time.Sleep(latency)
+	return fault
+}
+
+// write decides about a fault of the write operation.
+func (inj *Injector) write(isBatch bool) Fault {
+	inj.mu.Lock()
+	inj.writes++
+	fault := inj.cfg.WriteSchedule[inj.writes]
+	if fault == NoFault {
+		switch {
+		case inj.happens(inj.cfg.WriteErrRate):
+			fault = WriteErr
+		case isBatch && inj.happens(inj.cfg.TornBatchRate):
+			fault = TornBatch
+		}
+	}
+	if fault == TornBatch && !isBatch {
+		fault = WriteErr
+	}
+	latency := inj.latency()
+	inj.mu.Unlock()
+
+	time.Sleep(latency)
+	return fault
+}
+
+// fail returns injected error or panics.
+func (inj *Injector) fail() error {
+	inj.mu.Lock()
+	crash := inj.cfg.Crash
+	inj.mu.Unlock()
+
+	if crash {
+		panic(ErrInjected)
+	}
+	return ErrInjected
+}
+
+// intn returns random number in [0, n).
+func (inj *Injector) intn(n int) int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	return inj.rand.Intn(n)
+}
+
+// flip returns a copy of the value with a random bit flipped.
+func (inj *Injector) flip(value []byte) []byte {
+	if len(value) == 0 {
+		return value
+	}
+	bit := inj.intn(len(value) * 8)
+	flipped := append([]byte(nil), value...)
+	flipped[bit/8] ^= 1 << uint(bit%8)
+	return flipped
+}
+
+func (inj *Injector) happens(rate float64) bool {
+	return rate > 0 && inj.rand.Float64() < rate
+}
+
+func (inj *Injector) latency() time.Duration {
+	if inj.cfg.MaxLatency <= 0 {
+		return 0
+	}
+	return time.Duration(inj.rand.Int63n(int64(inj.cfg.MaxLatency)))
+}
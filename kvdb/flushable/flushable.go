@@ -219,6 +219,25 @@ func (w *Flushable) flush() error {
 	return batch.Write()
 }
 
+// journalModified calls journal callback with parent DB and the modified pairs (deleted values are nil).
+// The pairs aren't flushed.
+func (w *Flushable) journalModified(journal func(parent kvdb.KeyValueStore, pairs []kv) error) error {
+	if w.modified == nil {
+		return errClosed
+	}
+
+	pairs := make([]kv, 0, w.modified.Size())
+	for it := w.modified.Iterator(); it.Next(); {
+		pair := kv{k: []byte(it.Key().(string))}
+		if it.Value() != nil {
+			pair.v = it.Value().([]byte)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return journal(w.underlying, pairs)
+}
+
 // Stat returns a particular internal stat of the database.
 func (w *Flushable) Stat(property string) (string, error) {
 	return w.underlying.Stat(property)
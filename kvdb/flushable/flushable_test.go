@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/bboltdb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/leveldb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
@@ -27,6 +28,7 @@ func TestFlushable(t *testing.T) {
 	dictSize := opsPerIter // number of different words
 
 	disk := dbProducer("TestFlushable")
+	bbolt := bboltProducer("TestFlushable")
 
 	// open raw databases
 	leveldb1 := disk.OpenDb("1")
@@ -37,45 +39,60 @@ func TestFlushable(t *testing.T) {
 	defer leveldb2.Drop()
 	defer leveldb2.Close()
 
+	bbolt1 := bbolt.OpenDb("1")
+	defer bbolt1.Drop()
+	defer bbolt1.Close()
+
+	bbolt2 := bbolt.OpenDb("2")
+	defer bbolt2.Drop()
+	defer bbolt2.Close()
+
 	// create wrappers
 	dbs := map[string]kvdb.KeyValueStore{
 		"leveldb": leveldb1,
 		"memory":  memorydb.New(),
+		"bbolt":   bbolt1,
 	}
 
 	flushableDbs := map[string]*Flushable{
 		"cache-over-leveldb": Wrap(leveldb2),
 		"cache-over-memory":  Wrap(memorydb.New()),
+		"cache-over-bbolt":   Wrap(bbolt2),
 	}
 
-	baseLdb := table.New(dbs["leveldb"], []byte{})
-	baseMem := table.New(dbs["memory"], []byte{})
-
 	dbsTables := [][]ethdb.KeyValueStore{
 		{
 			dbs["leveldb"],
-			baseLdb.NewTable([]byte{0, 1}),
-			baseLdb.NewTable([]byte{0}).NewTable(common.Hex2Bytes("ffffffffffffffffffffffffffffffffffff")),
+			table.New(dbs["leveldb"], []byte{1}),
+			table.New(table.New(dbs["leveldb"], []byte{2}), []byte{0xff}),
 		},
 		{
 			dbs["memory"],
-			baseMem.NewTable([]byte{0, 1}),
-			baseMem.NewTable([]byte{0}).NewTable(common.Hex2Bytes("ffffffffffffffffffffffffffffffffffff")),
+			table.New(dbs["memory"], []byte{1}),
+			table.New(table.New(dbs["memory"], []byte{2}), []byte{0xff}),
+		},
+		{
+			dbs["bbolt"],
+			table.New(dbs["bbolt"], []byte{1}),
+			table.New(table.New(dbs["bbolt"], []byte{2}), []byte{0xff}),
 		},
 	}
 
-	baseLdb = table.New(flushableDbs["cache-over-leveldb"], []byte{})
-	baseMem = table.New(flushableDbs["cache-over-memory"], []byte{})
 	flushableDbsTables := [][]kvdb.KeyValueStore{
 		{
 			flushableDbs["cache-over-leveldb"],
-			baseLdb.NewTable([]byte{0, 1}),
-			baseLdb.NewTable([]byte{0}).NewTable(common.Hex2Bytes("ffffffffffffffffffffffffffffffffffff")),
+			table.New(flushableDbs["cache-over-leveldb"], []byte{1}),
+			table.New(table.New(flushableDbs["cache-over-leveldb"], []byte{2}), []byte{0xff}),
 		},
 		{
 			flushableDbs["cache-over-memory"],
-			baseMem.NewTable([]byte{0, 1}),
-			baseMem.NewTable([]byte{0}).NewTable(common.Hex2Bytes("ffffffffffffffffffffffffffffffffffff")),
+			table.New(flushableDbs["cache-over-memory"], []byte{1}),
+			table.New(table.New(flushableDbs["cache-over-memory"], []byte{2}), []byte{0xff}),
+		},
+		{
+			flushableDbs["cache-over-bbolt"],
+			table.New(flushableDbs["cache-over-bbolt"], []byte{1}),
+			table.New(table.New(flushableDbs["cache-over-bbolt"], []byte{2}), []byte{0xff}),
 		},
 	}
 
@@ -263,9 +280,18 @@ func TestFlushable(t *testing.T) {
 }
 
 func TestFlushableIterator(t *testing.T) {
-	assertar := assert.New(t)
+	for name, disk := range map[string]kvdb.DbProducer{
+		"leveldb": dbProducer("TestFlushableIterator"),
+		"bbolt":   bboltProducer("TestFlushableIterator"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			testFlushableIterator(t, disk)
+		})
+	}
+}
 
-	disk := dbProducer("TestFlushableIterator")
+func testFlushableIterator(t *testing.T, disk kvdb.DbProducer) {
+	assertar := assert.New(t)
 
 	leveldb := disk.OpenDb("1")
 	defer leveldb.Drop()
@@ -386,3 +412,11 @@ func dbProducer(name string) kvdb.DbProducer {
 	}
 	return leveldb.NewProducer(dir)
 }
+
+func bboltProducer(name string) kvdb.DbProducer {
+	dir, err := ioutil.TempDir("", name)
+	if err != nil {
+		panic(err)
+	}
+	return bboltdb.NewProducer(dir)
+}
@@ -0,0 +1,285 @@
+package flushable
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+// Flush of SyncedPool is journaled (write-ahead), so an interrupted flush may be either
+// rolled back to the previous flush ID or rolled forward to the new one:
+//  1. redo and undo journals of the modified pairs and dirty flushTx are written into each DB, DB data isn't touched;
+//  2. flushTx stage is set to flushed in each DB (since now the flush is committed);
+//  3. DB data is flushed in each DB;
+//  4. queued DBs are retired, see undo.go;
+//  5. clean flag is written into each DB, redo journal and flushTx are erased, old undo journal is pruned.
+// Rollback just erases the journals, roll forward writes the journaled pairs again.
+
+var (
+	// reserved keys are prefixed with 0x00 which isn't used by tables
+	flagKey    = []byte("\x00flag")
+	flushTxKey = []byte("\x00flush-tx")
+	redoPrefix = []byte("\x00flush-redo")
+	// legacyFlagKey is replaced with flagKey on the next flush, as it may collide with keys of table "f"
+	legacyFlagKey = []byte("flag")
+	// legacyDirtyPrefix marks a flush without journal: "dirty" + prev ID + new ID
+	legacyDirtyPrefix = []byte("dirty")
+	// legacyInitialID is prev ID of DB created by a legacy flush
+	legacyInitialID = []byte("initial")
+
+	// ErrNotSynced means that DBs have different flush IDs, and it can't be fixed
+	// by the flush journal (e.g. DBs are written by a version without journal).
+	ErrNotSynced = errors.New("databases aren't synced")
+
+	errReadOnlyRecovery = errors.New("interrupted flush can't be recovered in read-only mode")
+)
+
+const (
+	txDirty   uint8 = iota // journal may be partially written, DB data isn't touched
+	txFlushed              // journals of all the DBs are written, so the flush is committed
+)
+
+// flushTx is a record about flush in progress.
+type flushTx struct {
+	Stage uint8
+	Prev  []byte // empty if DB is new
+	ID    []byte
+	Drops []string
+}
+
+func getFlushTx(db kvdb.KeyValueStore) (*flushTx, error) {
+	buf, err := db.Get(flushTxKey)
+	if err != nil || buf == nil {
+		return nil, err
+	}
+	tx := &flushTx{}
+	err = rlp.DecodeBytes(buf, tx)
+	return tx, err
+}
+
+func setFlushTx(db ethdb.KeyValueWriter, tx *flushTx) error {
+	buf, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	return db.Put(flushTxKey, buf)
+}
+
+// writeRedo saves the modified pairs and dirty flushTx into db, along with the old values and undoTx.
+func writeRedo(db kvdb.KeyValueStore, pairs []kv, tx *flushTx, undo *undoTx) error {
+	seq, err := nextUndoSeq(db)
+	if err != nil {
+		return err
+	}
+
+	batch := db.NewBatch()
+	for _, pair := range pairs {
+		// 0 - key is deleted, 1 - key is put
+		rec := []byte{0}
+		if pair.v != nil {
+			rec = make([]byte, 0, 1+len(pair.v))
+			rec = append(rec, 1)
+			rec = append(rec, pair.v...)
+		}
+		err = batch.Put(redoKey(pair.k), rec)
+		if err != nil {
+			return err
+		}
+		old, err := undoRecord(db, pair.k)
+		if err != nil {
+			return err
+		}
+		err = batch.Put(undoRecKey(seq, pair.k), old)
+		if err != nil {
+			return err
+		}
+
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			err = batch.Write()
+			if err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+
+	// flushTx is written with the last pairs, so journal without flushTx is incomplete
+	err = setUndoTx(batch, seq, undo)
+	if err != nil {
+		return err
+	}
+	err = setFlushTx(batch, tx)
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// eraseRedo removes redo journal of the keys, and finishes flushTx with clean flag.
+func eraseRedo(db kvdb.KeyValueStore, keys [][]byte, flag []byte) error {
+	batch := db.NewBatch()
+	for _, key := range keys {
+		err := batch.Delete(redoKey(key))
+		if err != nil {
+			return err
+		}
+
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			err = batch.Write()
+			if err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+
+	return finishFlushTx(batch, flag)
+}
+
+// recoverRedo removes redo journal, optionally applying the journaled pairs,
+// and finishes flushTx with clean flag.
+func recoverRedo(db kvdb.KeyValueStore, apply bool, flag []byte) error {
+	var redo [][2][]byte // don't write during iteration
+	it := db.NewIteratorWithPrefix(redoPrefix)
+	for it.Next() {
+		redo = append(redo, [2][]byte{
+			common.CopyBytes(it.Key()),
+			common.CopyBytes(it.Value()),
+		})
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return err
+	}
+
+	batch := db.NewBatch()
+	for _, kv := range redo {
+		if apply {
+			key := kv[0][len(redoPrefix):]
+			if len(kv[1]) > 0 && kv[1][0] == 1 {
+				err = batch.Put(key, kv[1][1:])
+			} else {
+				err = batch.Delete(key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		err = batch.Delete(kv[0])
+		if err != nil {
+			return err
+		}
+
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			err = batch.Write()
+			if err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+
+	return finishFlushTx(batch, flag)
+}
+
+// finishFlushTx writes clean flag and erases flushTx within the batch.
+func finishFlushTx(batch ethdb.Batch, flag []byte) error {
+	err := batch.Put(flagKey, flag)
+	if err != nil {
+		return err
+	}
+	err = batch.Delete(legacyFlagKey)
+	if err != nil {
+		return err
+	}
+	err = batch.Delete(flushTxKey)
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// getFlag returns ID of the last flush.
+func getFlag(db kvdb.KeyValueStore) ([]byte, error) {
+	flag, err := db.Get(flagKey)
+	if err != nil || flag != nil {
+		return flag, err
+	}
+	return db.Get(legacyFlagKey)
+}
+
+func hasRedo(db kvdb.KeyValueStore) bool {
+	it := db.NewIteratorWithPrefix(redoPrefix)
+	defer it.Release()
+
+	return it.Next()
+}
+
+// legacyPrevID returns prev ID of legacy dirty mark "prev ID + new ID", or nil if DB is new or IDs can't be split.
+// IDs of a legacy flush have the same length, unless DB is new.
+func legacyPrevID(ids []byte) []byte {
+	if bytes.HasPrefix(ids, legacyInitialID) || len(ids)%2 != 0 {
+		return nil
+	}
+	return ids[:len(ids)/2]
+}
+
+func redoKey(key []byte) []byte {
+	res := make([]byte, 0, len(redoPrefix)+len(key))
+	res = append(res, redoPrefix...)
+	return append(res, key...)
+}
+
+// resolveLegacyFlush returns flush ID which the DBs should be brought to, after a legacy flush (without journal)
+// was interrupted. Legacy flush writes dirty marks into all the DBs first, then it flushes data of all the DBs,
+// and then it writes clean flags. So if any DB is clean with the previous ID, then data of DBs isn't touched yet,
+// and if any DB is clean with the new ID, then data of all the DBs is flushed already.
+// If all the DBs are dirty, data may be flushed partially and it can't be recovered.
+func resolveLegacyFlush(marks map[string][]byte) ([]byte, error) {
+	var (
+		clean []byte
+		dirty [][]byte
+	)
+	for _, mark := range marks {
+		if mark == nil {
+			continue
+		}
+		if bytes.HasPrefix(mark, legacyDirtyPrefix) {
+			dirty = append(dirty, mark[len(legacyDirtyPrefix):])
+			continue
+		}
+		if clean != nil && !bytes.Equal(clean, mark) {
+			return nil, errors.New("different clean flags")
+		}
+		clean = mark
+	}
+	if clean == nil {
+		return nil, errors.New("dirty state of all the databases")
+	}
+
+	forward, backward := 0, 0
+	for _, ids := range dirty {
+		isForward := len(ids) > len(clean) && bytes.HasSuffix(ids, clean)
+		isBackward := len(ids) > len(clean) && bytes.HasPrefix(ids, clean)
+		// DB created by the legacy flush has "initial" prev ID, so it isn't rolled back. Such flush
+		// might have dropped the previous epoch DBs already, i.e. the data of previous ID is lost.
+		if isForward == isBackward {
+			return nil, errors.New("unknown dirty flag")
+		}
+		if isForward {
+			forward++
+		} else {
+			backward++
+		}
+	}
+	if forward != 0 && backward != 0 {
+		return nil, errors.New("dirty flags of different flushes")
+	}
+	return clean, nil
+}
@@ -57,3 +57,14 @@ func (w *LazyFlushable) Flush() error {
 
 	return w.flush()
 }
+
+// journal calls write callback with parent DB and the modified pairs, before they are flushed.
+// Real db is produced.
+func (w *LazyFlushable) journal(write func(parent kvdb.KeyValueStore, pairs []kv) error) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.underlying = w.initUnderlyingDb()
+
+	return w.journalModified(write)
+}
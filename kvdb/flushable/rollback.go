@@ -0,0 +1,314 @@
+package flushable
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/status-im/keycard-go/hexutils"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/readonly"
+)
+
+// rollbackPlan is a way to bring the DBs to the same flush ID by their undo journals.
+type rollbackPlan struct {
+	id      []byte
+	undo    map[string][]undoHeader // flushes to undo in each DB, from the newest
+	drops   []string                // DBs created after the flush
+	restore []string                // retired DBs which are dropped after the flush
+}
+
+// RollbackID returns ID of the last flush which all the DBs may be brought to by Rollback,
+// e.g. if DBs aren't synced after a crash. It returns an error wrapping ErrNotSynced
+// if the undo journals aren't enough (e.g. a DB is behind the others for longer than the retention).
+func (p *SyncedPool) RollbackID() ([]byte, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	var id []byte
+	err := p.withRetired(func(retired map[string]kvdb.KeyValueStore) error {
+		plan, err := p.rollbackPlan(retired)
+		if err != nil {
+			return err
+		}
+		id = plan.id
+		return nil
+	})
+	return id, err
+}
+
+// UndoneKeys returns the keys of DB which are restored by Rollback to the flush id, i.e. the keys modified after the flush.
+func (p *SyncedPool) UndoneKeys(name string, id []byte) ([][]byte, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	var keys [][]byte
+	err := p.withRetired(func(retired map[string]kvdb.KeyValueStore) error {
+		plan, err := p.planTo(id, retired)
+		if err != nil {
+			return err
+		}
+		db := retired[name]
+		if w := p.wrappers[name]; w != nil {
+			db = w.InitUnderlyingDb()
+		}
+
+		unique := make(map[string]struct{})
+		for _, h := range plan.undo[name] {
+			undone, err := undoneKeys(db, h)
+			if err != nil {
+				return err
+			}
+			for _, key := range undone {
+				if _, ok := unique[string(key)]; ok {
+					continue
+				}
+				unique[string(key)] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Rollback brings all the DBs to the flush id by their undo journals: the flushes after the id are undone,
+// the DBs created after the flush are dropped, and the DBs dropped after the flush are restored.
+// Not flushed data is lost. Interrupted rollback is resumed by Rollback to the same id.
+func (p *SyncedPool) Rollback(id []byte) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.readonly {
+		return readonly.ErrReadOnly
+	}
+
+	for _, w := range p.wrappers {
+		w.DropNotFlushed()
+	}
+
+	return p.withRetired(func(retired map[string]kvdb.KeyValueStore) error {
+		plan, err := p.planTo(id, retired)
+		if err != nil {
+			return err
+		}
+
+		// created DBs are dropped first, as only the undo journals of the others prove they're created after the flush
+		dropped := make(map[string]bool, len(plan.drops))
+		for _, name := range plan.drops {
+			log.Warn("Dropping database created after the flush", "db", name, "id", hexutils.BytesToHex(id))
+			dropped[name] = true
+			if db := retired[name]; db != nil {
+				delete(retired, name)
+				delete(p.retired, name)
+				err = db.Close()
+				if err != nil {
+					return err
+				}
+				db.Drop()
+				continue
+			}
+			w := p.wrappers[name]
+			delete(p.wrappers, name)
+			// not w.Drop() because it just queues the drop
+			err = w.Close()
+			if err != nil {
+				return err
+			}
+			w.underlying.Drop()
+		}
+
+		names := make([]string, 0, len(plan.undo))
+		for name := range plan.undo {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if dropped[name] {
+				continue
+			}
+			db := retired[name]
+			if w := p.wrappers[name]; w != nil {
+				db = w.InitUnderlyingDb()
+			}
+			for _, h := range plan.undo[name] {
+				log.Warn("Undoing flush", "db", name, "id", hexutils.BytesToHex(h.tx.ID))
+				err = applyUndo(db, h)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, name := range plan.restore {
+			if dropped[name] {
+				continue
+			}
+			log.Warn("Restoring database dropped after the flush", "db", name, "id", hexutils.BytesToHex(id))
+			err = retired[name].Close()
+			if err != nil {
+				return err
+			}
+			delete(retired, name)
+			delete(p.retired, name)
+			open, drop := p.callbacks(name)
+			p.wrappers[name] = NewLazy(open, drop)
+		}
+
+		return nil
+	})
+}
+
+// withRetired calls fn with the opened retired DBs, and closes the DBs which are kept in the map.
+func (p *SyncedPool) withRetired(fn func(retired map[string]kvdb.KeyValueStore) error) error {
+	retired := make(map[string]kvdb.KeyValueStore, len(p.retired))
+	for name := range p.retired {
+		retired[name] = p.producer.OpenDb(name)
+	}
+
+	err := fn(retired)
+	for _, db := range retired {
+		if cerr := db.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// planTo returns rollback plan, if the DBs may be brought to the flush id.
+func (p *SyncedPool) planTo(id []byte, retired map[string]kvdb.KeyValueStore) (*rollbackPlan, error) {
+	plan, err := p.rollbackPlan(retired)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(plan.id, id) {
+		return nil, fmt.Errorf("%w, flush %s can't be reached, the last common flush is %s",
+			ErrNotSynced, hexutils.BytesToHex(id), hexutils.BytesToHex(plan.id))
+	}
+	return plan, nil
+}
+
+// rollbackPlan finds the last flush which all the DBs may be brought to by their undo journals.
+// The flush is the last flush of one of DBs, as a DB can't be rolled forward.
+func (p *SyncedPool) rollbackPlan(retired map[string]kvdb.KeyValueStore) (*rollbackPlan, error) {
+	var (
+		flags   = make(map[string][]byte)
+		headers = make(map[string][]undoHeader)
+		prevs   = make(map[string][][]byte) // previous IDs of each flush ID
+		descrs  []string
+	)
+	read := func(name string, db kvdb.KeyValueStore) error {
+		flag, err := getFlag(db)
+		if err != nil {
+			return err
+		}
+		hh, err := getUndo(db)
+		if err != nil {
+			return err
+		}
+		flags[name] = flag
+		headers[name] = hh
+		for _, h := range hh {
+			if len(h.tx.Prev) != 0 {
+				prevs[string(h.tx.ID)] = append(prevs[string(h.tx.ID)], h.tx.Prev)
+			}
+		}
+		descrs = append(descrs, fmt.Sprintf("%s: %s", name, hexutils.BytesToHex(flag)))
+		return nil
+	}
+
+	live := make([]string, 0, len(p.wrappers))
+	for name, w := range p.wrappers {
+		live = append(live, name)
+		if err := read(name, w.InitUnderlyingDb()); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(live)
+	old := make([]string, 0, len(retired))
+	for name, db := range retired {
+		old = append(old, name)
+		if err := read(name, db); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(old)
+
+	// isBefore returns true if the flush id is before the flush of
+	isBefore := func(id, of []byte) bool {
+		visited := make(map[string]bool)
+		queue := [][]byte{of}
+		for len(queue) != 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, prev := range prevs[string(cur)] {
+				if bytes.Equal(prev, id) {
+					return true
+				}
+				if !visited[string(prev)] {
+					visited[string(prev)] = true
+					queue = append(queue, prev)
+				}
+			}
+		}
+		return false
+	}
+	// undoTo returns the flushes of DB to undo, from the newest. If DB is created
+	// after the flush id, then it returns the flushes since the creation.
+	undoTo := func(name string, id []byte) (undo []undoHeader, created, ok bool) {
+		cur := flags[name]
+		hh := headers[name]
+		for i := len(hh) - 1; !bytes.Equal(cur, id); i-- {
+			if i < 0 || !bytes.Equal(hh[i].tx.ID, cur) {
+				return nil, false, false
+			}
+			undo = append(undo, hh[i])
+			if len(hh[i].tx.Prev) == 0 {
+				return undo, true, isBefore(id, cur)
+			}
+			cur = hh[i].tx.Prev
+		}
+		return undo, false, true
+	}
+
+	for _, candidate := range live {
+		plan := &rollbackPlan{
+			id:   flags[candidate],
+			undo: make(map[string][]undoHeader),
+		}
+		check := func(name string) bool {
+			undo, created, ok := undoTo(name, plan.id)
+			if !ok {
+				return false
+			}
+			if len(undo) != 0 {
+				plan.undo[name] = undo
+			}
+			if created {
+				plan.drops = append(plan.drops, name)
+			}
+			return true
+		}
+
+		ok := true
+		for _, name := range live {
+			ok = ok && check(name)
+		}
+		for _, name := range old {
+			if !ok || !isBefore(plan.id, p.retired[name]) {
+				continue
+			}
+			ok = check(name)
+			plan.restore = append(plan.restore, name)
+		}
+		if ok {
+			return plan, nil
+		}
+	}
+
+	sort.Strings(descrs)
+	return nil, fmt.Errorf("%w, undo journals aren't enough: %s", ErrNotSynced, strings.Join(descrs, ",\n"))
+}
@@ -3,28 +3,68 @@ package flushable
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/status-im/keycard-go/hexutils"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/readonly"
 )
 
+// SyncedPool keeps DBs synced by flush ID. Flush of the pool is journaled, see journal.go.
 type SyncedPool struct {
 	producer kvdb.DbProducer
 
 	wrappers    map[string]*LazyFlushable
 	queuedDrops map[string]struct{}
+	fresh       map[string]struct{} // DBs created after the last flush
+	retired     map[string][]byte   // dropped DBs which may be restored by Rollback, with the drop flush ID
+	readonly    bool
 
 	prevFlushTime time.Time
 
+	pins   sync.RWMutex // flushes wait while the pool is pinned
+	pinned int32
+
 	sync.Mutex
 }
 
+// NewSyncedPool makes pool over the producer's DBs. Interrupted flush is recovered,
+// and not synced DBs are considered as corrupted.
 func NewSyncedPool(producer kvdb.DbProducer) *SyncedPool {
+	p, err := OpenSyncedPool(producer)
+	if err != nil {
+		log.Crit("Databases are corrupted, which is possible after a crash or disk failure.", "err", err)
+	}
+	return p
+}
+
+// OpenSyncedPool is the same as NewSyncedPool, but it returns an error instead of exit.
+// The error wraps ErrNotSynced if DBs have different flush IDs which can't be recovered by the flush journal,
+// so the caller may restore the data by other means. The pool is returned along with ErrNotSynced,
+// so the data of DBs may be read to restore them.
+func OpenSyncedPool(producer kvdb.DbProducer) (*SyncedPool, error) {
+	return newSyncedPool(producer, false)
+}
+
+// NewReadOnlySyncedPool makes pool which never writes to the producer's DBs, so producer
+// may be read-only (e.g. leveldb.NewReadOnlyProducer). Changes are kept in memory, but Flush fails.
+// Interrupted flush isn't recovered, such DBs should be opened in read-write mode first.
+func NewReadOnlySyncedPool(producer kvdb.DbProducer) *SyncedPool {
+	p, err := newSyncedPool(producer, true)
+	if err != nil {
+		log.Crit("Databases are corrupted, which is possible after a crash or disk failure.", "err", err)
+	}
+	return p
+}
+
+func newSyncedPool(producer kvdb.DbProducer, readonly bool) (*SyncedPool, error) {
 	if producer == nil {
 		panic("nil producer")
 	}
@@ -33,6 +73,9 @@ func NewSyncedPool(producer kvdb.DbProducer) *SyncedPool {
 		producer:    producer,
 		wrappers:    make(map[string]*LazyFlushable),
 		queuedDrops: make(map[string]struct{}),
+		fresh:       make(map[string]struct{}),
+		retired:     make(map[string][]byte),
+		readonly:    readonly,
 	}
 
 	for _, name := range producer.Names() {
@@ -40,11 +83,17 @@ func NewSyncedPool(producer kvdb.DbProducer) *SyncedPool {
 		p.wrappers[name] = NewLazy(open, drop)
 	}
 
+	if err := p.recoverInterruptedFlush(); err != nil {
+		return p, err
+	}
+	if err := p.findRetired(); err != nil {
+		return p, err
+	}
 	if err := p.checkDbsSynced(); err != nil {
-		log.Crit("Databases are corrupted, which is possible after a crash or disk failure.", "err", err)
+		return p, err
 	}
 
-	return p
+	return p, nil
 }
 
 func (p *SyncedPool) callbacks(name string) (
@@ -52,6 +101,10 @@ func (p *SyncedPool) callbacks(name string) (
 	onDrop func(),
 ) {
 	onOpen = func() kvdb.KeyValueStore {
+		if p.readonly && (!p.exists(name) || p.retired[name] != nil) {
+			// don't create new DB, it's empty anyway
+			return readonly.Wrap(memorydb.New())
+		}
 		return p.producer.OpenDb(name)
 	}
 
@@ -62,6 +115,15 @@ func (p *SyncedPool) callbacks(name string) (
 	return
 }
 
+func (p *SyncedPool) exists(name string) bool {
+	for _, n := range p.producer.Names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *SyncedPool) dropDb(name string) {
 	p.Lock()
 	defer p.Unlock()
@@ -82,77 +144,231 @@ func (p *SyncedPool) getDb(name string) kvdb.KeyValueStore {
 		return wrapper
 	}
 
+	if _, ok := p.retired[name]; ok && !p.readonly {
+		// the name is reused, so the retired DB can't be restored anymore
+		p.dropRetired(name)
+	}
+
 	open, drop := p.callbacks(name)
 	wrapper = NewLazy(open, drop)
 
 	p.wrappers[name] = wrapper
+	p.fresh[name] = struct{}{}
 
 	return wrapper
 }
 
+// Names of the DBs, except the retired ones.
+func (p *SyncedPool) Names() []string {
+	p.Lock()
+	defer p.Unlock()
+
+	names := make([]string, 0, len(p.wrappers))
+	for name := range p.wrappers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FlushID returns ID of the last flush (the same for all the synced DBs, except not flushed yet).
+func (p *SyncedPool) FlushID() ([]byte, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, w := range p.wrappers {
+		flag, err := getFlag(w.InitUnderlyingDb())
+		if err != nil || flag != nil {
+			return flag, err
+		}
+	}
+	return nil, nil
+}
+
+// LastFlushIDs returns ID of the last finished flush of each DB, or nil if DB wasn't flushed.
+// If a legacy flush (without journal) of DB was interrupted, it's the ID before the flush, if it's known.
+func (p *SyncedPool) LastFlushIDs() (map[string][]byte, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	ids := make(map[string][]byte, len(p.wrappers))
+	for name, w := range p.wrappers {
+		mark, err := getFlag(w.InitUnderlyingDb())
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(mark, legacyDirtyPrefix) {
+			mark = legacyPrevID(mark[len(legacyDirtyPrefix):])
+		}
+		ids[name] = mark
+	}
+	return ids, nil
+}
+
+// Pin blocks flushes of the pool until unpin is called, so the flushed data may be read by GetFlushedDb
+// while the pool is written. Flush waits for unpin, and IsFlushNeeded returns false while the pool is pinned.
+func (p *SyncedPool) Pin() (unpin func()) {
+	p.pins.RLock()
+	atomic.AddInt32(&p.pinned, 1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt32(&p.pinned, -1)
+			p.pins.RUnlock()
+		})
+	}
+}
+
+// GetFlushedDb returns read-only data of DB as of the last flush. The pool must be pinned while it's read.
+func (p *SyncedPool) GetFlushedDb(name string) kvdb.KeyValueStore {
+	p.Lock()
+	defer p.Unlock()
+
+	p.getDb(name)
+	return readonly.Wrap(p.wrappers[name].InitUnderlyingDb())
+}
+
+// Close all the DBs. Not flushed data is lost.
+func (p *SyncedPool) Close() error {
+	p.Lock()
+	defer p.Unlock()
+
+	for name, w := range p.wrappers {
+		if _, ok := p.queuedDrops[name]; ok {
+			continue // closed before drop
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsReadOnly returns true if the pool is made by NewReadOnlySyncedPool.
+func (p *SyncedPool) IsReadOnly() bool {
+	return p.readonly
+}
+
 func (p *SyncedPool) Flush(id []byte) error {
+	p.pins.Lock()
+	defer p.pins.Unlock()
+
 	p.Lock()
 	defer p.Unlock()
 
+	if p.readonly {
+		return readonly.ErrReadOnly
+	}
+
 	return p.flush(id)
 }
 
 func (p *SyncedPool) flush(id []byte) error {
-	key := []byte("flag")
+	tx := flushTx{
+		Stage: txDirty,
+		ID:    id,
+	}
 
-	// drop old DBs
+	// detach old DBs, they will be retired after the data is flushed
+	drops := make(map[string]*LazyFlushable, len(p.queuedDrops))
 	for name := range p.queuedDrops {
-		w := p.wrappers[name]
+		tx.Drops = append(tx.Drops, name)
+		drops[name] = p.wrappers[name]
 		delete(p.wrappers, name)
-		if w == nil {
-			continue
-		}
-		db := w.underlying
-		if db == nil {
-			continue
-		}
-		// db.Close() is called inside wrapper.Close()
-		db.Drop()
 	}
+	sort.Strings(tx.Drops)
 	p.queuedDrops = make(map[string]struct{})
+	undo := undoTx{
+		ID:    id,
+		Drops: tx.Drops,
+	}
 
-	// write dirty flags
+	// write redo journals
+	journaled := make(map[*LazyFlushable][][]byte, len(p.wrappers))
 	for _, w := range p.wrappers {
-		db := w.InitUnderlyingDb()
-
-		prev, err := db.Get(key)
+		err := w.journal(func(db kvdb.KeyValueStore, pairs []kv) error {
+			prev, err := getFlag(db)
+			if err != nil {
+				return err
+			}
+			keys := make([][]byte, len(pairs))
+			for i, pair := range pairs {
+				keys[i] = pair.k
+			}
+			journaled[w] = keys
+
+			tx := tx
+			tx.Prev = prev
+			undo := undo
+			undo.Prev = prev
+			return writeRedo(db, pairs, &tx, &undo)
+		})
 		if err != nil {
 			return err
 		}
-		if prev == nil {
-			prev = []byte("initial")
-		}
+	}
 
-		marker := bytes.NewBuffer(nil)
-		marker.Write([]byte("dirty"))
-		marker.Write(prev)
-		marker.Write(id)
-		err = db.Put(key, marker.Bytes())
+	// commit
+	for _, w := range p.wrappers {
+		db := w.InitUnderlyingDb()
+		tx, err := getFlushTx(db)
+		if err != nil {
+			return err
+		}
+		tx.Stage = txFlushed
+		err = setFlushTx(db, tx)
 		if err != nil {
 			return err
 		}
 	}
 
 	// flush data
-	for _, wrapper := range p.wrappers {
-		err := wrapper.Flush()
+	for _, w := range p.wrappers {
+		err := w.Flush()
 		if err != nil {
 			return err
 		}
 	}
 
+	// retire old DBs, the never flushed ones are just dropped
+	for name, w := range drops {
+		if _, ok := p.fresh[name]; !ok {
+			p.retired[name] = id
+			continue
+		}
+		if w == nil || w.underlying == nil {
+			continue
+		}
+		// db.Close() is called inside wrapper.Close()
+		w.underlying.Drop()
+	}
+	p.fresh = make(map[string]struct{})
+
 	// write clean flags
 	for _, w := range p.wrappers {
 		db := w.InitUnderlyingDb()
-		err := db.Put(key, id)
+		err := eraseRedo(db, journaled[w], id)
+		if err != nil {
+			return err
+		}
+	}
+
+	// prune undo journals, the retired DBs are dropped once their drop can't be undone
+	kept := make(map[string]bool)
+	for _, w := range p.wrappers {
+		ids, err := pruneUndo(w.InitUnderlyingDb())
 		if err != nil {
 			return err
 		}
+		for _, id := range ids {
+			kept[string(id)] = true
+		}
+	}
+	for name, dropID := range p.retired {
+		if !kept[string(dropID)] {
+			p.dropRetired(name)
+		}
 	}
 
 	p.prevFlushTime = time.Now()
@@ -164,6 +380,9 @@ func (p *SyncedPool) IsFlushNeeded() bool {
 	p.Lock()
 	defer p.Unlock()
 
+	if p.readonly || atomic.LoadInt32(&p.pinned) != 0 {
+		return false
+	}
 	if time.Since(p.prevFlushTime) > 10*time.Minute {
 		return true
 	}
@@ -184,7 +403,6 @@ func (p *SyncedPool) checkDbsSynced() error {
 	defer p.Unlock()
 
 	var (
-		key    = []byte("flag")
 		prevID *[]byte
 		descrs []string
 		list   = func() string {
@@ -194,21 +412,226 @@ func (p *SyncedPool) checkDbsSynced() error {
 	for name, w := range p.wrappers {
 		db := w.InitUnderlyingDb()
 
-		mark, err := db.Get(key)
+		mark, err := getFlag(db)
 		if err != nil {
 			return err
 		}
 		descrs = append(descrs, fmt.Sprintf("%s: %s", name, hexutils.BytesToHex(mark)))
 
-		if bytes.HasPrefix(mark, []byte("dirty")) {
-			return fmt.Errorf("dirty state: %s", list())
+		if bytes.HasPrefix(mark, legacyDirtyPrefix) {
+			return fmt.Errorf("%w, dirty state: %s", ErrNotSynced, list())
 		}
 		if prevID == nil {
 			prevID = &mark
 		}
 		if !bytes.Equal(mark, *prevID) {
-			return fmt.Errorf("not synced: %s", list())
+			return fmt.Errorf("%w: %s", ErrNotSynced, list())
 		}
 	}
 	return nil
 }
+
+// recoverInterruptedFlush on startup, after all dbs are registered.
+// If the last flush was interrupted (by a crash or power loss), it brings
+// all the DBs to the same flush ID: either to the previous one, if the flush wasn't
+// committed, or to the new one otherwise.
+func (p *SyncedPool) recoverInterruptedFlush() error {
+	p.Lock()
+	defer p.Unlock()
+
+	txs := make(map[string]*flushTx)
+	marks := make(map[string][]byte)
+	legacy := false
+	committed := false
+	for name, w := range p.wrappers {
+		db := w.InitUnderlyingDb()
+
+		mark, err := getFlag(db)
+		if err != nil {
+			return err
+		}
+		marks[name] = mark
+		if bytes.HasPrefix(mark, legacyDirtyPrefix) {
+			legacy = true
+			continue
+		}
+
+		tx, err := getFlushTx(db)
+		if err != nil {
+			return err
+		}
+		if tx == nil {
+			if mark == nil {
+				// DB was created by the interrupted flush
+				p.queuedDrops[name] = struct{}{}
+				continue
+			}
+			if hasRedo(db) {
+				// flush was interrupted before flushTx is written
+				txs[name] = &flushTx{
+					Stage: txDirty,
+					Prev:  mark,
+					ID:    mark,
+				}
+			}
+			continue
+		}
+		txs[name] = tx
+		if tx.Stage == txFlushed {
+			committed = true
+		}
+	}
+	if legacy {
+		return p.recoverLegacyFlush(marks)
+	}
+	if len(txs) == 0 && len(p.queuedDrops) == 0 {
+		return nil
+	}
+	if p.readonly {
+		return errReadOnlyRecovery
+	}
+
+	for name, tx := range txs {
+		w := p.wrappers[name]
+		db := w.InitUnderlyingDb()
+
+		if committed {
+			log.Warn("Rolling forward interrupted flush", "db", name, "id", hexutils.BytesToHex(tx.ID))
+			// the dropped DBs are retired, see findRetired
+			err := recoverRedo(db, true, tx.ID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(tx.Prev) == 0 {
+			// DB was created by the interrupted flush
+			p.queuedDrops[name] = struct{}{}
+			continue
+		}
+		log.Warn("Rolling back interrupted flush", "db", name, "id", hexutils.BytesToHex(tx.Prev))
+		err := eraseInterruptedUndo(db, tx)
+		if err != nil {
+			return err
+		}
+		err = recoverRedo(db, false, tx.Prev)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.dropQueued()
+}
+
+// recoverLegacyFlush brings DBs to the same flush ID after a flush without journal was interrupted,
+// if only the flags of the flush are written partially. Otherwise, it returns ErrNotSynced.
+func (p *SyncedPool) recoverLegacyFlush(marks map[string][]byte) error {
+	id, err := resolveLegacyFlush(marks)
+	if err != nil {
+		descrs := make([]string, 0, len(marks))
+		for name, mark := range marks {
+			descrs = append(descrs, fmt.Sprintf("%s: %s", name, hexutils.BytesToHex(mark)))
+		}
+		sort.Strings(descrs)
+		return fmt.Errorf("%w, %v: %s", ErrNotSynced, err, strings.Join(descrs, ",\n"))
+	}
+	if p.readonly {
+		return errReadOnlyRecovery
+	}
+
+	for name, mark := range marks {
+		if mark == nil {
+			// DB was created but never flushed
+			p.queuedDrops[name] = struct{}{}
+			continue
+		}
+		if bytes.Equal(mark, id) {
+			continue
+		}
+		log.Warn("Recovering interrupted legacy flush", "db", name, "id", hexutils.BytesToHex(id))
+		err := finishFlushTx(p.wrappers[name].InitUnderlyingDb().NewBatch(), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.dropQueued()
+}
+
+func (p *SyncedPool) dropQueued() error {
+	for name := range p.queuedDrops {
+		w := p.wrappers[name]
+		delete(p.wrappers, name)
+		if w == nil {
+			continue
+		}
+		log.Warn("Dropping database after interrupted flush", "db", name)
+		// not w.Drop() because it just queues the drop
+		err := w.Close()
+		if err != nil {
+			return err
+		}
+		w.underlying.Drop()
+	}
+	p.queuedDrops = make(map[string]struct{})
+
+	return nil
+}
+
+// findRetired on startup, after interrupted flush is recovered. DB is retired
+// if it's dropped by a flush which may be undone, and it isn't flushed since then.
+func (p *SyncedPool) findRetired() error {
+	p.Lock()
+	defer p.Unlock()
+
+	type drop struct {
+		prev, id []byte
+	}
+	drops := make(map[string][]drop)
+	for _, w := range p.wrappers {
+		headers, err := getUndo(w.InitUnderlyingDb())
+		if err != nil {
+			return err
+		}
+		for _, h := range headers {
+			for _, name := range h.tx.Drops {
+				drops[name] = append(drops[name], drop{h.tx.Prev, h.tx.ID})
+			}
+		}
+	}
+
+	for name, dd := range drops {
+		w := p.wrappers[name]
+		if w == nil {
+			continue
+		}
+		flag, err := getFlag(w.InitUnderlyingDb())
+		if err != nil {
+			return err
+		}
+		for _, d := range dd {
+			if len(flag) == 0 || !bytes.Equal(flag, d.prev) {
+				continue
+			}
+			err = w.Close()
+			if err != nil {
+				return err
+			}
+			delete(p.wrappers, name)
+			p.retired[name] = d.id
+			break
+		}
+	}
+
+	return nil
+}
+
+// dropRetired drops the retired DB physically.
+func (p *SyncedPool) dropRetired(name string) {
+	log.Debug("Dropping retired database", "db", name)
+	db := p.producer.OpenDb(name)
+	_ = db.Close()
+	db.Drop()
+	delete(p.retired, name)
+}
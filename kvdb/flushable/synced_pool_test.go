@@ -0,0 +1,523 @@
+package flushable
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/readonly"
+)
+
+var errCrash = errors.New("crash")
+
+// crashable fails all the writes after counter runs out, so it simulates a crash.
+type crashable struct {
+	kvdb.KeyValueStore
+	writes *int
+}
+
+func (c *crashable) write() error {
+	if *c.writes <= 0 {
+		return errCrash
+	}
+	*c.writes--
+	return nil
+}
+
+func (c *crashable) Put(key []byte, value []byte) error {
+	if err := c.write(); err != nil {
+		return err
+	}
+	return c.KeyValueStore.Put(key, value)
+}
+
+func (c *crashable) Delete(key []byte) error {
+	if err := c.write(); err != nil {
+		return err
+	}
+	return c.KeyValueStore.Delete(key)
+}
+
+// Close keeps memory db data, as it would be kept on disk.
+func (c *crashable) Close() error {
+	return nil
+}
+
+func (c *crashable) Drop() {
+	_ = c.KeyValueStore.Close()
+	c.KeyValueStore.Drop()
+}
+
+func (c *crashable) NewBatch() ethdb.Batch {
+	return &crashableBatch{c.KeyValueStore.NewBatch(), c}
+}
+
+type crashableBatch struct {
+	ethdb.Batch
+	db *crashable
+}
+
+func (b *crashableBatch) Write() error {
+	if err := b.db.write(); err != nil {
+		return err
+	}
+	return b.Batch.Write()
+}
+
+func TestSyncedPoolRecovery(t *testing.T) {
+	for crashAfter := 0; ; crashAfter++ {
+		namespace := fmt.Sprintf("TestSyncedPoolRecovery-%d", crashAfter)
+		if testSyncedPoolCrash(t, namespace, crashAfter) {
+			break
+		}
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+// testSyncedPoolCrash returns true if flush is completed without a crash.
+func testSyncedPoolCrash(t *testing.T, namespace string, crashAfter int) bool {
+	assertar := assert.New(t)
+
+	writes := 1 << 30
+	crashMod := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return &crashable{db, &writes}
+	}
+
+	// state 1
+	pool := NewSyncedPool(memorydb.NewProducer(namespace, crashMod))
+	a, b, old := pool.GetDb("a"), pool.GetDb("b"), pool.GetDb("old")
+	assertar.NoError(a.Put([]byte("k1"), []byte("a1")))
+	assertar.NoError(a.Put([]byte("k2"), []byte("a1")))
+	assertar.NoError(b.Put([]byte("k1"), []byte("b1")))
+	assertar.NoError(old.Put([]byte("k1"), []byte("old")))
+	assertar.NoError(pool.Flush([]byte("id1")))
+
+	// state 2, interrupted
+	assertar.NoError(a.Put([]byte("k1"), []byte("a2")))
+	assertar.NoError(a.Delete([]byte("k2")))
+	assertar.NoError(b.Put([]byte("k2"), []byte("b2")))
+	assertar.NoError(pool.GetDb("new").Put([]byte("k1"), []byte("new")))
+	assertar.NoError(old.Close())
+	old.Drop()
+	writes = crashAfter
+	completed := pool.Flush([]byte("id2")) == nil
+
+	// restart
+	pool = NewSyncedPool(memorydb.NewProducer(namespace))
+	names := pool.Names()
+
+	flag, err := getFlag(pool.GetDb("a").(*LazyFlushable).InitUnderlyingDb())
+	assertar.NoError(err)
+
+	get := func(db, key string) string {
+		val, err := pool.GetDb(db).Get([]byte(key))
+		assertar.NoError(err)
+		return string(val)
+	}
+	switch string(flag) {
+	case "id1":
+		assertar.False(completed)
+		assertar.ElementsMatch([]string{"a", "b", "old"}, names, crashAfter)
+		assertar.Equal("a1", get("a", "k1"), crashAfter)
+		assertar.Equal("a1", get("a", "k2"), crashAfter)
+		assertar.Equal("b1", get("b", "k1"), crashAfter)
+		assertar.Equal("", get("b", "k2"), crashAfter)
+		assertar.Equal("old", get("old", "k1"), crashAfter)
+	case "id2":
+		assertar.ElementsMatch([]string{"a", "b", "new"}, names, crashAfter)
+		// dropped DB is retired, so the flush may be undone
+		assertar.Contains(memorydb.NewProducer(namespace).Names(), "old", crashAfter)
+		assertar.Equal("a2", get("a", "k1"), crashAfter)
+		assertar.Equal("", get("a", "k2"), crashAfter)
+		assertar.Equal("b1", get("b", "k1"), crashAfter)
+		assertar.Equal("b2", get("b", "k2"), crashAfter)
+		assertar.Equal("new", get("new", "k1"), crashAfter)
+	default:
+		t.Fatalf("unexpected flush ID %s after crash at %d", string(flag), crashAfter)
+	}
+
+	// no journal leftovers
+	for _, name := range names {
+		db := pool.GetDb(name).(*LazyFlushable).InitUnderlyingDb()
+		assertar.False(hasRedo(db), name)
+		tx, err := getFlushTx(db)
+		assertar.NoError(err)
+		assertar.Nil(tx, name)
+		legacy, err := db.Get(legacyFlagKey)
+		assertar.NoError(err)
+		assertar.Nil(legacy, name)
+		headers, err := getUndo(db)
+		assertar.NoError(err)
+		if assertar.NotEmpty(headers, name) {
+			last := headers[len(headers)-1]
+			assertar.Equal(flag, last.tx.ID, name)
+			it := db.NewIteratorWithPrefix(undoRecKey(last.seq+1, nil))
+			assertar.False(it.Next(), name)
+			it.Release()
+		}
+	}
+
+	return completed
+}
+
+func TestSyncedPoolLegacyFlag(t *testing.T) {
+	assertar := assert.New(t)
+
+	const namespace = "TestSyncedPoolLegacyFlag"
+	writes := 1 << 30
+	keep := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return &crashable{db, &writes}
+	}
+	producer := memorydb.NewProducer(namespace, keep)
+	for _, name := range []string{"a", "b"} {
+		assertar.NoError(producer.OpenDb(name).(*crashable).KeyValueStore.Put(legacyFlagKey, []byte("id1")))
+	}
+
+	pool := NewSyncedPool(producer)
+	id, err := pool.FlushID()
+	assertar.NoError(err)
+	assertar.Equal("id1", string(id))
+
+	assertar.NoError(pool.GetDb("a").Put([]byte("k"), []byte("v")))
+	assertar.NoError(pool.Flush([]byte("id2")))
+
+	for _, name := range []string{"a", "b"} {
+		db := pool.GetDb(name).(*LazyFlushable).InitUnderlyingDb()
+		legacy, err := db.Get(legacyFlagKey)
+		assertar.NoError(err)
+		assertar.Nil(legacy, name)
+		flag, err := db.Get(flagKey)
+		assertar.NoError(err)
+		assertar.Equal("id2", string(flag), name)
+	}
+}
+
+func TestSyncedPoolReadOnly(t *testing.T) {
+	assertar := assert.New(t)
+
+	const namespace = "TestSyncedPoolReadOnly"
+	writes := 1 << 30
+	keep := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return &crashable{db, &writes}
+	}
+	pool := NewSyncedPool(memorydb.NewProducer(namespace, keep))
+	assertar.NoError(pool.GetDb("a").Put([]byte("k1"), []byte("a1")))
+	assertar.NoError(pool.Flush([]byte("id1")))
+
+	readonlyMod := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return readonly.Wrap(&crashable{db, &writes})
+	}
+	producer := memorydb.NewProducer(namespace, readonlyMod)
+	pool = NewReadOnlySyncedPool(producer)
+	assertar.True(pool.IsReadOnly())
+
+	id, err := pool.FlushID()
+	assertar.NoError(err)
+	assertar.Equal("id1", string(id))
+
+	val, err := pool.GetDb("a").Get([]byte("k1"))
+	assertar.NoError(err)
+	assertar.Equal("a1", string(val))
+
+	// changes are kept in memory only
+	assertar.NoError(pool.GetDb("a").Put([]byte("k1"), []byte("a2")))
+	assertar.NoError(pool.GetDb("b").Put([]byte("k1"), []byte("b2")))
+	val, err = pool.GetDb("b").Get([]byte("k1"))
+	assertar.NoError(err)
+	assertar.Equal("b2", string(val))
+	assertar.False(pool.IsFlushNeeded())
+	assertar.Equal(readonly.ErrReadOnly, pool.Flush([]byte("id2")))
+	assertar.ElementsMatch([]string{"a"}, producer.Names())
+
+	pool = NewSyncedPool(memorydb.NewProducer(namespace, keep))
+	val, err = pool.GetDb("a").Get([]byte("k1"))
+	assertar.NoError(err)
+	assertar.Equal("a1", string(val))
+}
+
+func TestSyncedPoolLegacyRecovery(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		marks map[string]string
+		id    string
+		last  map[string]string
+	}{
+		{
+			name: "dirty flags are written partially",
+			marks: map[string]string{
+				"a": "dirtyid1id2",
+				"b": "id1",
+			},
+			id: "id1",
+		},
+		{
+			name: "clean flags are written partially",
+			marks: map[string]string{
+				"a": "dirtyid1id2",
+				"b": "id2",
+				"c": "dirtyinitialid2",
+			},
+			id: "id2",
+		},
+		{
+			name: "data is flushed partially",
+			marks: map[string]string{
+				"a": "dirtyid1id2",
+				"b": "dirtyid1id2",
+			},
+			last: map[string]string{
+				"a": "id1",
+				"b": "id1",
+			},
+		},
+		{
+			name: "DB is created by not finished flush",
+			marks: map[string]string{
+				"a": "dirtyid1id2",
+				"b": "id1",
+				"c": "dirtyinitialid2",
+			},
+			last: map[string]string{
+				"a": "id1",
+				"b": "id1",
+				"c": "",
+			},
+		},
+		{
+			name: "different flags",
+			marks: map[string]string{
+				"a": "id1",
+				"b": "id2",
+			},
+			last: map[string]string{
+				"a": "id1",
+				"b": "id2",
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assertar := assert.New(t)
+
+			namespace := "TestSyncedPoolLegacyRecovery-" + c.name
+			writes := 1 << 30
+			keep := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+				return &crashable{db, &writes}
+			}
+			producer := memorydb.NewProducer(namespace, keep)
+			for name, mark := range c.marks {
+				db := producer.OpenDb(name).(*crashable).KeyValueStore
+				assertar.NoError(db.Put(legacyFlagKey, []byte(mark)))
+				assertar.NoError(db.Put([]byte("k"), []byte(name)))
+			}
+
+			pool, err := OpenSyncedPool(producer)
+			if c.id == "" {
+				assertar.True(errors.Is(err, ErrNotSynced), err)
+				// the last finished flush of each DB is known
+				ids, err := pool.LastFlushIDs()
+				assertar.NoError(err)
+				last := make(map[string]string, len(ids))
+				for name, id := range ids {
+					last[name] = string(id)
+				}
+				assertar.Equal(c.last, last)
+				return
+			}
+			if !assertar.NoError(err) {
+				return
+			}
+			id, err := pool.FlushID()
+			assertar.NoError(err)
+			assertar.Equal(c.id, string(id))
+			for name := range c.marks {
+				val, err := pool.GetDb(name).Get([]byte("k"))
+				assertar.NoError(err)
+				assertar.Equal(name, string(val))
+			}
+		})
+	}
+}
+
+// countingReads counts reads of the DB keys.
+type countingReads struct {
+	kvdb.KeyValueStore
+	reads *int
+}
+
+func (c *countingReads) Get(key []byte) ([]byte, error) {
+	*c.reads++
+	return c.KeyValueStore.Get(key)
+}
+
+func (c *countingReads) Has(key []byte) (bool, error) {
+	*c.reads++
+	return c.KeyValueStore.Has(key)
+}
+
+func TestSyncedPoolFlushReads(t *testing.T) {
+	assertar := assert.New(t)
+
+	reads := 0
+	count := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return &countingReads{db, &reads}
+	}
+	pool := NewSyncedPool(memorydb.NewProducer("TestSyncedPoolFlushReads", count))
+	for i := 0; i < 1000; i++ {
+		assertar.NoError(pool.GetDb("a").Put([]byte(fmt.Sprintf("k%d", i)), []byte("v")))
+		assertar.NoError(pool.GetDb("b").Put([]byte(fmt.Sprintf("k%d", i)), []byte("v")))
+	}
+	assertar.NoError(pool.Flush([]byte("id1")))
+
+	reads = 0
+	for i := 0; i < 1000; i++ {
+		assertar.NoError(pool.GetDb("a").Put([]byte(fmt.Sprintf("k%d", i)), []byte("v2")))
+	}
+	assertar.NoError(pool.Flush([]byte("id2")))
+	// only old values of the modified keys are read, besides the journal records
+	assertar.True(reads < 1000+10, reads)
+}
+
+func TestSyncedPoolCloseDropped(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := NewSyncedPool(memorydb.NewProducer(""))
+	assertar.NoError(pool.GetDb("a").Put([]byte("k"), []byte("v")))
+	assertar.NoError(pool.GetDb("b").Put([]byte("k"), []byte("v")))
+	assertar.NoError(pool.Flush([]byte("id1")))
+
+	// dropped DB is closed by its owner, before the drop is flushed
+	db := pool.GetDb("b")
+	assertar.NoError(db.Close())
+	db.Drop()
+
+	assertar.NoError(pool.Close())
+}
+
+func TestSyncedPoolPin(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := NewSyncedPool(memorydb.NewProducer(""))
+	assertar.NoError(pool.GetDb("a").Put([]byte("k"), []byte("v1")))
+	assertar.NoError(pool.Flush([]byte("id1")))
+
+	unpin := pool.Pin()
+	assertar.NoError(pool.GetDb("a").Put([]byte("k"), []byte("v2")))
+	assertar.False(pool.IsFlushNeeded())
+
+	flushed := make(chan error)
+	go func() {
+		flushed <- pool.Flush([]byte("id2"))
+	}()
+	select {
+	case <-flushed:
+		t.Fatal("pinned pool is flushed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// the flushed data isn't changed while the pool is pinned
+	val, err := pool.GetFlushedDb("a").Get([]byte("k"))
+	assertar.NoError(err)
+	assertar.Equal([]byte("v1"), val)
+	assertar.Equal(readonly.ErrReadOnly, pool.GetFlushedDb("a").Put([]byte("k"), []byte("v3")))
+
+	unpin()
+	unpin() // no-op
+	assertar.NoError(<-flushed)
+	val, err = pool.GetFlushedDb("a").Get([]byte("k"))
+	assertar.NoError(err)
+	assertar.Equal([]byte("v2"), val)
+}
+
+func TestSyncedPoolRollback(t *testing.T) {
+	for crashAfter := 0; ; crashAfter++ {
+		namespace := fmt.Sprintf("TestSyncedPoolRollback-%d", crashAfter)
+		if testSyncedPoolRollback(t, namespace, crashAfter) {
+			break
+		}
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+// testSyncedPoolRollback returns true if rollback is completed without a crash.
+func testSyncedPoolRollback(t *testing.T, namespace string, crashAfter int) bool {
+	assertar := assert.New(t)
+
+	writes := 1 << 30
+	crashMod := func(db kvdb.KeyValueStore) kvdb.KeyValueStore {
+		return &crashable{db, &writes}
+	}
+	producer := memorydb.NewProducer(namespace, crashMod)
+
+	pool := NewSyncedPool(producer)
+	a, b, old := pool.GetDb("a"), pool.GetDb("b"), pool.GetDb("old")
+	assertar.NoError(a.Put([]byte("k1"), []byte("a1")))
+	assertar.NoError(b.Put([]byte("k1"), []byte("b1")))
+	assertar.NoError(old.Put([]byte("k1"), []byte("old")))
+	assertar.NoError(pool.Flush([]byte("id1")))
+
+	assertar.NoError(a.Put([]byte("k1"), []byte("a2")))
+	assertar.NoError(a.Put([]byte("k2"), []byte("a2")))
+	assertar.NoError(b.Put([]byte("k1"), []byte("b2")))
+	assertar.NoError(pool.GetDb("new").Put([]byte("k1"), []byte("new")))
+	assertar.NoError(old.Close())
+	old.Drop()
+	assertar.NoError(pool.Flush([]byte("id2")))
+
+	assertar.NoError(a.Delete([]byte("k1")))
+	assertar.NoError(b.Put([]byte("k2"), []byte("b3")))
+	assertar.NoError(pool.Flush([]byte("id3")))
+
+	// b loses the last flushes
+	db := producer.OpenDb("b")
+	headers, err := getUndo(db)
+	assertar.NoError(err)
+	assertar.NoError(applyUndo(db, headers[2]))
+	assertar.NoError(applyUndo(db, headers[1]))
+
+	pool, err = OpenSyncedPool(producer)
+	assertar.True(errors.Is(err, ErrNotSynced), err)
+	id, err := pool.RollbackID()
+	assertar.NoError(err)
+	assertar.Equal("id1", string(id))
+	keys, err := pool.UndoneKeys("a", id)
+	assertar.NoError(err)
+	assertar.ElementsMatch([][]byte{[]byte("k1"), []byte("k2")}, keys)
+
+	writes = crashAfter
+	completed := pool.Rollback(id) == nil
+	writes = 1 << 30
+	if !completed {
+		// resume after restart
+		pool, _ = OpenSyncedPool(producer)
+		assertar.NoError(pool.Rollback(id), crashAfter)
+	}
+
+	pool = NewSyncedPool(producer)
+	assertar.ElementsMatch([]string{"a", "b", "old"}, pool.Names(), crashAfter)
+	assertar.NotContains(producer.Names(), "new", crashAfter)
+	flushID, err := pool.FlushID()
+	assertar.NoError(err)
+	assertar.Equal("id1", string(flushID), crashAfter)
+
+	get := func(db, key string) string {
+		val, err := pool.GetDb(db).Get([]byte(key))
+		assertar.NoError(err)
+		return string(val)
+	}
+	assertar.Equal("a1", get("a", "k1"), crashAfter)
+	assertar.Equal("", get("a", "k2"), crashAfter)
+	assertar.Equal("b1", get("b", "k1"), crashAfter)
+	assertar.Equal("", get("b", "k2"), crashAfter)
+	assertar.Equal("old", get("old", "k1"), crashAfter)
+
+	return completed
+}
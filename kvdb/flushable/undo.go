@@ -0,0 +1,236 @@
+package flushable
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-lachesis/common/bigendian"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+// Flush of SyncedPool keeps undo journal of the last flushes in each DB, so DBs which are
+// ahead of the others (e.g. a DB lost the last writes after a power loss) may be rolled back
+// to the common flush ID, see SyncedPool.Rollback:
+//  - old values of the modified keys are written along with the redo journal;
+//  - undoTx is written along with flushTx, so undo journal of a not committed flush is erased by its rollback;
+//  - DBs dropped by flush are retired instead, i.e. they're kept until undo journal of the flush is pruned;
+//  - undo journal is kept only for the last undoFlushes, as a DB may lose only the last writes.
+
+var (
+	undoTxPrefix  = []byte("\x00undo-tx")
+	undoRecPrefix = []byte("\x00undo-rec")
+)
+
+// undoFlushes is a number of the last flushes which may be undone.
+const undoFlushes = 16
+
+// undoTx is a record about a finished flush.
+type undoTx struct {
+	Prev  []byte // empty if DB is created by the flush
+	ID    []byte
+	Drops []string
+}
+
+// undoHeader is undoTx along with its sequence number in DB.
+type undoHeader struct {
+	seq uint64
+	tx  *undoTx
+}
+
+// getUndo returns undo headers of db, from the oldest.
+func getUndo(db kvdb.KeyValueStore) ([]undoHeader, error) {
+	var headers []undoHeader
+	it := db.NewIteratorWithPrefix(undoTxPrefix)
+	defer it.Release()
+	for it.Next() {
+		tx := &undoTx{}
+		if err := rlp.DecodeBytes(it.Value(), tx); err != nil {
+			return nil, err
+		}
+		headers = append(headers, undoHeader{
+			seq: bigendian.BytesToInt64(it.Key()[len(undoTxPrefix):]),
+			tx:  tx,
+		})
+	}
+	return headers, it.Error()
+}
+
+// nextUndoSeq returns sequence number of the next flush of db.
+func nextUndoSeq(db kvdb.KeyValueStore) (uint64, error) {
+	headers, err := getUndo(db)
+	if err != nil || len(headers) == 0 {
+		return 1, err
+	}
+	return headers[len(headers)-1].seq + 1, nil
+}
+
+func setUndoTx(db ethdb.KeyValueWriter, seq uint64, tx *undoTx) error {
+	buf, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	return db.Put(undoTxKey(seq), buf)
+}
+
+// undoRecord returns old value of the key, which restores it by applyUndo.
+func undoRecord(db kvdb.KeyValueStore, key []byte) ([]byte, error) {
+	old, err := db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	// 0 - key is absent, 1 - key exists
+	if old == nil {
+		return []byte{0}, nil
+	}
+	rec := make([]byte, 0, 1+len(old))
+	rec = append(rec, 1)
+	return append(rec, old...), nil
+}
+
+// eraseUndo removes undo records of the flush seq, and its header if withHeader.
+func eraseUndo(db kvdb.KeyValueStore, seq uint64, withHeader bool) error {
+	return consumePrefix(db, undoRecKey(seq, nil), func(batch ethdb.Batch, key, val []byte) error {
+		return nil
+	}, func(batch ethdb.Batch) error {
+		if withHeader {
+			if err := batch.Delete(undoTxKey(seq)); err != nil {
+				return err
+			}
+		}
+		return batch.Write()
+	})
+}
+
+// eraseInterruptedUndo removes undo journal of the interrupted flush tx. Its header is written along with flushTx,
+// so if it's written then it's the last one.
+func eraseInterruptedUndo(db kvdb.KeyValueStore, tx *flushTx) error {
+	headers, err := getUndo(db)
+	if err != nil {
+		return err
+	}
+	seq := uint64(1)
+	if len(headers) != 0 {
+		last := headers[len(headers)-1]
+		seq = last.seq + 1
+		if bytes.Equal(last.tx.ID, tx.ID) && bytes.Equal(last.tx.Prev, tx.Prev) {
+			seq = last.seq
+		}
+	}
+	return eraseUndo(db, seq, true)
+}
+
+// applyUndo restores the values modified by the flush h, and sets the flag of the previous flush.
+// Records are erased along with the restored values, so it may be resumed after a crash.
+func applyUndo(db kvdb.KeyValueStore, h undoHeader) error {
+	return consumePrefix(db, undoRecKey(h.seq, nil), func(batch ethdb.Batch, key, rec []byte) error {
+		key = key[len(undoRecPrefix)+8:]
+		if len(rec) > 0 && rec[0] == 1 {
+			return batch.Put(key, rec[1:])
+		}
+		return batch.Delete(key)
+	}, func(batch ethdb.Batch) error {
+		if err := batch.Delete(undoTxKey(h.seq)); err != nil {
+			return err
+		}
+		return finishFlushTx(batch, h.tx.Prev)
+	})
+}
+
+// undoneKeys returns the keys which are restored by applyUndo of the flush h.
+func undoneKeys(db kvdb.KeyValueStore, h undoHeader) ([][]byte, error) {
+	var keys [][]byte
+	prefix := undoRecKey(h.seq, nil)
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	for it.Next() {
+		keys = append(keys, common.CopyBytes(it.Key()[len(prefix):]))
+	}
+	return keys, it.Error()
+}
+
+// pruneUndo removes undo journal of the flushes except the last undoFlushes. It returns IDs of the kept flushes.
+func pruneUndo(db kvdb.KeyValueStore) (kept [][]byte, err error) {
+	headers, err := getUndo(db)
+	if err != nil {
+		return nil, err
+	}
+	for i, h := range headers {
+		if i >= len(headers)-undoFlushes {
+			kept = append(kept, h.tx.ID)
+			continue
+		}
+		err = eraseUndo(db, h.seq, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+// consumePrefix calls consume for each pair with the prefix and deletes the pair within the same batch.
+// Pairs are read and written in bounded batches, finish is called with the last one.
+func consumePrefix(
+	db kvdb.KeyValueStore,
+	prefix []byte,
+	consume func(batch ethdb.Batch, key, val []byte) error,
+	finish func(batch ethdb.Batch) error,
+) error {
+	batch := db.NewBatch()
+	for {
+		var pairs [][2][]byte // don't write during iteration
+		size := 0
+		more := false
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			if size > ethdb.IdealBatchSize {
+				more = true
+				break
+			}
+			pairs = append(pairs, [2][]byte{
+				common.CopyBytes(it.Key()),
+				common.CopyBytes(it.Value()),
+			})
+			size += len(it.Key()) + len(it.Value())
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+
+		for _, kv := range pairs {
+			err = consume(batch, kv[0], kv[1])
+			if err != nil {
+				return err
+			}
+			err = batch.Delete(kv[0])
+			if err != nil {
+				return err
+			}
+		}
+		if !more {
+			return finish(batch)
+		}
+		err = batch.Write()
+		if err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+}
+
+func undoTxKey(seq uint64) []byte {
+	res := make([]byte, 0, len(undoTxPrefix)+8)
+	res = append(res, undoTxPrefix...)
+	return append(res, bigendian.Int64ToBytes(seq)...)
+}
+
+func undoRecKey(seq uint64, key []byte) []byte {
+	res := make([]byte, 0, len(undoRecPrefix)+8+len(key))
+	res = append(res, undoRecPrefix...)
+	res = append(res, bigendian.Int64ToBytes(seq)...)
+	return append(res, key...)
+}
@@ -67,6 +67,16 @@ type Database struct {
 // New returns a wrapped LevelDB object. The namespace is the prefix that the
 // metrics reporting should use for surfacing internal stats.
 func New(path string, cache int, handles int, namespace string, close func() error, drop func()) (*Database, error) {
+	return open(path, cache, handles, namespace, false, close, drop)
+}
+
+// NewReadOnly opens existing LevelDB in read-only mode. It takes a shared lock of the DB,
+// so it fails if the DB is opened for writing (e.g. by a running node).
+func NewReadOnly(path string, cache int, handles int, namespace string) (*Database, error) {
+	return open(path, cache, handles, namespace, true, nil, nil)
+}
+
+func open(path string, cache int, handles int, namespace string, readonly bool, close func() error, drop func()) (*Database, error) {
 	// Ensure we have some minimal caching and file guarantees
 	if cache < minCache {
 		cache = minCache
@@ -83,8 +93,10 @@ func New(path string, cache int, handles int, namespace string, close func() err
 		BlockCacheCapacity:     cache / 2 * opt.MiB,
 		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
+		ReadOnly:               readonly,
+		ErrorIfMissing:         readonly,
 	})
-	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+	if _, corrupted := err.(*errors.ErrCorrupted); corrupted && !readonly {
 		db, err = leveldb.RecoverFile(path, nil)
 	}
 	if err != nil {
@@ -7,10 +7,12 @@ import (
 	"strings"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/readonly"
 )
 
 type producer struct {
-	datadir string
+	datadir  string
+	readonly bool
 }
 
 // NewProducer of level db.
@@ -20,6 +22,15 @@ func NewProducer(datadir string) kvdb.DbProducer {
 	}
 }
 
+// NewReadOnlyProducer of level db. It opens only existing DBs by NewReadOnly, i.e. with a shared
+// lock, and rejects any changes. DBs can't be opened while a running node holds the exclusive lock.
+func NewReadOnlyProducer(datadir string) kvdb.DbProducer {
+	return &producer{
+		datadir:  datadir,
+		readonly: true,
+	}
+}
+
 // Names of existing databases.
 func (p *producer) Names() []string {
 	var names []string
@@ -47,6 +58,14 @@ func (p *producer) OpenDb(name string) kvdb.KeyValueStore {
 	dir := name + "-ldb"
 	path := filepath.Join(p.datadir, dir)
 
+	if p.readonly {
+		db, err := NewReadOnly(path, 64, 0, "")
+		if err != nil {
+			panic(err)
+		}
+		return readonly.Wrap(db)
+	}
+
 	err := os.MkdirAll(path, 0700)
 	if err != nil {
 		panic(err)
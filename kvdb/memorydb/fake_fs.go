@@ -62,7 +62,10 @@ func (fs *fakeFS) OpenFakeDB(name string) kvdb.KeyValueStore {
 	defer fs.Unlock()
 
 	if db, ok := fs.Files[name]; ok {
-		return db
+		// data of closed db is lost, so it's reopened as a new one
+		if mem, ok := db.(*Database); !ok || !mem.closed() {
+			return db
+		}
 	}
 
 	drop := func() {
@@ -64,6 +64,15 @@ func (db *Database) Close() error {
 	return nil
 }
 
+// closed returns true if the database is closed, so its data is lost.
+func (db *Database) closed() bool {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db == nil
+}
+
+// Drop whole database.
 func (db *Database) Drop() {
 	if db.db != nil {
 		panic("Close database first!")
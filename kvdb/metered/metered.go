@@ -0,0 +1,186 @@
+// Package metered implements key-value store wrapper which records
+// operations, traffic and latency into metrics.
+package metered
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+// Meters of a table. Meters with the same name are shared between stores.
+type Meters struct {
+	Reads      metrics.Meter // Get and Has calls
+	Writes     metrics.Meter // Put calls (including batched)
+	Deletes    metrics.Meter // Delete calls (including batched)
+	Iterators  metrics.Meter // iterators opened
+	ReadBytes  metrics.Meter // bytes of read keys and values
+	WriteBytes metrics.Meter // bytes of written keys and values
+	ReadTime   metrics.Timer // latency of Get and Has
+	WriteTime  metrics.Timer // latency of Put, Delete and batch Write
+}
+
+// NewMeters registers meters of table with the name prefix.
+func NewMeters(name string) *Meters {
+	return &Meters{
+		Reads:      metrics.GetOrRegisterMeter(name+"/reads", nil),
+		Writes:     metrics.GetOrRegisterMeter(name+"/writes", nil),
+		Deletes:    metrics.GetOrRegisterMeter(name+"/deletes", nil),
+		Iterators:  metrics.GetOrRegisterMeter(name+"/iterators", nil),
+		ReadBytes:  metrics.GetOrRegisterMeter(name+"/read_bytes", nil),
+		WriteBytes: metrics.GetOrRegisterMeter(name+"/write_bytes", nil),
+		ReadTime:   metrics.GetOrRegisterTimer(name+"/read_time", nil),
+		WriteTime:  metrics.GetOrRegisterTimer(name+"/write_time", nil),
+	}
+}
+
+// Store is a metered wrapper of kvdb.KeyValueStore.
+type Store struct {
+	kvdb.KeyValueStore
+	m *Meters
+}
+
+// Wrap db with meters.
+func Wrap(db kvdb.KeyValueStore, m *Meters) *Store {
+	return &Store{
+		KeyValueStore: db,
+		m:             m,
+	}
+}
+
+// WrapTables wraps the tables (struct fields with "table" tag, see table.MigrateTables)
+// with meters named "kvdb/<scope>/<field>". It does nothing if metrics are disabled.
+func WrapTables(s interface{}, scope string) {
+	if !metrics.Enabled {
+		return
+	}
+
+	value := reflect.ValueOf(s).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if prefix := field.Tag.Get("table"); prefix == "" || prefix == "-" {
+			continue
+		}
+		db, ok := value.Field(i).Interface().(kvdb.KeyValueStore)
+		if !ok || db == nil {
+			continue
+		}
+		m := NewMeters("kvdb/" + scope + "/" + field.Name)
+		value.Field(i).Set(reflect.ValueOf(Wrap(db, m)))
+	}
+}
+
+// Has retrieves if a key is present in the key-value data store.
+func (s *Store) Has(key []byte) (bool, error) {
+	start := time.Now()
+	has, err := s.KeyValueStore.Has(key)
+	s.m.ReadTime.UpdateSince(start)
+	s.m.Reads.Mark(1)
+	s.m.ReadBytes.Mark(int64(len(key)))
+	return has, err
+}
+
+// Get retrieves the given key if it's present in the key-value data store.
+func (s *Store) Get(key []byte) ([]byte, error) {
+	start := time.Now()
+	val, err := s.KeyValueStore.Get(key)
+	s.m.ReadTime.UpdateSince(start)
+	s.m.Reads.Mark(1)
+	s.m.ReadBytes.Mark(int64(len(key) + len(val)))
+	return val, err
+}
+
+// Put inserts the given value into the key-value data store.
+func (s *Store) Put(key []byte, value []byte) error {
+	start := time.Now()
+	err := s.KeyValueStore.Put(key, value)
+	s.m.WriteTime.UpdateSince(start)
+	s.m.Writes.Mark(1)
+	s.m.WriteBytes.Mark(int64(len(key) + len(value)))
+	return err
+}
+
+// Delete removes the key from the key-value data store.
+func (s *Store) Delete(key []byte) error {
+	start := time.Now()
+	err := s.KeyValueStore.Delete(key)
+	s.m.WriteTime.UpdateSince(start)
+	s.m.Deletes.Mark(1)
+	s.m.WriteBytes.Mark(int64(len(key)))
+	return err
+}
+
+// NewBatch creates a write-only database that buffers changes to its host db
+// until a final write is called.
+func (s *Store) NewBatch() ethdb.Batch {
+	return &batch{s.KeyValueStore.NewBatch(), s.m}
+}
+
+// NewIterator creates a binary-alphabetical iterator over the entire keyspace.
+func (s *Store) NewIterator() ethdb.Iterator {
+	s.m.Iterators.Mark(1)
+	return &iterator{s.KeyValueStore.NewIterator(), s.m}
+}
+
+// NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
+// database content starting at a particular initial key.
+func (s *Store) NewIteratorWithStart(start []byte) ethdb.Iterator {
+	s.m.Iterators.Mark(1)
+	return &iterator{s.KeyValueStore.NewIteratorWithStart(start), s.m}
+}
+
+// NewIteratorWithPrefix creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix.
+func (s *Store) NewIteratorWithPrefix(prefix []byte) ethdb.Iterator {
+	s.m.Iterators.Mark(1)
+	return &iterator{s.KeyValueStore.NewIteratorWithPrefix(prefix), s.m}
+}
+
+/*
+ * Batch
+ */
+
+type batch struct {
+	ethdb.Batch
+	m *Meters
+}
+
+func (b *batch) Put(key, value []byte) error {
+	b.m.Writes.Mark(1)
+	b.m.WriteBytes.Mark(int64(len(key) + len(value)))
+	return b.Batch.Put(key, value)
+}
+
+func (b *batch) Delete(key []byte) error {
+	b.m.Deletes.Mark(1)
+	b.m.WriteBytes.Mark(int64(len(key)))
+	return b.Batch.Delete(key)
+}
+
+func (b *batch) Write() error {
+	start := time.Now()
+	err := b.Batch.Write()
+	b.m.WriteTime.UpdateSince(start)
+	return err
+}
+
+/*
+ * Iterator
+ */
+
+type iterator struct {
+	ethdb.Iterator
+	m *Meters
+}
+
+func (it *iterator) Next() bool {
+	next := it.Iterator.Next()
+	if next {
+		it.m.ReadBytes.Mark(int64(len(it.Iterator.Key()) + len(it.Iterator.Value())))
+	}
+	return next
+}
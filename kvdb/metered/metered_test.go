@@ -0,0 +1,59 @@
+package metered
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
+)
+
+func TestWrapTables(t *testing.T) {
+	assertar := assert.New(t)
+
+	metrics.Enabled = true
+	defer func() {
+		metrics.Enabled = false
+	}()
+
+	var tables struct {
+		A kvdb.KeyValueStore `table:"a"`
+		B kvdb.KeyValueStore `table:"b"`
+		C kvdb.KeyValueStore
+	}
+	table.MigrateTables(&tables, memorydb.New())
+	WrapTables(&tables, "TestWrapTables")
+
+	assertar.IsType(&Store{}, tables.A)
+	assertar.IsType(&Store{}, tables.B)
+	assertar.Nil(tables.C)
+
+	assertar.NoError(tables.A.Put([]byte("k1"), []byte("v1")))
+	batch := tables.A.NewBatch()
+	assertar.NoError(batch.Put([]byte("k2"), []byte("v2")))
+	assertar.NoError(batch.Delete([]byte("k3")))
+	assertar.NoError(batch.Write())
+	_, err := tables.A.Get([]byte("k1"))
+	assertar.NoError(err)
+	it := tables.A.NewIterator()
+	for it.Next() {
+	}
+	it.Release()
+	_, err = tables.B.Has([]byte("k1"))
+	assertar.NoError(err)
+
+	a := NewMeters("kvdb/TestWrapTables/A")
+	assertar.Equal(int64(2), a.Writes.Count())
+	assertar.Equal(int64(1), a.Deletes.Count())
+	assertar.Equal(int64(1), a.Reads.Count())
+	assertar.Equal(int64(1), a.Iterators.Count())
+	assertar.Equal(int64(4+4+2), a.WriteBytes.Count())
+	assertar.Equal(int64(4+4+4), a.ReadBytes.Count())
+
+	b := NewMeters("kvdb/TestWrapTables/B")
+	assertar.Equal(int64(0), b.Writes.Count())
+	assertar.Equal(int64(1), b.Reads.Count())
+}
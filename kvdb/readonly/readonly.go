@@ -0,0 +1,76 @@
+// Package readonly implements key-value store wrapper which rejects any changes.
+package readonly
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+// ErrReadOnly is returned on attempt to change read-only DB.
+var ErrReadOnly = errors.New("database is opened in read-only mode")
+
+// Store is a read-only wrapper of kvdb.KeyValueStore.
+type Store struct {
+	kvdb.KeyValueStore
+}
+
+// Wrap db to reject changes.
+func Wrap(db kvdb.KeyValueStore) *Store {
+	return &Store{db}
+}
+
+// Put is rejected.
+func (s *Store) Put(key []byte, value []byte) error {
+	return ErrReadOnly
+}
+
+// Delete is rejected.
+func (s *Store) Delete(key []byte) error {
+	return ErrReadOnly
+}
+
+// NewBatch creates a batch which rejects changes.
+func (s *Store) NewBatch() ethdb.Batch {
+	return &batch{}
+}
+
+// Compact is rejected.
+func (s *Store) Compact(start []byte, limit []byte) error {
+	return ErrReadOnly
+}
+
+// Drop panics, because it has no error result.
+func (s *Store) Drop() {
+	panic(ErrReadOnly)
+}
+
+/*
+ * Batch
+ */
+
+type batch struct{}
+
+func (b *batch) Put(key, value []byte) error {
+	return ErrReadOnly
+}
+
+func (b *batch) Delete(key []byte) error {
+	return ErrReadOnly
+}
+
+func (b *batch) ValueSize() int {
+	return 0
+}
+
+func (b *batch) Write() error {
+	return ErrReadOnly
+}
+
+func (b *batch) Reset() {}
+
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	return nil
+}
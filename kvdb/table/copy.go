@@ -0,0 +1,75 @@
+package table
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// copyPart is a number of key-value pairs which are read before they are written,
+// so it's safe to copy tables within the same DB.
+const copyPart = 10000
+
+// CopyTables copies data of the tables with the IDs from src into dst.
+func CopyTables(dst ethdb.KeyValueWriter, src ethdb.Iteratee, ids []byte) error {
+	for _, id := range ids {
+		prefix := []byte{id}
+		start := prefix
+		for {
+			keys, vals, err := readPart(src, prefix, start)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				break
+			}
+
+			for i, key := range keys {
+				if err := dst.Put(key, vals[i]); err != nil {
+					return err
+				}
+			}
+			start = append(keys[len(keys)-1], 0)
+		}
+	}
+	return nil
+}
+
+// DropTables erases data of the tables with the IDs.
+func DropTables(db ethdb.KeyValueStore, ids []byte) error {
+	for _, id := range ids {
+		prefix := []byte{id}
+		for {
+			keys, _, err := readPart(db, prefix, prefix)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				break
+			}
+
+			for _, key := range keys {
+				if err := db.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readPart reads up to copyPart key-value pairs with the prefix, starting from the start key.
+func readPart(src ethdb.Iteratee, prefix, start []byte) (keys, vals [][]byte, err error) {
+	it := src.NewIteratorWithStart(start)
+	defer it.Release()
+
+	for len(keys) < copyPart && it.Next() {
+		if !bytes.HasPrefix(it.Key(), prefix) {
+			break
+		}
+		keys = append(keys, common.CopyBytes(it.Key()))
+		vals = append(vals, common.CopyBytes(it.Value()))
+	}
+	return keys, vals, it.Error()
+}
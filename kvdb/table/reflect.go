@@ -1,26 +1,26 @@
 package table
 
 import (
-	"bytes"
 	"reflect"
 
 	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 // MigrateTables sets target fields to database tables.
+// It panics if the table IDs aren't valid or aren't unique.
 func MigrateTables(s interface{}, db ethdb.KeyValueStore) {
 	value := reflect.ValueOf(s).Elem()
 
-	var keys uniqKeys
-	defer keys.Check()
+	keys := make(uniqKeys)
 
 	for i := 0; i < value.NumField(); i++ {
 		if prefix := value.Type().Field(i).Tag.Get("table"); prefix != "" && prefix != "-" {
 
+			keys.Add(prefix)
+
 			field := value.Field(i)
 			var val reflect.Value
 			if db != nil {
-				keys.Add(prefix)
 				table := New(db, []byte(prefix))
 				val = reflect.ValueOf(table)
 			} else {
@@ -52,28 +52,13 @@ func MigrateCaches(c interface{}, get func() interface{}) {
 	}
 }
 
-type uniqKeys struct {
-	len  int
-	keys [][]byte
-}
+type uniqKeys map[string]struct{}
 
-func (u *uniqKeys) Add(s string) {
-	key := []byte(s)
+func (u uniqKeys) Add(s string) {
+	CheckID([]byte(s))
 
-	if len(u.keys) == 0 || u.len > len(key) {
-		u.len = len(key)
-	}
-	u.keys = append(u.keys, key)
-}
-
-func (u *uniqKeys) Check() {
-	for i := 0; i < len(u.keys); i++ {
-		for j := i + 1; j < len(u.keys); j++ {
-			a := u.keys[i][:u.len]
-			b := u.keys[j][:u.len]
-			if bytes.Equal(a, b) {
-				panic("prefixes '" + string(u.keys[i]) + "' and '" + string(u.keys[j]) + "' are the same")
-			}
-		}
+	if _, ok := u[s]; ok {
+		panic("prefix '" + s + "' is used twice")
 	}
+	u[s] = struct{}{}
 }
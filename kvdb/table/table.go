@@ -2,6 +2,7 @@ package table
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/ethdb"
 )
@@ -12,37 +13,55 @@ type Table struct {
 	prefix []byte
 }
 
-var (
-	// NOTE: key collisions are possible
-	separator = []byte{}
+// Table prefixes are fixed-width IDs, so the set of prefixes is prefix-free:
+// keys of tables with different IDs never collide, whatever the keys are.
+// It holds for nested tables (made by New over another table) as well, since a table is a DB for its sub-tables.
+// Note that Table.NewTable makes a sibling table over the same DB, not a nested one.
+const (
+	// IDLen is the length of table ID.
+	IDLen = 1
+	// ReservedID isn't used by tables, it's for service records of DB wrappers.
+	ReservedID = 0x00
 )
 
-// prefixed key (prefix + separator + key)
+// CheckID panics if prefix isn't a valid table ID.
+func CheckID(prefix []byte) {
+	if len(prefix) != IDLen {
+		panic(fmt.Sprintf("table ID '%s' should be %d byte(s) long", string(prefix), IDLen))
+	}
+	if prefix[0] == ReservedID {
+		panic(fmt.Sprintf("table ID %#x is reserved", prefix[0]))
+	}
+}
+
+// prefixed key (prefix + key)
 func prefixed(key, prefix []byte) []byte {
-	prefixedKey := make([]byte, 0, len(prefix)+len(separator)+len(key))
+	prefixedKey := make([]byte, 0, len(prefix)+len(key))
 	prefixedKey = append(prefixedKey, prefix...)
-	prefixedKey = append(prefixedKey, separator...)
 	prefixedKey = append(prefixedKey, key...)
 	return prefixedKey
 }
 
 func noPrefix(key, prefix []byte) []byte {
-	if len(key) < len(prefix)+len(separator) {
+	if len(key) < len(prefix) {
 		return key
 	}
-	return key[len(prefix)+len(separator):]
+	return key[len(prefix):]
 }
 
 /*
  * Database
  */
 
+// New table with the ID prefix over db.
 func New(db ethdb.KeyValueStore, prefix []byte) *Table {
+	CheckID(prefix)
 	return &Table{db, prefix}
 }
 
+// NewTable with the ID prefix over the same db, i.e. a sibling of the table.
 func (t Table) NewTable(prefix []byte) *Table {
-	return &Table{t.db, prefix}
+	return New(t.db, prefix)
 }
 
 func (t *Table) Close() error {
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/bboltdb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/leveldb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
@@ -36,6 +38,26 @@ func tempLevelDB(name string) *leveldb.Database {
 	return diskdb
 }
 
+func tempBboltDB(name string) *bboltdb.Database {
+	dir, err := ioutil.TempDir("", "flushable-test"+name)
+	if err != nil {
+		panic(fmt.Sprintf("can't create temporary directory: %v", err))
+	}
+
+	drop := func() {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	diskdb, err := bboltdb.New(filepath.Join(dir, "data.db"), nil, drop)
+	if err != nil {
+		panic(fmt.Sprintf("can't create temporary database: %v", err))
+	}
+	return diskdb
+}
+
 func TestTable(t *testing.T) {
 	prefix0 := map[string][]byte{
 		"00": []byte{0},
@@ -57,21 +79,26 @@ func TestTable(t *testing.T) {
 	defer leveldb2.Drop()
 	defer leveldb2.Close()
 
+	bbolt1 := tempBboltDB("3")
+	defer bbolt1.Drop()
+	defer bbolt1.Close()
+
 	for name, db := range map[string]kvdb.KeyValueStore{
 		"memory":                       memorydb.New(),
 		"leveldb":                      leveldb1,
 		"cache-over-leveldb":           flushable.Wrap(leveldb2),
+		"bbolt":                        bbolt1,
 		"cache-over-cache-over-memory": flushable.Wrap(memorydb.New()),
 	} {
 		t.Run(name, func(t *testing.T) {
 			assertar := assert.New(t)
 
 			// tables
-			t1 := New(db, []byte("t1"))
+			t1 := New(db, []byte("1"))
 			tables := map[string]kvdb.KeyValueStore{
 				"/t1":   t1,
 				"/t1/x": t1.NewTable([]byte("x")),
-				"/t2":   New(db, []byte("t2")),
+				"/t2":   New(db, []byte("2")),
 			}
 
 			// write
@@ -124,6 +151,47 @@ func TestTable(t *testing.T) {
 	}
 }
 
+func TestMigrateTables(t *testing.T) {
+	assertar := assert.New(t)
+
+	db := memorydb.New()
+
+	var valid struct {
+		A kvdb.KeyValueStore `table:"a"`
+		B kvdb.KeyValueStore `table:"b"`
+		C kvdb.KeyValueStore `table:"-"`
+	}
+	assertar.NotPanics(func() {
+		MigrateTables(&valid, db)
+	})
+	assertar.NotNil(valid.A)
+	assertar.NotNil(valid.B)
+	assertar.Nil(valid.C)
+
+	var long struct {
+		T  kvdb.KeyValueStore `table:"t"`
+		Tx kvdb.KeyValueStore `table:"tx"`
+	}
+	assertar.Panics(func() {
+		MigrateTables(&long, db)
+	})
+
+	var twice struct {
+		A1 kvdb.KeyValueStore `table:"a"`
+		A2 kvdb.KeyValueStore `table:"a"`
+	}
+	assertar.Panics(func() {
+		MigrateTables(&twice, db)
+	})
+
+	var reserved struct {
+		R kvdb.KeyValueStore `table:"\x00"`
+	}
+	assertar.Panics(func() {
+		MigrateTables(&reserved, db)
+	})
+}
+
 func join(aa ...map[string][]byte) map[string][]byte {
 	res := make(map[string][]byte)
 	for _, a := range aa {
@@ -134,3 +202,51 @@ func join(aa ...map[string][]byte) map[string][]byte {
 
 	return res
 }
+
+func TestCopyTables(t *testing.T) {
+	assertar := assert.New(t)
+
+	src := memorydb.New()
+	data := map[string][]byte{
+		"a1": []byte("1"),
+		"a2": []byte("2"),
+		"b1": []byte("3"),
+		"c1": []byte("4"),
+	}
+	for k, v := range data {
+		assertar.NoError(src.Put([]byte(k), v))
+	}
+
+	// into another DB
+	dst := memorydb.New()
+	assertar.NoError(dst.Put([]byte("a3"), []byte("5")))
+	assertar.NoError(CopyTables(dst, src, []byte("ac")))
+	for _, k := range []string{"a1", "a2", "c1"} {
+		got, err := dst.Get([]byte(k))
+		assertar.NoError(err)
+		assertar.Equal(data[k], got, k)
+	}
+	ok, err := dst.Has([]byte("b1"))
+	assertar.NoError(err)
+	assertar.False(ok)
+
+	// drop
+	assertar.NoError(DropTables(dst, []byte("a")))
+	for _, k := range []string{"a1", "a2", "a3"} {
+		ok, err := dst.Has([]byte(k))
+		assertar.NoError(err)
+		assertar.False(ok, k)
+	}
+	ok, err = dst.Has([]byte("c1"))
+	assertar.NoError(err)
+	assertar.True(ok)
+
+	// into a table of the same DB
+	sub := New(src, []byte("s"))
+	assertar.NoError(CopyTables(sub, src, []byte("ab")))
+	for _, k := range []string{"a1", "a2", "b1"} {
+		got, err := sub.Get([]byte(k))
+		assertar.NoError(err)
+		assertar.Equal(data[k], got, k)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	ethparams "github.com/ethereum/go-ethereum/params"
 
 	"github.com/Fantom-foundation/go-lachesis/inter"
@@ -42,12 +43,40 @@ type DagConfig struct {
 
 	MaxEpochBlocks   idx.Frame     `json:"maxEpochBlocks"`
 	MaxEpochDuration time.Duration `json:"maxEpochDuration"`
+	// EpochSealing is a list of additional epoch sealing rules versions, ordered by FromEpoch
+	EpochSealing []EpochSealingConfig `json:"epochSealing"`
 
 	VectorClockConfig vector.IndexConfig `json:"vectorClockConfig"`
 
 	MaxValidatorEventsInBlock idx.Event `json:"maxValidatorEventsInBlock"`
 }
 
+// EpochSealingConfig is a version of additional epoch sealing rules, which are active since FromEpoch.
+// Zero values disable the rules.
+type EpochSealingConfig struct {
+	FromEpoch idx.Epoch `json:"fromEpoch"`
+
+	// MaxEpochGas seals epoch after the epoch's blocks have used the gas
+	MaxEpochGas uint64 `json:"maxEpochGas"`
+	// OnValidatorsChange seals epoch as soon as the validators set of next epoch differs from the current one
+	OnValidatorsChange bool `json:"onValidatorsChange"`
+	// SignalContract and SignalTopic seal epoch after the contract emits the log with the topic
+	SignalContract *common.Address `json:"signalContract"`
+	SignalTopic    common.Hash     `json:"signalTopic"`
+}
+
+// EpochSealingAt returns the epoch sealing rules version, which is active at the epoch.
+func (c *DagConfig) EpochSealingAt(epoch idx.Epoch) EpochSealingConfig {
+	var active EpochSealingConfig
+	for _, version := range c.EpochSealing {
+		if version.FromEpoch > epoch {
+			break
+		}
+		active = version
+	}
+	return active
+}
+
 // BlocksMissed is information about missed blocks from a staker
 type BlocksMissed struct {
 	BlocksNum idx.Block
@@ -0,0 +1,147 @@
+package sealing
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+)
+
+type (
+	// Block is a decided block with the epoch's data, which the rules are evaluated by.
+	// The app-level data is gathered by the previous blocks of the epoch,
+	// because the rules are evaluated before the block is applied.
+	Block struct {
+		*inter.Block
+		DecidedFrame idx.Frame
+		Cheaters     inter.Cheaters
+		EpochStart   inter.Timestamp
+
+		// EpochGas is a gas used by the previous blocks of the epoch
+		EpochGas uint64
+		// ValidatorsChanged returns true if the validators set of next epoch differs from the current one (optional)
+		ValidatorsChanged func() bool
+		// Signaled is true if the sealing signal is emitted by the previous blocks of the epoch
+		Signaled bool
+	}
+
+	// Rule decides whether the epoch should be sealed by the block.
+	Rule interface {
+		// Name of the rule, for logs
+		Name() string
+		// SealEpoch returns true if epoch should be sealed by the block
+		SealEpoch(b *Block) bool
+	}
+
+	// Rules seal epoch if any of them decides so.
+	Rules []Rule
+
+	rule struct {
+		name string
+		seal func(b *Block) bool
+	}
+)
+
+// Name of the rule.
+func (r *rule) Name() string {
+	return r.name
+}
+
+// SealEpoch returns true if epoch should be sealed by the block.
+func (r *rule) SealEpoch(b *Block) bool {
+	return r.seal(b)
+}
+
+// New rule from the sealing func.
+func New(name string, seal func(b *Block) bool) Rule {
+	return &rule{
+		name: name,
+		seal: seal,
+	}
+}
+
+// MaxBlocks seals epoch when frame of the block reaches the limit.
+func MaxBlocks(limit idx.Frame) Rule {
+	return New("blocks", func(b *Block) bool {
+		return b.DecidedFrame >= limit
+	})
+}
+
+// MaxDuration seals epoch when the block is the limit older than the epoch start.
+func MaxDuration(limit time.Duration) Rule {
+	return New("duration", func(b *Block) bool {
+		return b.Time-b.EpochStart >= inter.Timestamp(limit)
+	})
+}
+
+// Cheaters seals epoch right away if a cheater is confirmed, to prune them from of BFT validators list.
+func Cheaters() Rule {
+	return New("cheaters", func(b *Block) bool {
+		return b.Cheaters.Len() > 0
+	})
+}
+
+// MaxGas seals epoch when the epoch's blocks have used the limit of gas.
+func MaxGas(limit uint64) Rule {
+	return New("gas", func(b *Block) bool {
+		return b.EpochGas >= limit
+	})
+}
+
+// ValidatorsChange seals epoch as soon as the validators set of next epoch is changed.
+func ValidatorsChange() Rule {
+	return New("validators", func(b *Block) bool {
+		return b.ValidatorsChanged != nil && b.ValidatorsChanged()
+	})
+}
+
+// Signal seals epoch after the sealing signal is emitted.
+func Signal() Rule {
+	return New("signal", func(b *Block) bool {
+		return b.Signaled
+	})
+}
+
+// RulesOf returns the rules of the epoch, according to the rules version which is active at the epoch.
+func RulesOf(dag *lachesis.DagConfig, epoch idx.Epoch) Rules {
+	rules := Rules{
+		MaxBlocks(dag.MaxEpochBlocks),
+		MaxDuration(dag.MaxEpochDuration),
+		Cheaters(),
+	}
+
+	cfg := dag.EpochSealingAt(epoch)
+	if cfg.MaxEpochGas != 0 {
+		rules = append(rules, MaxGas(cfg.MaxEpochGas))
+	}
+	if cfg.OnValidatorsChange {
+		rules = append(rules, ValidatorsChange())
+	}
+	if cfg.SignalContract != nil {
+		rules = append(rules, Signal())
+	}
+
+	return rules
+}
+
+// SealEpoch returns the first rule which decides to seal epoch by the block, or nil.
+func (rr Rules) SealEpoch(b *Block) Rule {
+	for _, r := range rr {
+		if r.SealEpoch(b) {
+			return r
+		}
+	}
+	return nil
+}
+
+// IsSignal returns true if the log is a sealing signal, according to the rules version which is active at the epoch.
+func IsSignal(dag *lachesis.DagConfig, epoch idx.Epoch, l *types.Log) bool {
+	cfg := dag.EpochSealingAt(epoch)
+	if cfg.SignalContract == nil || l.Address != *cfg.SignalContract {
+		return false
+	}
+	return len(l.Topics) != 0 && l.Topics[0] == cfg.SignalTopic
+}
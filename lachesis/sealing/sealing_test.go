@@ -0,0 +1,116 @@
+package sealing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/lachesis"
+)
+
+func TestRulesOf(t *testing.T) {
+	assertar := assert.New(t)
+
+	governance := common.HexToAddress("0x1")
+	topic := common.HexToHash("0x2")
+
+	dag := lachesis.FakeNetDagConfig()
+	dag.EpochSealing = []lachesis.EpochSealingConfig{
+		{
+			FromEpoch:   3,
+			MaxEpochGas: 1000,
+		},
+		{
+			FromEpoch:          5,
+			OnValidatorsChange: true,
+			SignalContract:     &governance,
+			SignalTopic:        topic,
+		},
+	}
+
+	names := func(rr Rules) []string {
+		res := make([]string, len(rr))
+		for i, r := range rr {
+			res[i] = r.Name()
+		}
+		return res
+	}
+	assertar.Equal([]string{"blocks", "duration", "cheaters"}, names(RulesOf(&dag, 1)))
+	assertar.Equal([]string{"blocks", "duration", "cheaters", "gas"}, names(RulesOf(&dag, 3)))
+	assertar.Equal([]string{"blocks", "duration", "cheaters", "gas"}, names(RulesOf(&dag, 4)))
+	assertar.Equal([]string{"blocks", "duration", "cheaters", "validators", "signal"}, names(RulesOf(&dag, 5)))
+	assertar.Equal([]string{"blocks", "duration", "cheaters", "validators", "signal"}, names(RulesOf(&dag, 100)))
+
+	start := inter.Timestamp(1000 * time.Second)
+	block := func() *Block {
+		return &Block{
+			Block: &inter.Block{
+				Time: start + 1,
+			},
+			DecidedFrame: 1,
+			EpochStart:   start,
+		}
+	}
+	sealedBy := func(epoch idx.Epoch, b *Block) string {
+		r := RulesOf(&dag, epoch).SealEpoch(b)
+		if r == nil {
+			return ""
+		}
+		return r.Name()
+	}
+
+	for _, epoch := range []idx.Epoch{1, 3, 5} {
+		assertar.Equal("", sealedBy(epoch, block()))
+
+		b := block()
+		b.DecidedFrame = dag.MaxEpochBlocks
+		assertar.Equal("blocks", sealedBy(epoch, b))
+
+		b = block()
+		b.Time = start + inter.Timestamp(dag.MaxEpochDuration)
+		assertar.Equal("duration", sealedBy(epoch, b))
+
+		b = block()
+		b.Cheaters = inter.Cheaters{1}
+		assertar.Equal("cheaters", sealedBy(epoch, b))
+	}
+
+	b := block()
+	b.EpochGas = 1000
+	assertar.Equal("", sealedBy(1, b))
+	assertar.Equal("gas", sealedBy(3, b))
+	assertar.Equal("", sealedBy(5, b))
+
+	b = block()
+	b.ValidatorsChanged = func() bool {
+		return true
+	}
+	assertar.Equal("", sealedBy(3, b))
+	assertar.Equal("validators", sealedBy(5, b))
+
+	b = block()
+	b.Signaled = true
+	assertar.Equal("", sealedBy(3, b))
+	assertar.Equal("signal", sealedBy(5, b))
+
+	// signal
+	signal := &types.Log{
+		Address: governance,
+		Topics:  []common.Hash{topic},
+	}
+	assertar.False(IsSignal(&dag, 3, signal))
+	assertar.True(IsSignal(&dag, 5, signal))
+	assertar.False(IsSignal(&dag, 5, &types.Log{
+		Address: governance,
+		Topics:  []common.Hash{common.HexToHash("0x3")},
+	}))
+	assertar.False(IsSignal(&dag, 5, &types.Log{
+		Address: common.HexToAddress("0x3"),
+		Topics:  []common.Hash{topic},
+	}))
+}
@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -9,7 +10,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var logger = log.New("module", "prometheus")
+var (
+	logger = log.New("module", "prometheus")
+
+	// collected metrics names
+	collected sync.Map
+)
 
 // ListenTo serves prometheus connections.
 func ListenTo(endpoint string, reg metrics.Registry) {
@@ -22,8 +28,12 @@ func ListenTo(endpoint string, reg metrics.Registry) {
 		logger.Info("metrics server starts", "endpoint", endpoint)
 		defer logger.Info("metrics server is stopped")
 
-		http.HandleFunc(
-			"/metrics", promhttp.Handler().ServeHTTP)
+		handler := promhttp.Handler()
+		http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			// collect metrics which are registered after start (e.g. per DB table ones)
+			reg.Each(collect)
+			handler.ServeHTTP(w, r)
+		})
 		err := http.ListenAndServe(endpoint, nil)
 		if err != nil {
 			logger.Info("metrics server", "err", err)
@@ -34,6 +44,9 @@ func ListenTo(endpoint string, reg metrics.Registry) {
 }
 
 func collect(name string, metric interface{}) {
+	if _, loaded := collected.LoadOrStore(name, struct{}{}); loaded {
+		return
+	}
 	logger.Info("metric to prometheus", "metric", name)
 
 	collector, ok := convertToPrometheusMetric(name, metric)
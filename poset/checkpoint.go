@@ -44,10 +44,14 @@ func (p *Poset) Bootstrap(callback inter.ConsensusCallbacks) {
 
 	// restore current epoch
 	p.loadEpoch()
-	p.vecClock = vector.NewIndex(p.dag.VectorClockConfig, p.Validators, p.store.epochTable.VectorIndex, func(id hash.Event) *inter.EventHeaderData {
+	vecCfg := p.dag.VectorClockConfig
+	if p.store.cfg.Cache != nil {
+		vecCfg.Cache = p.store.cfg.Cache
+	}
+	p.vecClock = vector.NewIndex(vecCfg, p.Validators, p.store.epochTable.VectorIndex, func(id hash.Event) *inter.EventHeaderData {
 		return p.input.GetEventHeader(p.EpochN, id)
 	})
-	p.election = election.New(p.Validators, p.LastDecidedFrame+1, p.vecClock.ForklessCause, p.store.GetFrameRoots)
+	p.election = election.New(p.Validators, p.LastDecidedFrame+1, p.vecClock.ForklessCauseMany, p.store.GetFrameRoots)
 
 	// events reprocessing
 	p.handleElection(nil)
@@ -1,21 +1,28 @@
 package poset
 
+import (
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
+)
+
 // StoreConfig is a config for store db.
 type StoreConfig struct {
+	// Cache is a memory budget shared by the store and vector index caches, it's injected by the caller.
+	// If nil, nothing is cached.
+	Cache *sharedcache.Pool `toml:"-"`
+
+	// NOTE: fields for config-file back compatibility
 	// Cache size for Roots.
 	Roots int
 }
 
 // DefaultStoreConfig for product.
 func DefaultStoreConfig() StoreConfig {
-	return StoreConfig{
-		Roots: 20,
-	}
+	return StoreConfig{}
 }
 
 // LiteStoreConfig is for tests or inmemory.
 func LiteStoreConfig() StoreConfig {
 	return StoreConfig{
-		Roots: 5,
+		Cache: sharedcache.TestPool(),
 	}
 }
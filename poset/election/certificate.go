@@ -0,0 +1,187 @@
+package election
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+)
+
+type (
+	// Certificate is a compact record of the Atropos decision, i.e. which roots have decided it.
+	// It isn't a proof of the decision, see SignedDecisionRecord.
+	// Subjects are decisions about the root slots of frame in the order of validators.SortedIDs().
+	// All the subjects except the last one are decided "no", and the last one is decided "yes" for Atropos.
+	Certificate struct {
+		Frame    idx.Frame
+		Atropos  hash.Event
+		Subjects []Decision
+	}
+
+	// Decision is an evidence of the final vote for a validator's root slot.
+	Decision struct {
+		Validator idx.StakerID
+		Yes       bool
+		// Decider is the root which has decided the vote.
+		Decider RootAndSlot
+		// Voters are the roots of the previous to Decider frame, which are forkless caused by Decider
+		// and which voted the same way. Their stake is a quorum.
+		Voters []RootAndSlot
+	}
+
+	// SignedDecisionRecord is a certificate with the signed headers of Atropos, deciders and voters.
+	// It's NOT a proof of finality, and must not be served as one (e.g. to a bridge): the signatures prove
+	// only that the roots exist, while the forkless cause relations between deciders and voters, which make
+	// the decision, can't be checked without the events between them. So the record is as trusted as its source.
+	SignedDecisionRecord struct {
+		Certificate
+		Headers []inter.EventHeader
+	}
+)
+
+var (
+	// ErrCertNotDecided is returned if certificate has no decision for Atropos.
+	ErrCertNotDecided = errors.New("certificate has no 'yes' decision")
+	// ErrCertWrongOrder is returned if decisions aren't in the order of validators.
+	ErrCertWrongOrder = errors.New("certificate decisions aren't in the order of validators")
+	// ErrCertNoQuorum is returned if voters of a decision have no quorum.
+	ErrCertNoQuorum = errors.New("certificate decision has no quorum")
+	// ErrCertWrongEpoch is returned if certificate roots are from different epochs.
+	ErrCertWrongEpoch = errors.New("certificate roots are from different epochs")
+	// ErrCertNoHeader is returned if a signed header of a certificate root is missing.
+	ErrCertNoHeader = errors.New("certificate root has no signed header")
+	// ErrCertWrongSig is returned if a header of a certificate root isn't signed by its creator.
+	ErrCertWrongSig = errors.New("certificate root has wrong signature")
+)
+
+// Epoch of the Atropos.
+func (c *Certificate) Epoch() idx.Epoch {
+	return c.Atropos.Epoch()
+}
+
+// Roots returns Atropos and all the deciders and voters of certificate, without duplicates.
+func (c *Certificate) Roots() hash.Events {
+	set := hash.EventsSet{}
+	roots := hash.Events{c.Atropos}
+	set.Add(c.Atropos)
+	for _, d := range c.Subjects {
+		for _, r := range append([]RootAndSlot{d.Decider}, d.Voters...) {
+			if !set.Contains(r.ID) {
+				set.Add(r.ID)
+				roots = append(roots, r.ID)
+			}
+		}
+	}
+	return roots
+}
+
+// Check checks that certificate is consistent and its decisions are claimed by a quorum of validators.
+// Validators must be of the certificate epoch, they should be taken from a trusted source.
+// The forkless cause relations between Decider and Voters can't be checked without the events.
+func (c *Certificate) Check(validators *pos.Validators) error {
+	epoch := c.Epoch()
+	sorted := validators.SortedIDs()
+	if len(c.Subjects) == 0 || len(c.Subjects) > len(sorted) {
+		return ErrCertNotDecided
+	}
+
+	for i, d := range c.Subjects {
+		if d.Validator != sorted[i] {
+			return ErrCertWrongOrder
+		}
+		last := i == len(c.Subjects)-1
+		if d.Yes != last {
+			return ErrCertNotDecided
+		}
+
+		if d.Decider.ID.Epoch() != epoch {
+			return ErrCertWrongEpoch
+		}
+		if !validators.Exists(d.Decider.Slot.Validator) {
+			return fmt.Errorf("decider %s of validator %d isn't a validator", d.Decider.ID.String(), d.Validator)
+		}
+		// decisions are made not earlier than in the second round
+		if d.Decider.Slot.Frame < c.Frame+2 {
+			return fmt.Errorf("decider %s of validator %d is at frame %d, election frame=%d",
+				d.Decider.ID.String(), d.Validator, d.Decider.Slot.Frame, c.Frame)
+		}
+
+		votes := validators.NewCounter()
+		for _, voter := range d.Voters {
+			if voter.ID.Epoch() != epoch {
+				return ErrCertWrongEpoch
+			}
+			if voter.Slot.Frame != d.Decider.Slot.Frame-1 {
+				return fmt.Errorf("voter %s of validator %d is at frame %d, decider frame=%d",
+					voter.ID.String(), d.Validator, voter.Slot.Frame, d.Decider.Slot.Frame)
+			}
+			if !validators.Exists(voter.Slot.Validator) {
+				return fmt.Errorf("voter %s of validator %d isn't a validator", voter.ID.String(), d.Validator)
+			}
+			if !votes.Count(voter.Slot.Validator) {
+				return fmt.Errorf("voter %d of validator %d is counted twice", voter.Slot.Validator, d.Validator)
+			}
+		}
+		if !votes.HasQuorum() {
+			return ErrCertNoQuorum
+		}
+	}
+
+	return nil
+}
+
+// Check checks the certificate, and that its roots are signed by their creators.
+// Validators and their addresses must be of the certificate epoch, they should be taken from a trusted source.
+// Passed check doesn't prove the decision, as the forkless cause relations between Decider and Voters
+// still can't be checked without the events.
+func (c *SignedDecisionRecord) Check(validators *pos.Validators, addresses map[idx.StakerID]common.Address) error {
+	err := c.Certificate.Check(validators)
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[hash.Event]*inter.EventHeader, len(c.Headers))
+	for i := range c.Headers {
+		h := &c.Headers[i]
+		headers[h.CalcHash()] = h
+	}
+	verify := func(r RootAndSlot) error {
+		h := headers[r.ID]
+		if h == nil {
+			return ErrCertNoHeader
+		}
+		if h.Creator != r.Slot.Validator || h.Frame != r.Slot.Frame || !h.IsRoot {
+			return fmt.Errorf("header of root %s doesn't match its slot", r.ID.String())
+		}
+		addr, ok := addresses[h.Creator]
+		if !ok || !h.VerifySignature(addr) {
+			return ErrCertWrongSig
+		}
+		return nil
+	}
+
+	last := c.Subjects[len(c.Subjects)-1]
+	err = verify(RootAndSlot{
+		ID: c.Atropos,
+		Slot: Slot{
+			Frame:     c.Frame,
+			Validator: last.Validator,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range c.Subjects {
+		for _, r := range append([]RootAndSlot{d.Decider}, d.Voters...) {
+			if err := verify(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package election
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+)
+
+func fakeCertificate(validators *pos.Validators) *Certificate {
+	root := func(f idx.Frame, v idx.StakerID) RootAndSlot {
+		return RootAndSlot{
+			ID: hash.FakeEvent(),
+			Slot: Slot{
+				Frame:     f,
+				Validator: v,
+			},
+		}
+	}
+
+	sorted := validators.SortedIDs()
+	cert := &Certificate{
+		Frame:   1,
+		Atropos: hash.FakeEvent(),
+	}
+	for i, subject := range sorted[:2] {
+		d := Decision{
+			Validator: subject,
+			Yes:       i == 1,
+			Decider:   root(4, sorted[0]),
+		}
+		for _, voter := range sorted[:3] {
+			d.Voters = append(d.Voters, root(3, voter))
+		}
+		cert.Subjects = append(cert.Subjects, d)
+	}
+	return cert
+}
+
+func TestCertificateVerify(t *testing.T) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	validators := pos.EqualStakeValidators([]idx.StakerID{1, 2, 3, 4}, 1)
+
+	cert := fakeCertificate(validators)
+	require.NoError(cert.Check(validators))
+
+	// encoding
+	raw, err := rlp.EncodeToBytes(cert)
+	require.NoError(err)
+	decoded := &Certificate{}
+	require.NoError(rlp.DecodeBytes(raw, decoded))
+	assertar.Equal(cert, decoded)
+	assertar.NoError(decoded.Check(validators))
+
+	// tampered certificates
+	for name, tamper := range map[string]func(c *Certificate){
+		"no quorum": func(c *Certificate) {
+			c.Subjects[1].Voters = c.Subjects[1].Voters[:2]
+		},
+		"double vote": func(c *Certificate) {
+			c.Subjects[1].Voters[2].Slot.Validator = c.Subjects[1].Voters[0].Slot.Validator
+		},
+		"not validator": func(c *Certificate) {
+			c.Subjects[0].Voters[0].Slot.Validator = 5
+		},
+		"wrong order": func(c *Certificate) {
+			c.Subjects[0], c.Subjects[1] = c.Subjects[1], c.Subjects[0]
+		},
+		"no atropos": func(c *Certificate) {
+			c.Subjects[1].Yes = false
+		},
+		"first round": func(c *Certificate) {
+			c.Subjects[0].Decider.Slot.Frame = 2
+		},
+		"voter frame": func(c *Certificate) {
+			c.Subjects[0].Voters[1].Slot.Frame = 2
+		},
+		"wrong epoch": func(c *Certificate) {
+			c.Subjects[1].Decider.ID[0]++
+		},
+		"empty": func(c *Certificate) {
+			c.Subjects = nil
+		},
+	} {
+		cert := fakeCertificate(validators)
+		tamper(cert)
+		assertar.Error(cert.Check(validators), name)
+	}
+
+	// other validators
+	other := pos.EqualStakeValidators([]idx.StakerID{1, 2, 3, 4, 5, 6}, 1)
+	assertar.Equal(ErrCertNoQuorum, cert.Check(other))
+}
+
+func signedRecord(t *testing.T, validators *pos.Validators, keys map[idx.StakerID]*ecdsa.PrivateKey) *SignedDecisionRecord {
+	cert := &SignedDecisionRecord{
+		Certificate: *fakeCertificate(validators),
+	}
+	signed := map[hash.Event]hash.Event{}
+	sign := func(id hash.Event, slot Slot) hash.Event {
+		if res, ok := signed[id]; ok {
+			return res
+		}
+		e := inter.NewEvent()
+		e.Epoch = 1
+		e.Lamport = idx.Lamport(len(signed) + 1)
+		e.Creator = slot.Validator
+		e.Frame = slot.Frame
+		e.IsRoot = true
+		e.Parents = hash.Events{hash.FakeEvent()}
+		require.NoError(t, e.SignBy(keys[slot.Validator]))
+		signed[id] = e.Hash()
+		cert.Headers = append(cert.Headers, e.EventHeader)
+		return e.Hash()
+	}
+
+	last := cert.Subjects[len(cert.Subjects)-1].Validator
+	cert.Atropos = sign(cert.Atropos, Slot{Frame: cert.Frame, Validator: last})
+	for i := range cert.Subjects {
+		d := &cert.Subjects[i]
+		d.Decider.ID = sign(d.Decider.ID, d.Decider.Slot)
+		for j := range d.Voters {
+			d.Voters[j].ID = sign(d.Voters[j].ID, d.Voters[j].Slot)
+		}
+	}
+	return cert
+}
+
+func TestSignedDecisionRecordCheck(t *testing.T) {
+	require := require.New(t)
+	assertar := assert.New(t)
+
+	validators := pos.EqualStakeValidators([]idx.StakerID{1, 2, 3, 4}, 1)
+	keys := map[idx.StakerID]*ecdsa.PrivateKey{}
+	addresses := map[idx.StakerID]common.Address{}
+	for _, id := range validators.IDs() {
+		key, err := crypto.GenerateKey()
+		require.NoError(err)
+		keys[id] = key
+		addresses[id] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+
+	cert := signedRecord(t, validators, keys)
+	require.NoError(cert.Check(validators, addresses))
+	assertar.Equal(len(cert.Headers), len(cert.Roots()))
+
+	// encoding
+	raw, err := rlp.EncodeToBytes(cert)
+	require.NoError(err)
+	decoded := &SignedDecisionRecord{}
+	require.NoError(rlp.DecodeBytes(raw, decoded))
+	assertar.NoError(decoded.Check(validators, addresses))
+
+	// tampered certificates
+	for name, tamper := range map[string]func(c *SignedDecisionRecord){
+		"no header": func(c *SignedDecisionRecord) {
+			c.Headers = c.Headers[1:]
+		},
+		"unsigned root": func(c *SignedDecisionRecord) {
+			c.Subjects[1].Voters[2].ID = hash.FakeEvent()
+		},
+		"wrong sig": func(c *SignedDecisionRecord) {
+			c.Headers[1].Sig = c.Headers[2].Sig
+		},
+		"wrong slot": func(c *SignedDecisionRecord) {
+			c.Subjects[0].Voters[0].Slot.Validator, c.Subjects[0].Voters[1].Slot.Validator =
+				c.Subjects[0].Voters[1].Slot.Validator, c.Subjects[0].Voters[0].Slot.Validator
+		},
+		"no quorum": func(c *SignedDecisionRecord) {
+			c.Subjects[1].Voters = c.Subjects[1].Voters[:2]
+		},
+	} {
+		cert := signedRecord(t, validators, keys)
+		tamper(cert)
+		assertar.Error(cert.Check(validators, addresses), name)
+	}
+
+	// other addresses
+	other := map[idx.StakerID]common.Address{}
+	for id, addr := range addresses {
+		other[id+1] = addr
+	}
+	assertar.Equal(ErrCertWrongSig, cert.Check(validators, other))
+}
@@ -17,6 +17,7 @@ type (
 
 		// election state
 		decidedRoots map[idx.StakerID]voteValue // decided roots at "frameToDecide"
+		decisions    map[idx.StakerID]Decision  // evidences of decidedRoots
 		votes        map[voteID]voteValue
 
 		// external world
@@ -26,8 +27,8 @@ type (
 		logger.Instance
 	}
 
-	// ForklessCauseFn returns true if event A is forkless caused by event B
-	ForklessCauseFn func(a hash.Event, b hash.Event) bool
+	// ForklessCauseFn returns true for each event B, which is forkless caused by event A
+	ForklessCauseFn func(a hash.Event, bs hash.Events) []bool
 	// GetFrameRootsFn returns all the roots in the specified frame
 	GetFrameRootsFn func(f idx.Frame) []RootAndSlot
 
@@ -59,6 +60,9 @@ type voteValue struct {
 type Res struct {
 	Frame   idx.Frame
 	Atropos hash.Event
+
+	// Certificate of the decision, it's always set by Election.ProcessRoot
+	Certificate *Certificate
 }
 
 // New election context
@@ -86,6 +90,7 @@ func (el *Election) Reset(validators *pos.Validators, frameToDecide idx.Frame) {
 	el.frameToDecide = frameToDecide
 	el.votes = make(map[voteID]voteValue)
 	el.decidedRoots = make(map[idx.StakerID]voteValue)
+	el.decisions = make(map[idx.StakerID]Decision)
 }
 
 // return root slots which are not within el.decidedRoots
@@ -107,9 +112,9 @@ func (el *Election) notDecidedRoots() []idx.StakerID {
 func (el *Election) observedRoots(root hash.Event, frame idx.Frame) []RootAndSlot {
 	observedRoots := make([]RootAndSlot, 0, el.validators.Len())
 
-	frameRoots := el.getFrameRoots(frame)
-	for _, frameRoot := range frameRoots {
-		if el.observe(root, frameRoot.ID) {
+	frameRoots, observed := el.observeFrameRoots(root, frame)
+	for i, frameRoot := range frameRoots {
+		if observed[i] {
 			observedRoots = append(observedRoots, frameRoot)
 		}
 	}
@@ -119,11 +124,21 @@ func (el *Election) observedRoots(root hash.Event, frame idx.Frame) []RootAndSlo
 func (el *Election) observedRootsMap(root hash.Event, frame idx.Frame) map[idx.StakerID]RootAndSlot {
 	observedRootsMap := make(map[idx.StakerID]RootAndSlot, el.validators.Len())
 
-	frameRoots := el.getFrameRoots(frame)
-	for _, frameRoot := range frameRoots {
-		if el.observe(root, frameRoot.ID) {
+	frameRoots, observed := el.observeFrameRoots(root, frame)
+	for i, frameRoot := range frameRoots {
+		if observed[i] {
 			observedRootsMap[frameRoot.Slot.Validator] = frameRoot
 		}
 	}
 	return observedRootsMap
 }
+
+// observeFrameRoots returns all the roots at the specified frame, and whether each of them is forkless caused by the specified root.
+func (el *Election) observeFrameRoots(root hash.Event, frame idx.Frame) ([]RootAndSlot, []bool) {
+	frameRoots := el.getFrameRoots(frame)
+	ids := make(hash.Events, len(frameRoots))
+	for i, frameRoot := range frameRoots {
+		ids[i] = frameRoot.ID
+	}
+	return frameRoots, el.observe(root, ids)
+}
@@ -50,6 +50,9 @@ func (el *Election) ProcessRoot(newRoot RootAndSlot) (*Res, error) {
 				yesVotes = el.validators.NewCounter()
 				noVotes  = el.validators.NewCounter()
 				allVotes = el.validators.NewCounter()
+
+				yesVoters = make([]RootAndSlot, 0, len(observedRoots))
+				noVoters  = make([]RootAndSlot, 0, len(observedRoots))
 			)
 
 			// calc number of "yes" and "no", weighted by validator's stake
@@ -69,8 +72,10 @@ func (el *Election) ProcessRoot(newRoot RootAndSlot) (*Res, error) {
 					if vote.yes {
 						subjectHash = &vote.observedRoot
 						yesVotes.Count(observedRoot.Slot.Validator)
+						yesVoters = append(yesVoters, observedRoot)
 					} else {
 						noVotes.Count(observedRoot.Slot.Validator)
+						noVoters = append(noVoters, observedRoot)
 					}
 					if !allVotes.Count(observedRoot.Slot.Validator) {
 						// it shouldn't be possible to get here, because we've taken 1 root from every node above
@@ -100,6 +105,16 @@ func (el *Election) ProcessRoot(newRoot RootAndSlot) (*Res, error) {
 			vote.decided = yesVotes.HasQuorum() || noVotes.HasQuorum()
 			if vote.decided {
 				el.decidedRoots[validatorSubject] = vote
+				voters := noVoters
+				if yesVotes.HasQuorum() {
+					voters = yesVoters
+				}
+				el.decisions[validatorSubject] = Decision{
+					Validator: validatorSubject,
+					Yes:       vote.yes,
+					Decider:   newRoot,
+					Voters:    voters,
+				}
 			}
 		}
 		// save vote for next rounds
@@ -245,12 +245,16 @@ func testProcessRoot(
 	}
 	validators := validatorsBuilder.Build()
 
-	forklessCauseFn := func(a hash.Event, b hash.Event) bool {
-		edge := fakeEdge{
-			from: a,
-			to:   b,
+	forklessCauseFn := func(a hash.Event, bs hash.Events) []bool {
+		res := make([]bool, len(bs))
+		for i, b := range bs {
+			edge := fakeEdge{
+				from: a,
+				to:   b,
+			}
+			res[i] = edges[edge]
 		}
-		return edges[edge]
+		return res
 	}
 	getFrameRootsFn := func(f idx.Frame) []RootAndSlot {
 		return frameRoots[f]
@@ -287,6 +291,9 @@ func testProcessRoot(
 			assertar.NotNil(got)
 			assertar.Equal(expected.DecidedFrame, got.Frame)
 			assertar.Equal(expected.DecidedAtropos, got.Atropos.String())
+			assertar.Equal(got.Frame, got.Certificate.Frame)
+			assertar.Equal(got.Atropos, got.Certificate.Atropos)
+			assertar.NoError(got.Certificate.Check(validators))
 			alreadyDecided = true
 		} else {
 			assertar.Nil(got)
@@ -9,15 +9,25 @@ import (
 // Other validators will come to the same Atropos not later than current highest frame + 2.
 func (el *Election) chooseAtropos() (*Res, error) {
 	// iterate until Yes root is met, which will be Atropos. I.e. not necessarily all the roots must be decided
-	for _, validator := range el.validators.SortedIDs() {
+	sorted := el.validators.SortedIDs()
+	for i, validator := range sorted {
 		vote, ok := el.decidedRoots[validator]
 		if !ok {
 			return nil, nil // not decided
 		}
 		if vote.yes {
+			cert := &Certificate{
+				Frame:    el.frameToDecide,
+				Atropos:  vote.observedRoot,
+				Subjects: make([]Decision, 0, i+1),
+			}
+			for _, subject := range sorted[:i+1] {
+				cert.Subjects = append(cert.Subjects, el.decisions[subject])
+			}
 			return &Res{
-				Frame:   el.frameToDecide,
-				Atropos: vote.observedRoot,
+				Frame:       el.frameToDecide,
+				Atropos:     vote.observedRoot,
+				Certificate: cert,
 			}, nil
 		}
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
 )
 
 func (p *Poset) confirmEvents(frame idx.Frame, atropos hash.Event, onEventConfirmed func(*inter.EventHeaderData)) {
@@ -79,6 +80,9 @@ func (p *Poset) confirmBlock(frame idx.Frame, atropos hash.Event) (block *inter.
 	})
 
 	p.Log.Debug("Confirmed events by", "atropos", atropos.String(), "events", confirmedNum, "blocksEvents", len(blockEvents))
+	atroposConfirmedHistogram.Update(int64(confirmedNum))
+	blockEventsHistogram.Update(int64(len(blockEvents)))
+	blockLamportSpanHistogram.Update(int64(highestLamport - lowestLamport))
 
 	// ordering
 	orderedBlockEvents := p.fareOrdering(blockEvents)
@@ -92,11 +96,12 @@ func (p *Poset) confirmBlock(frame idx.Frame, atropos hash.Event) (block *inter.
 
 // onFrameDecided moves LastDecidedFrameN to frame.
 // It includes: moving current decided frame, txs ordering and execution, epoch sealing.
-func (p *Poset) onFrameDecided(frame idx.Frame, atropos hash.Event) bool {
+func (p *Poset) onFrameDecided(frame idx.Frame, atropos hash.Event, cert *election.Certificate) bool {
 	p.Log.Debug("consensus: event is atropos", "event", atropos.String())
 
 	p.election.Reset(p.Validators, frame+1)
 	p.Checkpoint.LastDecidedFrame = frame
+	p.markFrameDecided(frame, cert)
 
 	block, cheaters := p.confirmBlock(frame, atropos)
 
@@ -104,6 +109,7 @@ func (p *Poset) onFrameDecided(frame idx.Frame, atropos hash.Event) bool {
 	var sealEpoch bool
 	var appHash common.Hash
 	p.Checkpoint.LastBlockN++
+	p.store.SetBlockCertificate(p.Checkpoint.LastBlockN, cert)
 	if p.callback.ApplyBlock != nil {
 		appHash, sealEpoch = p.callback.ApplyBlock(block, frame, cheaters)
 		p.Checkpoint.AppHash = hash.Of(p.Checkpoint.AppHash.Bytes(), appHash.Bytes())
@@ -117,6 +123,24 @@ func (p *Poset) onFrameDecided(frame idx.Frame, atropos hash.Event) bool {
 	return sealEpoch
 }
 
+// markFrameDecided updates the metrics of the decided frame.
+func (p *Poset) markFrameDecided(frame idx.Frame, cert *election.Certificate) {
+	framesDecidedMeter.Mark(1)
+	frameRootsHistogram.Update(int64(len(p.store.GetFrameRoots(frame))))
+
+	if cert == nil {
+		return
+	}
+	// the election is decided by the highest deciding root
+	var rounds idx.Frame
+	for _, d := range cert.Subjects {
+		if d.Decider.Slot.Frame > frame && d.Decider.Slot.Frame-frame > rounds {
+			rounds = d.Decider.Slot.Frame - frame
+		}
+	}
+	electionRoundsHistogram.Update(int64(rounds))
+}
+
 func (p *Poset) sealEpoch() {
 	// new PrevEpoch state
 	p.PrevEpoch.Time = p.frameConsensusTime(p.LastDecidedFrame)
@@ -139,10 +163,21 @@ func (p *Poset) sealEpoch() {
 	// reset internal epoch DB
 	p.store.RecreateEpochDb(p.EpochN)
 
-	// reset election & vectorindex to new epoch db
+	// reset election & vectorindex to new epoch db, the caches of prev epoch are dropped
 	p.vecClock.Reset(p.Validators, p.store.epochTable.VectorIndex, func(id hash.Event) *inter.EventHeaderData {
 		return p.input.GetEventHeader(p.EpochN, id)
 	})
 	p.election.Reset(p.Validators, firstFrame)
+	p.precalcs.Purge()
+
+}
+
+// GetBlockCertificate returns certificate of the block Atropos decision, or nil if block isn't found.
+func (p *Poset) GetBlockCertificate(n idx.Block) *election.Certificate {
+	return p.store.GetBlockCertificate(n)
+}
 
+// GetEventConfirmedOn returns frame which event is confirmed on, or 0 if event isn't confirmed.
+func (p *Poset) GetEventConfirmedOn(id hash.Event) idx.Frame {
+	return p.store.GetEventConfirmedOn(id)
 }
@@ -13,4 +13,22 @@ var (
 
 	// event to block cache capacity.
 	event2BlockCacheCap = metrics.NewRegisteredGauge("poset/event_2_block_cache_cap", nil)
+
+	// decided frames rate.
+	framesDecidedMeter = metrics.NewRegisteredMeter("poset/frames_decided", nil)
+
+	// number of roots in a decided frame.
+	frameRootsHistogram = metrics.NewRegisteredHistogram("poset/frame_roots", nil, metrics.NewUniformSample(500))
+
+	// number of frames between a decided frame and the frame of the deciding roots.
+	electionRoundsHistogram = metrics.NewRegisteredHistogram("poset/election_rounds", nil, metrics.NewUniformSample(500))
+
+	// number of events in a block.
+	blockEventsHistogram = metrics.NewRegisteredHistogram("poset/block_events", nil, metrics.NewUniformSample(500))
+
+	// number of events confirmed by an Atropos, including the events which aren't included into block.
+	atroposConfirmedHistogram = metrics.NewRegisteredHistogram("poset/atropos_confirmed_events", nil, metrics.NewUniformSample(500))
+
+	// difference between highest and lowest Lamport times of the events confirmed by a block.
+	blockLamportSpanHistogram = metrics.NewRegisteredHistogram("poset/block_lamport_span", nil, metrics.NewUniformSample(500))
 )
@@ -0,0 +1,128 @@
+package poset
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pipeline"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+// TestPosetPipeline checks that events processed by pipeline give the same consensus as serially processed.
+func TestPosetPipeline(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	nodes := inter.GenNodes(10)
+	ordered := genPosetEvents(nodes, 30, 5, rand.New(rand.NewSource(0)))
+
+	posets := make([]*ExtendedPoset, 0, 3)
+	for i := 0; i < cap(posets); i++ {
+		poset, _, input := FakePoset("", nodes)
+		posets = append(posets, poset)
+
+		// shuffle events, pipeline has to order them
+		unordered := make(inter.Events, len(ordered))
+		for k, j := range rand.Perm(len(ordered)) {
+			unordered[j] = ordered[k]
+		}
+
+		var workers *pipeline.Workers
+		if i != 0 {
+			workers = pipeline.NewWorkers(i * 2)
+		}
+		incomplete := pipeline.Run(workers, unordered, pipelineCallback(poset, input, func(err error) {
+			assertar.NoError(err)
+		}))
+		assertar.Empty(incomplete)
+		if workers != nil {
+			workers.Stop()
+		}
+	}
+
+	t.Run("Check consensus", func(t *testing.T) {
+		compareResults(t, posets)
+	})
+	assertar.NotEmpty(posets[0].blocks)
+}
+
+func BenchmarkPosetPipeline(b *testing.B) {
+	logger.SetTestMode(b)
+
+	for _, validators := range []int{100, 150} {
+		nodes := inter.GenNodes(validators)
+		events := genPosetEvents(nodes, 10, 10, rand.New(rand.NewSource(0)))
+
+		for _, n := range []int{0, runtime.NumCPU()} {
+			var workers *pipeline.Workers
+			if n != 0 {
+				workers = pipeline.NewWorkers(n)
+			}
+			b.Run(fmt.Sprintf("validators=%d,workers=%d", validators, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					poset, _, input := FakePoset("", nodes)
+					b.StartTimer()
+
+					pipeline.Run(workers, events, pipelineCallback(poset, input, func(err error) {
+						b.Fatal(err) // Process is called by the benchmark goroutine
+					}))
+				}
+				b.ReportMetric(float64(len(events)), "events/op")
+			})
+			if workers != nil {
+				workers.Stop()
+			}
+		}
+	}
+}
+
+// genPosetEvents generates valid events of 1st epoch, ordered by parents.
+func genPosetEvents(nodes []idx.StakerID, count, parents int, r *rand.Rand) inter.Events {
+	generator, _, input := FakePoset("", nodes)
+
+	var ordered inter.Events
+	_ = inter.ForEachRandEvent(nodes, count, parents, r, inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			ordered = append(ordered, e)
+
+			input.SetEvent(e)
+			if err := generator.ProcessEvent(e); err != nil {
+				panic(err)
+			}
+			if err := flushDb(generator, e.Hash()); err != nil {
+				panic(err)
+			}
+		},
+		Build: func(e *inter.Event, name string) *inter.Event {
+			e.Epoch = 1
+			return generator.Prepare(e)
+		},
+	})
+	return ordered
+}
+
+func pipelineCallback(p *ExtendedPoset, input *EventStore, onError func(error)) pipeline.Callback {
+	return pipeline.Callback{
+		Precalc: p.PrecalcEvent,
+		Process: func(e *inter.Event) error {
+			input.SetEvent(e)
+			err := p.ProcessEvent(e)
+			if err == nil {
+				err = flushDb(p, e.Hash())
+			}
+			if err != nil {
+				onError(err)
+			}
+			return err
+		},
+		Exists: input.HasEvent,
+	}
+}
@@ -1,6 +1,9 @@
 package poset
 
 import (
+	"sync"
+
+	"github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
 
 	"github.com/Fantom-foundation/go-lachesis/eventcheck/epochcheck"
@@ -38,6 +41,9 @@ type Poset struct {
 
 	epochMu utils.SpinLock // protects p.Validators and p.EpochN
 
+	dagMu    sync.RWMutex // protects DAG indexes from PrecalcEvent while event is processed
+	precalcs *lru.Cache   // event hash -> *precalcEvent
+
 	logger.Instance
 }
 
@@ -49,6 +55,8 @@ func New(dag lachesis.DagConfig, store *Store, input EventSource) *Poset {
 		store: store,
 		input: input,
 
+		precalcs: newPrecalcsCache(),
+
 		Instance: logger.MakeInstance(),
 	}
 
@@ -68,6 +76,9 @@ func (p *Poset) LastBlock() (idx.Block, hash.Event) {
 // Prepare fills consensus-related fields: Frame, IsRoot, MedianTimestamp, PrevEpochHash
 // returns nil if event should be dropped
 func (p *Poset) Prepare(e *inter.Event) *inter.Event {
+	p.dagMu.Lock()
+	defer p.dagMu.Unlock()
+
 	if err := epochcheck.New(&p.dag, p).Validate(e); err != nil {
 		p.Log.Error("Event prepare error", "err", err, "event", e)
 		return nil
@@ -88,7 +99,8 @@ func (p *Poset) Prepare(e *inter.Event) *inter.Event {
 }
 
 // checks consensus-related fields: Frame, IsRoot, MedianTimestamp, PrevEpochHash
-func (p *Poset) checkAndSaveEvent(e *inter.Event) error {
+// pre is an optional precalculated data of the event.
+func (p *Poset) checkAndSaveEvent(e *inter.Event, pre *precalcEvent) error {
 	if e.Seq <= 1 && e.PrevEpochHash != p.PrevEpoch.Hash() {
 		return ErrWrongEpochHash
 	}
@@ -101,11 +113,22 @@ func (p *Poset) checkAndSaveEvent(e *inter.Event) error {
 		return ErrCheatersObserved
 	}
 
-	p.vecClock.Add(&e.EventHeaderData)
+	precalcActual := false
+	if pre != nil {
+		precalcActual = p.vecClock.AddPrecalc(&e.EventHeaderData, pre.vecs)
+	} else {
+		p.vecClock.Add(&e.EventHeaderData)
+	}
 	defer p.vecClock.DropNotFlushed()
 
 	// check frame & isRoot
-	frameIdx, isRoot := p.calcFrameIdx(e, true)
+	var frameIdx idx.Frame
+	var isRoot bool
+	if precalcActual {
+		frameIdx, isRoot = pre.frame, pre.isRoot
+	} else {
+		frameIdx, isRoot = p.calcFrameIdx(e, true)
+	}
 	if e.IsRoot != isRoot {
 		return ErrWrongIsRoot
 	}
@@ -124,6 +147,12 @@ func (p *Poset) checkAndSaveEvent(e *inter.Event) error {
 		p.store.AddRoot(e)
 	}
 
+	if p.callback.OnForkDetected != nil {
+		if conflicting := p.vecClock.GetConflictingEvent(e.Hash()); conflicting != nil {
+			p.callback.OnForkDetected(&e.EventHeaderData, *conflicting)
+		}
+	}
+
 	return nil
 }
 
@@ -140,7 +169,7 @@ func (p *Poset) handleElection(root *inter.Event) {
 		}
 
 		// if we’re here, then this root has observed that lowest not decided frame is decided now
-		if p.onFrameDecided(decided.Frame, decided.Atropos) {
+		if p.onFrameDecided(decided.Frame, decided.Atropos, decided.Certificate) {
 			return
 		}
 	}
@@ -153,7 +182,7 @@ func (p *Poset) handleElection(root *inter.Event) {
 			break
 		}
 
-		if p.onFrameDecided(decided.Frame, decided.Atropos) {
+		if p.onFrameDecided(decided.Frame, decided.Atropos, decided.Certificate) {
 			return
 		}
 	}
@@ -197,15 +226,20 @@ func (p *Poset) processKnownRoots() *election.Res {
 
 // ProcessEvent takes event into processing.
 // Event order matter: parents first.
-// ProcessEvent is not safe for concurrent use.
+// ProcessEvent is not safe for concurrent use, except with PrecalcEvent.
 func (p *Poset) ProcessEvent(e *inter.Event) (err error) {
+	p.dagMu.Lock()
+	defer p.dagMu.Unlock()
+
+	pre := p.takePrecalc(e)
+
 	err = epochcheck.New(&p.dag, p).Validate(e)
 	if err != nil {
 		return
 	}
 	p.Log.Debug("Consensus: start event processing", "event", e)
 
-	err = p.checkAndSaveEvent(e)
+	err = p.checkAndSaveEvent(e, pre)
 	if err != nil {
 		return
 	}
@@ -214,25 +248,48 @@ func (p *Poset) ProcessEvent(e *inter.Event) (err error) {
 	return
 }
 
+// forklessCauseChunk is a number of roots which are checked by a single ForklessCauseMany call.
+const forklessCauseChunk = 16
+
 // forklessCausedByQuorumOn returns true if event is forkless caused by 2/3W roots on specified frame
-func (p *Poset) forklessCausedByQuorumOn(e *inter.Event, f idx.Frame) bool {
+func (p *Poset) forklessCausedByQuorumOn(forklessCause func(roots hash.Events) []bool, f idx.Frame) bool {
+	frameRoots := p.store.GetFrameRoots(f)
+	ids := make(hash.Events, len(frameRoots))
+	for i, it := range frameRoots {
+		ids[i] = it.ID
+	}
+
 	observedCounter := p.Validators.NewCounter()
-	// check "observing" prev roots only if called by creator, or if creator has marked that event as root
-	for _, it := range p.store.GetFrameRoots(f) {
-		if p.vecClock.ForklessCause(e.Hash(), it.ID) {
-			observedCounter.Count(it.Slot.Validator)
+	// check "observing" prev roots only if called by creator, or if creator has marked that event as root.
+	// Roots are checked by chunks, to not calculate the rest of them after 2/3W is reached
+	for from := 0; from < len(ids); from += forklessCauseChunk {
+		to := from + forklessCauseChunk
+		if to > len(ids) {
+			to = len(ids)
+		}
+		for i, observed := range forklessCause(ids[from:to]) {
+			if observed {
+				observedCounter.Count(frameRoots[from+i].Slot.Validator)
+			}
 		}
 		if observedCounter.HasQuorum() {
-			break
+			return true
 		}
 	}
-	return observedCounter.HasQuorum()
+	return false
 }
 
 // calcFrameIdx checks root-conditions for new event
 // and returns event's frame.
 // It is not safe for concurrent use.
 func (p *Poset) calcFrameIdx(e *inter.Event, checkOnly bool) (frame idx.Frame, isRoot bool) {
+	return p.calcFrameIdxBy(e, checkOnly, func(roots hash.Events) []bool {
+		return p.vecClock.ForklessCauseMany(e.Hash(), roots)
+	})
+}
+
+// calcFrameIdxBy is calcFrameIdx with custom forklessCause(e, roots) func.
+func (p *Poset) calcFrameIdxBy(e *inter.Event, checkOnly bool, forklessCause func(roots hash.Events) []bool) (frame idx.Frame, isRoot bool) {
 	if len(e.Parents) == 0 {
 		// special case for very first events in the epoch
 		return 1, true
@@ -266,7 +323,7 @@ func (p *Poset) calcFrameIdx(e *inter.Event, checkOnly bool) (frame idx.Frame, i
 		// by 2/3W+1 there. It's because of liveness with forks, when up to 1/3W of roots on any frame may become "invisible"
 		// for forklessCause relation (so if we skip frames, there's may be deadlock when frames cannot advance because there's
 		// less than 2/3W visible roots)
-		isRoot = frame == selfParentFrame+1 && (e.Frame <= 1 || p.forklessCausedByQuorumOn(e, e.Frame-1))
+		isRoot = frame == selfParentFrame+1 && (e.Frame <= 1 || p.forklessCausedByQuorumOn(forklessCause, e.Frame-1))
 		return selfParentFrame + 1, isRoot
 	}
 
@@ -274,7 +331,7 @@ func (p *Poset) calcFrameIdx(e *inter.Event, checkOnly bool) (frame idx.Frame, i
 	if e.SelfParent() == nil {
 		return 1, true
 	}
-	if p.forklessCausedByQuorumOn(e, selfParentFrame) {
+	if p.forklessCausedByQuorumOn(forklessCause, selfParentFrame) {
 		return selfParentFrame + 1, true
 	}
 	// Note: if we assign maxParentsFrame, it'll break the liveness for a case with forks, because there may be less
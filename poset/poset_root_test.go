@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 )
@@ -330,3 +331,40 @@ func codegen4PosetRandomRoot() {
 	fmt.Println(inter.DAGtoASCIIscheme(dag))
 }
 */
+
+func TestForklessCausedByQuorumOnStopsEarly(t *testing.T) {
+	assertar := assert.New(t)
+
+	nodes := inter.GenNodes(forklessCauseChunk * 4)
+	p, store, _ := FakePoset("", nodes)
+
+	for i, node := range nodes {
+		e := inter.NewEvent()
+		e.Creator = node
+		e.Frame = 1
+		e.Seq = idx.Event(i + 1)
+		e.RecacheHash()
+		store.AddRoot(e)
+	}
+
+	checked := 0
+	everyRoot := func(roots hash.Events) []bool {
+		checked += len(roots)
+		res := make([]bool, len(roots))
+		for i := range res {
+			res[i] = true
+		}
+		return res
+	}
+	noRoot := func(roots hash.Events) []bool {
+		checked += len(roots)
+		return make([]bool, len(roots))
+	}
+
+	assertar.True(p.forklessCausedByQuorumOn(everyRoot, 1))
+	assertar.Equal(forklessCauseChunk*3, checked)
+
+	checked = 0
+	assertar.False(p.forklessCausedByQuorumOn(noRoot, 1))
+	assertar.Equal(len(nodes), checked)
+}
@@ -73,6 +73,56 @@ func TestPoset(t *testing.T) {
 	})
 }
 
+// TestPosetForkDetected checks that forks are reported with the conflicting events.
+func TestPosetForkDetected(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	nodes := inter.GenNodes(5)
+	cheater := nodes[0]
+
+	poset, _, input := FakePoset("", nodes)
+
+	forks := make(map[hash.Event]hash.Event)
+	poset.callback.OnForkDetected = func(fork *inter.EventHeaderData, conflicting hash.Event) {
+		forks[fork.Hash()] = conflicting
+	}
+
+	// events with cheaters as parents may be rejected, their descendants are skipped
+	processed := make(map[hash.Event]*inter.Event)
+	inter.ForEachRandFork(nodes, []idx.StakerID{cheater}, 10, 3, 3, nil, inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			input.SetEvent(e)
+			if poset.ProcessEvent(e) != nil {
+				return
+			}
+			processed[e.Hash()] = e
+			assertar.NoError(
+				flushDb(poset, e.Hash()))
+		},
+		Build: func(e *inter.Event, name string) *inter.Event {
+			for _, p := range e.Parents {
+				if _, ok := processed[p]; !ok {
+					return nil
+				}
+			}
+			e.Epoch = 1
+			return poset.Prepare(e)
+		},
+	})
+
+	assertar.NotEmpty(forks)
+	for id, conflicting := range forks {
+		e, c := processed[id], processed[conflicting]
+		if assertar.NotNil(c) {
+			assertar.Equal(cheater, e.Creator)
+			assertar.Equal(e.Creator, c.Creator)
+			assertar.Equal(e.Seq, c.Seq)
+			assertar.NotEqual(id, conflicting)
+		}
+	}
+}
+
 // reorder events, but ancestors are before it's descendants.
 func reorder(events inter.Events) inter.Events {
 	unordered := make(inter.Events, len(events))
@@ -111,6 +161,14 @@ func compareResults(t *testing.T, posets []*ExtendedPoset) {
 					"block %d", b) {
 					break
 				}
+				// certificates may be different, as roots are processed in different order
+				for _, p := range []*ExtendedPoset{p0, p1} {
+					cert := p.GetBlockCertificate(b)
+					if assertar.NotNil(cert, "block %d", b) {
+						assertar.Equal(p.blocks[b].Atropos, cert.Atropos, "block %d", b)
+						assertar.NoError(cert.Check(p.Validators), "block %d", b)
+					}
+				}
 			}
 
 		}
@@ -0,0 +1,80 @@
+package poset
+
+import (
+	"github.com/hashicorp/golang-lru"
+
+	"github.com/Fantom-foundation/go-lachesis/eventcheck/epochcheck"
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/vector"
+)
+
+// precalcsCacheSize is a max number of precalculated events, which aren't processed yet.
+const precalcsCacheSize = 4096
+
+// precalcEvent is a consensus data of the event, calculated before the event processing.
+type precalcEvent struct {
+	epoch  idx.Epoch
+	vecs   *vector.Precalc
+	frame  idx.Frame
+	isRoot bool
+}
+
+func newPrecalcsCache() *lru.Cache {
+	cache, _ := lru.New(precalcsCacheSize)
+	return cache
+}
+
+// PrecalcEvent calculates vector clock and frame of the event ahead of ProcessEvent,
+// so ProcessEvent does only the rest of work. Event's parents must be processed already,
+// and the event must pass the epoch checks, otherwise the call is a no-op.
+// PrecalcEvent is safe for concurrent use. Precalcs run in parallel with each other, but they exclude
+// ProcessEvent, as both read DAG indexes which ProcessEvent modifies. So the speedup is from calculating
+// many events by many workers, rather than from overlapping the calculations with processing.
+func (p *Poset) PrecalcEvent(e *inter.Event) {
+	p.dagMu.RLock()
+	defer p.dagMu.RUnlock()
+
+	if p.vecClock == nil || e.Epoch != p.EpochN || len(e.Parents) == 0 {
+		return
+	}
+	if epochcheck.New(&p.dag, p).Validate(e) != nil {
+		return
+	}
+	for _, parent := range e.Parents {
+		if p.GetEventHeader(p.EpochN, parent) == nil {
+			return
+		}
+	}
+
+	vecs := p.vecClock.Precalc(&e.EventHeaderData)
+	if vecs == nil {
+		return
+	}
+	frame, isRoot := p.calcFrameIdxBy(e, true, func(roots hash.Events) []bool {
+		return vecs.ForklessCauseMany(p.vecClock, roots)
+	})
+
+	p.precalcs.Add(e.Hash(), &precalcEvent{
+		epoch:  p.EpochN,
+		vecs:   vecs,
+		frame:  frame,
+		isRoot: isRoot,
+	})
+}
+
+// takePrecalc returns the precalculated data of the event, if any.
+func (p *Poset) takePrecalc(e *inter.Event) *precalcEvent {
+	v, ok := p.precalcs.Get(e.Hash())
+	if !ok {
+		return nil
+	}
+	p.precalcs.Remove(e.Hash())
+
+	pre := v.(*precalcEvent)
+	if pre.epoch != p.EpochN {
+		return nil
+	}
+	return pre
+}
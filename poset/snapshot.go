@@ -0,0 +1,98 @@
+package poset
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+)
+
+// epochSnapshot is the consensus state at an epoch start.
+type epochSnapshot struct {
+	Epoch      EpochState
+	Checkpoint Checkpoint
+}
+
+var (
+	// ErrSnapshotMismatch is returned if the consensus state doesn't match to the last block of previous epoch.
+	ErrSnapshotMismatch = errors.New("snapshot doesn't match to the last block of sealed epoch")
+)
+
+// EpochSnapshot returns the consensus state at the current epoch start, RLP-encoded.
+// Returns nil if a block is decided in the current epoch already.
+func (p *Poset) EpochSnapshot() []byte {
+	p.dagMu.RLock()
+	defer p.dagMu.RUnlock()
+
+	if p.LastDecidedFrame != 0 {
+		return nil
+	}
+	raw, err := rlp.EncodeToBytes(&epochSnapshot{
+		Epoch:      p.EpochState,
+		Checkpoint: *p.Checkpoint,
+	})
+	if err != nil {
+		p.Log.Crit("Failed to encode rlp", "err", err)
+	}
+	return raw
+}
+
+// VerifyEpochSnapshot checks the consensus state against the last block of the sealed epoch, without applying it.
+func (p *Poset) VerifyEpochSnapshot(raw []byte, lastBlock *inter.Block) error {
+	_, err := decodeEpochSnapshot(raw, lastBlock)
+	return err
+}
+
+// ApplyEpochSnapshot moves the consensus state to the epoch start, instead of processing of the previous epochs.
+// The state is verified against the last block of the sealed epoch.
+func (p *Poset) ApplyEpochSnapshot(raw []byte, lastBlock *inter.Block) error {
+	s, err := decodeEpochSnapshot(raw, lastBlock)
+	if err != nil {
+		return err
+	}
+
+	p.dagMu.Lock()
+	defer p.dagMu.Unlock()
+
+	p.PrevEpoch = s.Epoch.PrevEpoch
+	p.setEpochValidators(s.Epoch.Validators, s.Epoch.EpochN)
+	*p.Checkpoint = s.Checkpoint
+
+	// commit
+	p.store.SetEpoch(&p.EpochState)
+	p.saveCheckpoint()
+
+	// reset internal epoch DB
+	p.store.RecreateEpochDb(p.EpochN)
+	p.precalcs.Purge()
+
+	// reset election & vectorindex to new epoch db
+	p.vecClock.Reset(p.Validators, p.store.epochTable.VectorIndex, func(id hash.Event) *inter.EventHeaderData {
+		return p.input.GetEventHeader(p.EpochN, id)
+	})
+	p.election.Reset(p.Validators, firstFrame)
+
+	p.Log.Info("Epoch snapshot is applied", "epoch", p.EpochN, "block", p.LastBlockN, "atropos", p.LastAtropos)
+	return nil
+}
+
+// decodeEpochSnapshot decodes the consensus state, and verifies it against the last block of the sealed epoch.
+func decodeEpochSnapshot(raw []byte, lastBlock *inter.Block) (*epochSnapshot, error) {
+	var s epochSnapshot
+	if err := rlp.DecodeBytes(raw, &s); err != nil {
+		return nil, err
+	}
+	if s.Epoch.Validators == nil || s.Epoch.Validators.Len() == 0 ||
+		s.Epoch.EpochN != s.Epoch.PrevEpoch.Epoch+1 ||
+		s.Epoch.PrevEpoch.LastAtropos != lastBlock.Atropos ||
+		s.Epoch.PrevEpoch.Time != lastBlock.Time ||
+		s.Checkpoint.LastDecidedFrame != 0 ||
+		s.Checkpoint.LastBlockN != lastBlock.Index ||
+		s.Checkpoint.LastAtropos != lastBlock.Atropos ||
+		s.Checkpoint.AppHash != s.Epoch.PrevEpoch.AppHash {
+		return nil, ErrSnapshotMismatch
+	}
+	return &s, nil
+}
@@ -0,0 +1,93 @@
+package poset
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+func TestEpochSnapshot(t *testing.T) {
+	logger.SetTestMode(t)
+	assertar := assert.New(t)
+
+	const maxEpochBlocks = 10
+	nodes := inter.GenNodes(5)
+
+	generator, _, input := FakePoset("", nodes)
+	synced, _, syncedInput := FakePoset("", nodes)
+	// seal epoch on decided frame == maxEpochBlocks
+	for _, p := range []*ExtendedPoset{generator, synced} {
+		applyBlock := p.callback.ApplyBlock
+		p.callback.ApplyBlock = func(block *inter.Block, decidedFrame idx.Frame, cheaters inter.Cheaters) (common.Hash, bool) {
+			h, _ := applyBlock(block, decidedFrame, cheaters)
+			return h, decidedFrame == maxEpochBlocks
+		}
+	}
+
+	var (
+		snapshot  []byte
+		lastBlock *inter.Block
+		epoch2    inter.Events
+	)
+	for epoch := idx.Epoch(1); epoch <= 2; epoch++ {
+		_ = inter.ForEachRandEvent(nodes, maxEpochBlocks*4, 3, rand.New(rand.NewSource(int64(epoch))), inter.ForEachEvent{
+			Process: func(e *inter.Event, name string) {
+				input.SetEvent(e)
+				assertar.NoError(generator.ProcessEvent(e))
+				assertar.NoError(flushDb(generator, e.Hash()))
+
+				if epoch == 2 {
+					epoch2 = append(epoch2, e)
+					// mid-epoch state isn't a snapshot
+					if generator.GetEpoch() == 2 && generator.LastDecidedFrame != 0 {
+						assertar.Nil(generator.EpochSnapshot())
+					}
+				} else if generator.GetEpoch() == 2 && snapshot == nil {
+					snapshot = generator.EpochSnapshot()
+					lastBlock = generator.blocks[generator.LastBlockN]
+				}
+			},
+			Build: func(e *inter.Event, name string) *inter.Event {
+				e.Epoch = epoch
+				return generator.Prepare(e)
+			},
+		})
+	}
+	if !assertar.NotNil(snapshot) || !assertar.NotNil(lastBlock) {
+		return
+	}
+	// the snapshot is verified against the last block
+	other := *lastBlock
+	other.Atropos = epoch2[0].Hash()
+	assertar.Equal(ErrSnapshotMismatch, synced.VerifyEpochSnapshot(snapshot, &other))
+	assertar.Equal(ErrSnapshotMismatch, synced.ApplyEpochSnapshot(snapshot, &other))
+	assertar.Equal(idx.Epoch(1), synced.GetEpoch())
+	assertar.NoError(synced.VerifyEpochSnapshot(snapshot, lastBlock))
+	assertar.Equal(idx.Epoch(1), synced.GetEpoch())
+
+	assertar.NoError(synced.ApplyEpochSnapshot(snapshot, lastBlock))
+	assertar.Equal(idx.Epoch(2), synced.GetEpoch())
+	assertar.Equal(lastBlock.Index, synced.LastBlockN)
+
+	// the synced node continues from the snapshot
+	for _, e := range epoch2 {
+		syncedInput.SetEvent(e)
+		assertar.NoError(synced.ProcessEvent(e))
+		assertar.NoError(flushDb(synced, e.Hash()))
+	}
+	assertar.Equal(generator.LastBlockN, synced.LastBlockN)
+	assertar.Equal(generator.Checkpoint, synced.Checkpoint)
+	for n := lastBlock.Index + 1; n <= generator.LastBlockN; n++ {
+		if !assertar.NotNil(synced.blocks[n]) {
+			break
+		}
+		assertar.Equal(generator.blocks[n].Atropos, synced.blocks[n].Atropos)
+		assertar.Equal(generator.blocks[n].Events, synced.blocks[n].Events)
+	}
+}
@@ -5,14 +5,16 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
-	lru "github.com/hashicorp/golang-lru"
 
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/metered"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 )
 
 // Store is a poset persistent storage working over parent key-value database.
@@ -26,11 +28,14 @@ type Store struct {
 		Epochs         kvdb.KeyValueStore `table:"e"`
 		ConfirmedEvent kvdb.KeyValueStore `table:"C"`
 		FrameInfos     kvdb.KeyValueStore `table:"f"`
+		Certificates   kvdb.KeyValueStore `table:"a"`
+
+		Migrations kvdb.KeyValueStore `table:"_"`
 	}
 
 	cache struct {
 		GenesisHash *common.Hash
-		FrameRoots  *lru.Cache `cache:"-"` // store by pointer
+		FrameRoots  *sharedcache.Cache `cache:"-"` // store by pointer
 	}
 
 	epochDb    kvdb.KeyValueStore
@@ -52,6 +57,7 @@ func NewStore(dbs *flushable.SyncedPool, cfg StoreConfig) *Store {
 	}
 
 	table.MigrateTables(&s.table, s.mainDb)
+	metered.WrapTables(&s.table, "poset")
 
 	s.initCache()
 
@@ -59,7 +65,9 @@ func NewStore(dbs *flushable.SyncedPool, cfg StoreConfig) *Store {
 }
 
 func (s *Store) initCache() {
-	s.cache.FrameRoots = s.makeCache(s.cfg.Roots)
+	s.cache.FrameRoots = s.makeCache("poset/frame_roots", func(v interface{}) int {
+		return 48 * cap(v.([]election.RootAndSlot))
+	})
 }
 
 // NewMemStore creates store over memory map.
@@ -79,6 +87,9 @@ func (s *Store) Close() {
 	}
 
 	table.MigrateTables(&s.table, nil)
+	if s.cache.FrameRoots != nil {
+		s.cache.FrameRoots.Purge() // release the shared cache budget
+	}
 	table.MigrateCaches(&s.cache, setnil)
 	table.MigrateTables(&s.epochTable, nil)
 	err := s.mainDb.Close()
@@ -115,6 +126,7 @@ func (s *Store) RecreateEpochDb(n idx.Epoch) {
 
 	s.epochDb = s.dbs.GetDb(name(n))
 	table.MigrateTables(&s.epochTable, s.epochDb)
+	metered.WrapTables(&s.epochTable, "poset_epoch")
 }
 
 func name(n idx.Epoch) string {
@@ -162,15 +174,11 @@ func (s *Store) has(table kvdb.KeyValueStore, key []byte) bool {
 	return res
 }
 
-func (s *Store) makeCache(size int) *lru.Cache {
-	if size <= 0 {
-		return nil
+func (s *Store) makeCache(name string, weight sharedcache.Weigher) *sharedcache.Cache {
+	if s.cfg.Cache == nil {
+		// caching is disabled
+		return sharedcache.New(0).NewCache(name, weight)
 	}
 
-	cache, err := lru.New(size)
-	if err != nil {
-		s.Log.Crit("Error create LRU cache", "err", err)
-		return nil
-	}
-	return cache
+	return s.cfg.Cache.NewCache(name, weight)
 }
@@ -0,0 +1,21 @@
+package poset
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/poset/election"
+)
+
+// SetBlockCertificate stores certificate of the block Atropos decision.
+func (s *Store) SetBlockCertificate(n idx.Block, cert *election.Certificate) {
+	s.set(s.table.Certificates, n.Bytes(), cert)
+}
+
+// GetBlockCertificate returns stored certificate of the block Atropos decision.
+func (s *Store) GetBlockCertificate(n idx.Block) *election.Certificate {
+	cert, exists := s.get(s.table.Certificates, n.Bytes(), &election.Certificate{}).(*election.Certificate)
+	if !exists {
+		return nil
+	}
+
+	return cert
+}
@@ -0,0 +1,11 @@
+package poset
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/utils/migration"
+)
+
+// Migrations returns the manager of store schema migrations.
+// NOTE: append new migrations to the end of the list only.
+func (s *Store) Migrations() *migration.Manager {
+	return migration.New("poset-main", s.table.Migrations)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/metered"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 )
 
@@ -38,6 +39,7 @@ func New(db kvdb.KeyValueStore) *Index {
 	tt.fetchMethod = tt.fetchAsync
 
 	table.MigrateTables(&tt.table, tt.db)
+	metered.WrapTables(&tt.table, "topicsdb")
 
 	return tt
 }
@@ -0,0 +1,132 @@
+// Package migration implements versioning of database schemas.
+// Schema version is a number of applied migrations from the ordered list.
+package migration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/go-lachesis/common/bigendian"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+	"github.com/Fantom-foundation/go-lachesis/logger"
+)
+
+var (
+	versionKey = []byte("v")
+	nameKey    = []byte("n")
+)
+
+var (
+	// ErrUnknownVersion is returned if database schema is newer than the node knows.
+	ErrUnknownVersion = errors.New("database schema is newer than known, upgrade the node")
+	// ErrMismatch is returned if applied migration differs from the known one.
+	ErrMismatch = errors.New("applied migrations don't match the known ones")
+)
+
+// Migration is a named step of database upgrade.
+type Migration struct {
+	Name string
+	Exec func() error
+}
+
+// Manager applies ordered list of migrations to a database
+// and persists schema version into the given table.
+type Manager struct {
+	name  string
+	table kvdb.KeyValueStore
+	list  []Migration
+
+	logger.Instance
+}
+
+// New makes migrations manager of named store.
+func New(name string, table kvdb.KeyValueStore, list ...Migration) *Manager {
+	m := &Manager{
+		name:     name,
+		table:    table,
+		list:     list,
+		Instance: logger.MakeInstance(),
+	}
+	m.SetName(name)
+
+	return m
+}
+
+// Name of the store.
+func (m *Manager) Name() string {
+	return m.name
+}
+
+// Latest returns schema version the manager is able to upgrade to.
+func (m *Manager) Latest() uint64 {
+	return uint64(len(m.list))
+}
+
+// Version returns current schema version of the database.
+func (m *Manager) Version() (uint64, error) {
+	buf, err := m.table.Get(versionKey)
+	if err != nil || buf == nil {
+		return 0, err
+	}
+	return bigendian.BytesToInt64(buf), nil
+}
+
+// Pending returns migrations which aren't applied yet.
+func (m *Manager) Pending() ([]Migration, error) {
+	ver, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	if ver > m.Latest() {
+		return nil, fmt.Errorf("%s: version %d, latest known %d: %w", m.name, ver, m.Latest(), ErrUnknownVersion)
+	}
+	if ver > 0 {
+		name, err := m.table.Get(nameKey)
+		if err != nil {
+			return nil, err
+		}
+		if last := m.list[ver-1].Name; string(name) != last {
+			return nil, fmt.Errorf("%s: version %d is '%s', expected '%s': %w", m.name, ver, string(name), last, ErrMismatch)
+		}
+	}
+
+	return m.list[ver:], nil
+}
+
+// Exec applies pending migrations one by one. In dry-run mode it just logs them.
+// It returns the number of (would be) applied migrations.
+func (m *Manager) Exec(dryRun bool) (int, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	ver := m.Latest() - uint64(len(pending))
+	for i, migration := range pending {
+		next := ver + uint64(i) + 1
+		if dryRun {
+			m.Log.Info("Pending migration", "version", next, "migration", migration.Name)
+			continue
+		}
+
+		m.Log.Info("Applying migration", "version", next, "migration", migration.Name)
+		err = migration.Exec()
+		if err != nil {
+			return i, fmt.Errorf("%s: migration '%s' failed: %v", m.name, migration.Name, err)
+		}
+		err = m.setVersion(next, migration.Name)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+func (m *Manager) setVersion(ver uint64, name string) error {
+	err := m.table.Put(nameKey, []byte(name))
+	if err != nil {
+		return err
+	}
+	return m.table.Put(versionKey, bigendian.Int64ToBytes(ver))
+}
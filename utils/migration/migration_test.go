@@ -0,0 +1,85 @@
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+)
+
+func TestManager(t *testing.T) {
+	assertar := assert.New(t)
+
+	db := memorydb.New()
+	var applied []string
+	step := func(name string) Migration {
+		return Migration{
+			Name: name,
+			Exec: func() error {
+				applied = append(applied, name)
+				return nil
+			},
+		}
+	}
+
+	// dry run
+	m := New("test", db, step("1"), step("2"))
+	n, err := m.Exec(true)
+	assertar.NoError(err)
+	assertar.Equal(2, n)
+	assertar.Empty(applied)
+	ver, err := m.Version()
+	assertar.NoError(err)
+	assertar.Equal(uint64(0), ver)
+
+	// apply all
+	n, err = m.Exec(false)
+	assertar.NoError(err)
+	assertar.Equal(2, n)
+	assertar.Equal([]string{"1", "2"}, applied)
+	ver, err = m.Version()
+	assertar.NoError(err)
+	assertar.Equal(uint64(2), ver)
+
+	// apply only new ones
+	m = New("test", db, step("1"), step("2"), step("3"))
+	n, err = m.Exec(false)
+	assertar.NoError(err)
+	assertar.Equal(1, n)
+	assertar.Equal([]string{"1", "2", "3"}, applied)
+
+	// newer schema
+	m = New("test", db, step("1"), step("2"))
+	_, err = m.Pending()
+	assertar.True(errors.Is(err, ErrUnknownVersion))
+	n, err = m.Exec(false)
+	assertar.Error(err)
+	assertar.Equal(0, n)
+
+	// another history
+	m = New("test", db, step("1"), step("2"), step("x"))
+	_, err = m.Exec(false)
+	assertar.True(errors.Is(err, ErrMismatch))
+	assertar.Equal([]string{"1", "2", "3"}, applied)
+}
+
+func TestManagerFailure(t *testing.T) {
+	assertar := assert.New(t)
+
+	db := memorydb.New()
+	fail := errors.New("fail")
+
+	m := New("test", db,
+		Migration{Name: "ok", Exec: func() error { return nil }},
+		Migration{Name: "fail", Exec: func() error { return fail }},
+	)
+	n, err := m.Exec(false)
+	assertar.Error(err)
+	assertar.Equal(1, n)
+
+	ver, err := m.Version()
+	assertar.NoError(err)
+	assertar.Equal(uint64(1), ver)
+}
@@ -0,0 +1,215 @@
+// Package sharedcache implements LRU caches which share a single memory budget.
+// The least recently used entry is evicted across all the caches of a pool,
+// so the memory is rebalanced towards the caches which are in use.
+package sharedcache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// entryOverhead is an estimated size of an entry bookkeeping and key, in bytes.
+const entryOverhead = 128
+
+// Weigher estimates memory size of a cached value, in bytes.
+type Weigher func(value interface{}) int
+
+// Fixed weigher for values of the same size.
+func Fixed(size int) Weigher {
+	return func(interface{}) int {
+		return size
+	}
+}
+
+// Pool is a memory budget shared by caches.
+type Pool struct {
+	budget int
+	used   int
+	lru    *list.List // of *entry, front is the most recently used
+
+	mu sync.Mutex
+}
+
+// Cache is an LRU cache, which memory is limited by pool budget.
+type Cache struct {
+	pool   *Pool
+	weight Weigher
+	items  map[interface{}]*list.Element
+	used   int
+
+	hitMeter  metrics.Meter
+	missMeter metrics.Meter
+	sizeGauge metrics.Gauge
+}
+
+type entry struct {
+	cache *Cache
+	key   interface{}
+	value interface{}
+	size  int
+}
+
+// testPool is shared by the caches of tests and in-memory stores.
+var testPool = New(64 * 1024 * 1024)
+
+// TestPool returns the pool which is shared by tests and in-memory stores.
+// Nodes use a pool of their own, configured by the caller.
+func TestPool() *Pool {
+	return testPool
+}
+
+// New pool with budget in bytes.
+func New(budget int) *Pool {
+	return &Pool{
+		budget: budget,
+		lru:    list.New(),
+	}
+}
+
+// Budget returns the pool budget in bytes.
+func (p *Pool) Budget() int {
+	return p.budget
+}
+
+// Used returns estimated memory used by all the caches, in bytes.
+func (p *Pool) Used() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.used
+}
+
+// NewCache makes named cache within the pool budget.
+// Metrics are named "cache/<name>/hit", "cache/<name>/miss" and "cache/<name>/size".
+func (p *Pool) NewCache(name string, weight Weigher) *Cache {
+	return &Cache{
+		pool:      p,
+		weight:    weight,
+		items:     make(map[interface{}]*list.Element),
+		hitMeter:  metrics.GetOrRegisterMeter("cache/"+name+"/hit", nil),
+		missMeter: metrics.GetOrRegisterMeter("cache/"+name+"/miss", nil),
+		sizeGauge: metrics.GetOrRegisterGauge("cache/"+name+"/size", nil),
+	}
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+// A value heavier than the whole budget isn't cached, and the key's previous value is removed.
+func (c *Cache) Add(key, value interface{}) (evicted bool) {
+	size := c.weight(value) + entryOverhead
+
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if size > p.budget {
+		// it would evict entries of all the caches, and then itself
+		if el, ok := c.items[key]; ok {
+			p.removeElement(el)
+		}
+		return false
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		c.resize(e, size-e.size)
+		e.value = value
+		p.lru.MoveToFront(el)
+	} else {
+		e := &entry{
+			cache: c,
+			key:   key,
+			value: value,
+		}
+		c.items[key] = p.lru.PushFront(e)
+		c.resize(e, size)
+	}
+
+	for p.used > p.budget && p.lru.Len() > 0 {
+		p.removeElement(p.lru.Back())
+		evicted = true
+	}
+
+	return evicted
+}
+
+// Get looks up a key's value from the cache.
+func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.missMeter.Mark(1)
+		return nil, false
+	}
+	c.hitMeter.Mark(1)
+	p.lru.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+// Contains checks if a key is in the cache, without updating the recent-ness.
+func (c *Cache) Contains(key interface{}) bool {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, ok := c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache.
+func (c *Cache) Remove(key interface{}) {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		p.removeElement(el)
+	}
+}
+
+// Purge is used to completely clear the cache.
+func (c *Cache) Purge() {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, el := range c.items {
+		p.removeElement(el)
+	}
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache) Len() int {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(c.items)
+}
+
+// Used returns estimated memory used by the cache, in bytes.
+func (c *Cache) Used() int {
+	p := c.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return c.used
+}
+
+func (c *Cache) resize(e *entry, diff int) {
+	e.size += diff
+	c.used += diff
+	c.pool.used += diff
+	c.sizeGauge.Update(int64(c.used))
+}
+
+func (p *Pool) removeElement(el *list.Element) {
+	e := el.Value.(*entry)
+	p.lru.Remove(el)
+	delete(e.cache.items, e.key)
+	e.cache.resize(e, -e.size)
+}
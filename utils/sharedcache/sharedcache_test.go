@@ -0,0 +1,102 @@
+package sharedcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBudget(t *testing.T) {
+	assertar := assert.New(t)
+
+	const size = 1000 - entryOverhead
+	pool := New(3 * 1000)
+	a := pool.NewCache("test/a", Fixed(size))
+	b := pool.NewCache("test/b", Fixed(size))
+
+	assertar.False(a.Add(1, "a1"))
+	assertar.False(a.Add(2, "a2"))
+	assertar.False(b.Add(1, "b1"))
+	assertar.Equal(3000, pool.Used())
+
+	// a1 is the least recently used across the pool
+	assertar.True(b.Add(2, "b2"))
+	assertar.False(a.Contains(1))
+	assertar.Equal(1, a.Len())
+	assertar.Equal(2, b.Len())
+	assertar.Equal(3000, pool.Used())
+
+	// a2 became the most recently used, so b1 is evicted
+	v, ok := a.Get(2)
+	assertar.True(ok)
+	assertar.Equal("a2", v)
+	assertar.True(a.Add(3, "a3"))
+	assertar.False(b.Contains(1))
+	assertar.True(a.Contains(2))
+	assertar.Equal(2000, a.Used())
+	assertar.Equal(1000, b.Used())
+
+	// update doesn't evict
+	assertar.False(a.Add(3, "a3'"))
+	v, ok = a.Get(3)
+	assertar.True(ok)
+	assertar.Equal("a3'", v)
+
+	a.Remove(2)
+	assertar.Equal(2000, pool.Used())
+	_, ok = a.Get(2)
+	assertar.False(ok)
+
+	b.Purge()
+	assertar.Equal(0, b.Len())
+	assertar.Equal(1000, pool.Used())
+}
+
+func TestCacheWeight(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := New(10 * 1000)
+	c := pool.NewCache("test/weight", func(v interface{}) int {
+		return len(v.([]byte))
+	})
+
+	// one big value displaces many small ones
+	for i := 0; i < 10; i++ {
+		c.Add(i, make([]byte, 1000-entryOverhead))
+	}
+	assertar.Equal(10, c.Len())
+	c.Add(10, make([]byte, 5000-entryOverhead))
+	assertar.Equal(6, c.Len())
+	assertar.True(c.Contains(10))
+	assertar.False(c.Contains(4))
+	assertar.True(c.Contains(5))
+
+	// value bigger than budget isn't kept, and doesn't evict the others
+	assertar.False(c.Add(11, make([]byte, 20*1000)))
+	assertar.Equal(6, c.Len())
+	assertar.Equal(10*1000, pool.Used())
+
+	// zero budget
+	c = New(0).NewCache("test/weight", Fixed(1))
+	assertar.False(c.Add(1, 1))
+	assertar.Equal(0, c.Len())
+}
+
+func TestCacheOverBudget(t *testing.T) {
+	assertar := assert.New(t)
+
+	pool := New(3 * 1000)
+	a := pool.NewCache("test/a", Fixed(1000-entryOverhead))
+	huge := pool.NewCache("test/huge", func(v interface{}) int {
+		return len(v.([]byte))
+	})
+
+	assertar.False(a.Add(1, "a1"))
+	assertar.False(huge.Add(1, []byte("small")))
+
+	// heavier than the budget, other caches are kept, the previous value is removed
+	assertar.False(huge.Add(1, make([]byte, 3*1000)))
+	assertar.True(a.Contains(1))
+	assertar.False(huge.Contains(1))
+	assertar.Equal(1000, pool.Used())
+}
@@ -0,0 +1,86 @@
+// Package tokenbucket implements a token bucket rate limiter.
+// The bucket may go into debt, so a request of unknown cost is allowed while any tokens are available.
+// Its estimated cost may be reserved before the request is done, and refunded after.
+package tokenbucket
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// Bucket is refilled with a constant rate, up to its capacity.
+type Bucket struct {
+	rate     float64 // tokens per second, 0 means no limit
+	capacity float64
+
+	tokens float64
+	last   mclock.AbsTime
+	clock  mclock.Clock
+
+	mu sync.Mutex
+}
+
+// New creates a full bucket. Zero rate means no limit.
+func New(rate, capacity uint64, clock mclock.Clock) *Bucket {
+	return &Bucket{
+		rate:     float64(rate),
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		last:     clock.Now(),
+		clock:    clock,
+	}
+}
+
+// Delay returns time until at least one token is available, or 0 if it's available already.
+func (b *Bucket) Delay() time.Duration {
+	if b.rate == 0 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Spend takes the tokens from the bucket, the bucket goes into debt if there isn't enough tokens.
+func (b *Bucket) Spend(n uint64) {
+	if b.rate == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.tokens -= float64(n)
+}
+
+// Refund returns the tokens into the bucket, e.g. if the spent tokens were reserved for a request
+// which turned out to be cheaper. The bucket capacity isn't exceeded.
+func (b *Bucket) Refund(n uint64) {
+	if b.rate == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.tokens += float64(n)
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+func (b *Bucket) refill() {
+	now := b.clock.Now()
+	b.tokens += time.Duration(now-b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
@@ -0,0 +1,62 @@
+package tokenbucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	assertar := assert.New(t)
+
+	clock := &mclock.Simulated{}
+	b := New(100, 200, clock)
+
+	// full bucket
+	assertar.Equal(time.Duration(0), b.Delay())
+	b.Spend(199)
+	assertar.Equal(time.Duration(0), b.Delay())
+
+	// debt
+	b.Spend(101)
+	assertar.Equal(time.Second+10*time.Millisecond, b.Delay())
+
+	// refill
+	clock.Run(500 * time.Millisecond)
+	assertar.Equal(510*time.Millisecond, b.Delay())
+	clock.Run(510 * time.Millisecond)
+	assertar.Equal(time.Duration(0), b.Delay())
+
+	// capacity isn't exceeded
+	clock.Run(time.Hour)
+	b.Spend(200)
+	assertar.Equal(10*time.Millisecond, b.Delay())
+}
+
+func TestBucketRefund(t *testing.T) {
+	assertar := assert.New(t)
+
+	clock := &mclock.Simulated{}
+	b := New(100, 200, clock)
+
+	// reserved tokens are returned
+	b.Spend(300)
+	assertar.Equal(time.Second+10*time.Millisecond, b.Delay())
+	b.Refund(101)
+	assertar.Equal(time.Duration(0), b.Delay())
+
+	// capacity isn't exceeded
+	b.Refund(1000)
+	b.Spend(200)
+	assertar.Equal(10*time.Millisecond, b.Delay())
+}
+
+func TestBucketUnlimited(t *testing.T) {
+	assertar := assert.New(t)
+
+	b := New(0, 0, mclock.System{})
+	b.Spend(1 << 40)
+	assertar.Equal(time.Duration(0), b.Delay())
+}
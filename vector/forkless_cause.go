@@ -3,6 +3,7 @@ package vector
 import (
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
 )
 
 type kv struct {
@@ -79,6 +80,109 @@ func (vi *Index) forklessCause(aID, bID hash.Event) bool {
 	return yes.HasQuorum()
 }
 
+// ForklessCauseMany calculates ForklessCause(a, b) for each of bIDs.
+// A's vector is decoded once for all the Bs, so it's cheaper than calling ForklessCause for each pair.
+func (vi *Index) ForklessCauseMany(aID hash.Event, bIDs hash.Events) []bool {
+	res := make([]bool, len(bIDs))
+
+	var batch *forklessCauseBatch
+	for i, bID := range bIDs {
+		if cached, ok := vi.cache.ForklessCause.Get(kv{aID, bID}); ok {
+			res[i] = cached.(bool)
+			continue
+		}
+
+		if batch == nil {
+			vi.initBranchesInfo()
+			a := vi.GetHighestBeforeSeq(aID)
+			if a == nil {
+				vi.Log.Crit("Event A not found", "event", aID.String())
+				return res
+			}
+			batch = newForklessCauseBatch(vi.validators, vi.bi, a)
+		}
+
+		// check A doesn't observe any forks from B
+		if vi.atLeastOneFork() && batch.forkObserved(vi.getEventBranchID(bID)) {
+			res[i] = false
+		} else {
+			b := vi.GetLowestAfterSeq(bID)
+			if b == nil {
+				vi.Log.Crit("Event B not found", "event", bID.String())
+				return res
+			}
+			res[i] = batch.forklessCause(b)
+		}
+		vi.cache.ForklessCause.Add(kv{aID, bID}, res[i])
+	}
+	return res
+}
+
+// forklessCauseBatch is a decoded HighestBefore vector of A,
+// which is used to calculate ForklessCause(A, B) against many Bs.
+type forklessCauseBatch struct {
+	bi *branchesInfo
+
+	seqs  []idx.Event // highest observed seq by branches, 0 if fork is observed
+	forks []bool      // fork is observed by branches
+
+	// flat stake accumulator
+	counted []bool
+	stakes  []pos.Stake
+	quorum  pos.Stake
+}
+
+func newForklessCauseBatch(validators *pos.Validators, bi *branchesInfo, a HighestBeforeSeq) *forklessCauseBatch {
+	batch := &forklessCauseBatch{
+		bi:      bi,
+		seqs:    make([]idx.Event, len(bi.BranchIDCreatorIdxs)),
+		forks:   make([]bool, len(bi.BranchIDCreatorIdxs)),
+		counted: make([]bool, validators.Len()),
+		stakes:  validators.SortedStakes(),
+		quorum:  validators.Quorum(),
+	}
+	for branchIDint := range bi.BranchIDCreatorIdxs {
+		seq := a.Get(idx.Validator(branchIDint))
+		batch.seqs[branchIDint] = seq.Seq
+		batch.forks[branchIDint] = seq.IsForkDetected()
+	}
+	return batch
+}
+
+// forkObserved returns true if A observes a fork of the branch's creator.
+func (batch *forklessCauseBatch) forkObserved(branchID idx.Validator) bool {
+	return int(branchID) < len(batch.forks) && batch.forks[branchID]
+}
+
+// seq returns the highest seq of the branch, observed by A.
+func (batch *forklessCauseBatch) seq(branchID idx.Validator) idx.Event {
+	if int(branchID) >= len(batch.seqs) {
+		return 0
+	}
+	return batch.seqs[branchID]
+}
+
+// forklessCause is the same as Index.forklessCause, but for the decoded A.
+// Forks should be checked by forkObserved before.
+func (batch *forklessCauseBatch) forklessCause(b LowestAfterSeq) bool {
+	for i := range batch.counted {
+		batch.counted[i] = false
+	}
+	sum := pos.Stake(0)
+	for branchIDint, creatorIdx := range batch.bi.BranchIDCreatorIdxs {
+		bLowestAfter := b.Get(idx.Validator(branchIDint))
+		// A's seq is 0 if fork is observed, so such branches aren't counted
+		if bLowestAfter <= batch.seqs[branchIDint] && bLowestAfter != 0 && !batch.counted[creatorIdx] {
+			batch.counted[creatorIdx] = true
+			sum += batch.stakes[creatorIdx]
+			if sum >= batch.quorum {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // NoCheaters excludes events which are observed by selfParents as cheaters.
 // Called by emitter to exclude cheater's events from potential parents list.
 func (vi *Index) NoCheaters(selfParent *hash.Event, options hash.Events) hash.Events {
@@ -558,6 +558,26 @@ func TestRandomForksSanity(t *testing.T) {
 			}
 		}
 	}
+
+	// every fork has a conflicting event with the same creator and seq
+	forks := make(map[idx.StakerID]int)
+	for id, e := range processed {
+		conflicting := vi.GetConflictingEvent(id)
+		if conflicting == nil {
+			continue
+		}
+		c := processed[*conflicting]
+		if assertar.NotNil(c) {
+			assertar.NotEqual(id, *conflicting)
+			assertar.Equal(e.Creator, c.Creator)
+			assertar.Equal(e.Seq, c.Seq)
+		}
+		forks[e.Creator]++
+	}
+	for n, node := range nodes {
+		isCheater := n < len(cheaters)
+		assertar.Equal(isCheater, forks[node] != 0, node)
+	}
 }
 
 func TestRandomForks(t *testing.T) {
@@ -798,3 +818,89 @@ func codegen4ForklessCausedStability() {
 	fmt.Printf("}\n")
 }
 */
+
+func TestForklessCauseMany(t *testing.T) {
+	assertar := assert.New(t)
+
+	nodes := inter.GenNodes(8)
+	cheaters := []idx.StakerID{nodes[0], nodes[1]}
+
+	validatorsBuilder := pos.NewBuilder()
+	for i, peer := range nodes {
+		validatorsBuilder.Set(peer, pos.Stake(1+i%3))
+	}
+	validators := validatorsBuilder.Build()
+
+	var ordered hash.Events
+	processed := make(map[hash.Event]*inter.EventHeaderData)
+	getEvent := func(id hash.Event) *inter.EventHeaderData {
+		return processed[id]
+	}
+
+	// separated indexes, to not share ForklessCause cache
+	ref := NewIndex(DefaultIndexConfig(), validators, memorydb.New(), getEvent)
+	vi := NewIndex(DefaultIndexConfig(), validators, memorydb.New(), getEvent)
+
+	_ = inter.ForEachRandFork(nodes, cheaters, 30, 4, 10, nil, inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			if _, ok := processed[e.Hash()]; ok {
+				return
+			}
+			processed[e.Hash()] = &e.EventHeaderData
+			ordered.Add(e.Hash())
+			ref.Add(&e.EventHeaderData)
+			vi.Add(&e.EventHeaderData)
+		},
+	})
+	ref.Flush()
+	vi.Flush()
+
+	for _, a := range ordered {
+		res := vi.ForklessCauseMany(a, ordered)
+		for i, b := range ordered {
+			assertar.Equal(ref.ForklessCause(a, b), res[i], "%s %s", a.String(), b.String())
+		}
+		// cached
+		assertar.Equal(res, vi.ForklessCauseMany(a, ordered))
+	}
+}
+
+func BenchmarkIndex_ForklessCauseMany(b *testing.B) {
+	logger.SetTestMode(b)
+
+	nodes := inter.GenNodes(100)
+	validators := pos.EqualStakeValidators(nodes, 1)
+
+	var ordered hash.Events
+	processed := make(map[hash.Event]*inter.EventHeaderData)
+	vi := NewIndex(DefaultIndexConfig(), validators, memorydb.New(), func(id hash.Event) *inter.EventHeaderData {
+		return processed[id]
+	})
+	_ = inter.ForEachRandEvent(nodes, 10, 10, nil, inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			processed[e.Hash()] = &e.EventHeaderData
+			ordered.Add(e.Hash())
+			vi.Add(&e.EventHeaderData)
+		},
+	})
+	vi.Flush()
+
+	// A is the last event, Bs are the first events of each validator (like roots of a frame)
+	a := ordered[len(ordered)-1]
+	bs := ordered[:len(nodes)]
+
+	b.Run("pairwise", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			vi.cache.ForklessCause.Purge()
+			for _, b := range bs {
+				vi.ForklessCause(a, b)
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			vi.cache.ForklessCause.Purge()
+			vi.ForklessCauseMany(a, bs)
+		}
+	})
+}
@@ -1,26 +1,20 @@
 package vector
 
 import (
-	"github.com/hashicorp/golang-lru"
-
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/idx"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
 	"github.com/Fantom-foundation/go-lachesis/kvdb"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/flushable"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/metered"
 	"github.com/Fantom-foundation/go-lachesis/kvdb/table"
 	"github.com/Fantom-foundation/go-lachesis/logger"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 )
 
-const (
-	forklessCauseCacheSize     = 5000
-	highestBeforeSeqCacheSize  = 1000
-	highestBeforeTimeCacheSize = 1000
-	lowestAfterSeqCacheSize    = 1000
-)
-
-// IndexCacheConfig - config for cache sizes of Index
+// IndexCacheConfig - config for cache sizes of Index.
+// NOTE: fields for config-file back compatibility, caches are limited by IndexConfig.Cache budget.
 type IndexCacheConfig struct {
 	ForklessCause     int `json:"forklessCause"`
 	HighestBeforeSeq  int `json:"highestBeforeSeq"`
@@ -31,6 +25,10 @@ type IndexCacheConfig struct {
 // IndexConfig - Index config (cache sizes)
 type IndexConfig struct {
 	Caches IndexCacheConfig `json:"cacheSizes"`
+
+	// Cache is a memory budget shared by the caches, it's injected by the caller.
+	// If nil, nothing is cached.
+	Cache *sharedcache.Pool `json:"-" toml:"-"`
 }
 
 // Index is a data to detect forkless-cause condition, calculate median timestamp, detect forks.
@@ -50,13 +48,16 @@ type Index struct {
 
 		EventBranch  kvdb.KeyValueStore `table:"b"`
 		BranchesInfo kvdb.KeyValueStore `table:"B"`
+
+		FirstBySeq kvdb.KeyValueStore `table:"q"`
+		Forks      kvdb.KeyValueStore `table:"f"`
 	}
 
 	cache struct {
-		HighestBeforeSeq  *lru.Cache
-		HighestBeforeTime *lru.Cache
-		LowestAfterSeq    *lru.Cache
-		ForklessCause     *lru.Cache
+		HighestBeforeSeq  *sharedcache.Cache
+		HighestBeforeTime *sharedcache.Cache
+		LowestAfterSeq    *sharedcache.Cache
+		ForklessCause     *sharedcache.Cache
 	}
 
 	cfg IndexConfig
@@ -66,14 +67,7 @@ type Index struct {
 
 // DefaultIndexConfig return default index config for tests
 func DefaultIndexConfig() IndexConfig {
-	return IndexConfig{
-		Caches: IndexCacheConfig{
-			ForklessCause:     forklessCauseCacheSize,
-			HighestBeforeSeq:  highestBeforeSeqCacheSize,
-			HighestBeforeTime: highestBeforeTimeCacheSize,
-			LowestAfterSeq:    lowestAfterSeqCacheSize,
-		},
-	}
+	return IndexConfig{}
 }
 
 // NewIndex creates Index instance.
@@ -82,10 +76,26 @@ func NewIndex(config IndexConfig, validators *pos.Validators, db kvdb.KeyValueSt
 		Instance: logger.MakeInstance(),
 		cfg:      config,
 	}
-	vi.cache.ForklessCause, _ = lru.New(vi.cfg.Caches.ForklessCause)
-	vi.cache.HighestBeforeSeq, _ = lru.New(vi.cfg.Caches.HighestBeforeSeq)
-	vi.cache.HighestBeforeTime, _ = lru.New(vi.cfg.Caches.HighestBeforeTime)
-	vi.cache.LowestAfterSeq, _ = lru.New(vi.cfg.Caches.LowestAfterSeq)
+	pool := vi.cfg.Cache
+	if pool == nil {
+		// caching is disabled
+		pool = sharedcache.New(0)
+	}
+	vectorSize := func(v interface{}) int {
+		switch vec := v.(type) {
+		case HighestBeforeSeq:
+			return len(vec)
+		case HighestBeforeTime:
+			return len(vec)
+		case LowestAfterSeq:
+			return len(vec)
+		}
+		return 0
+	}
+	vi.cache.ForklessCause = pool.NewCache("vector/forkless_cause", sharedcache.Fixed(1))
+	vi.cache.HighestBeforeSeq = pool.NewCache("vector/highest_before_seq", vectorSize)
+	vi.cache.HighestBeforeTime = pool.NewCache("vector/highest_before_time", vectorSize)
+	vi.cache.LowestAfterSeq = pool.NewCache("vector/lowest_after_seq", vectorSize)
 	vi.Reset(validators, db, getEvent)
 
 	return vi
@@ -103,6 +113,7 @@ func (vi *Index) Reset(validators *pos.Validators, db kvdb.KeyValueStore, getEve
 	vi.dropDependentCaches()
 
 	table.MigrateTables(&vi.table, vi.vecDb)
+	metered.WrapTables(&vi.table, "vector")
 }
 
 func (vi *Index) dropDependentCaches() {
@@ -119,7 +130,7 @@ func (vi *Index) Add(e *inter.EventHeaderData) {
 		return
 	}
 	vi.initBranchesInfo()
-	_ = vi.fillEventVectors(e)
+	_, _ = vi.fillEventVectors(e, nil)
 }
 
 // Flush writes vector clocks to persistent store.
@@ -172,6 +183,9 @@ func (vi *Index) fillGlobalBranchID(e *inter.EventHeaderData, meIdx idx.Validato
 	}
 
 	// if we're here, then new fork is observed (only globally), create new branchID due to a new fork
+	if conflicting := vi.getEventID(vi.table.FirstBySeq, creatorSeqKey(e)); conflicting != nil {
+		vi.setConflictingEvent(e.Hash(), *conflicting)
+	}
 	vi.bi.BranchIDLastSeq = append(vi.bi.BranchIDLastSeq, e.Seq)
 	vi.bi.BranchIDCreatorIdxs = append(vi.bi.BranchIDCreatorIdxs, meIdx)
 	newBranchID := idx.Validator(len(vi.bi.BranchIDLastSeq) - 1)
@@ -179,40 +193,85 @@ func (vi *Index) fillGlobalBranchID(e *inter.EventHeaderData, meIdx idx.Validato
 	return newBranchID
 }
 
-func (vi *Index) setForkDetected(beforeSeq HighestBeforeSeq, branchID idx.Validator) {
-	creatorIdx := vi.bi.BranchIDCreatorIdxs[branchID]
-	for _, branchID := range vi.bi.BranchIDByCreators[creatorIdx] {
+func (vi *Index) setForkDetected(bi *branchesInfo, beforeSeq HighestBeforeSeq, branchID idx.Validator) {
+	creatorIdx := bi.BranchIDCreatorIdxs[branchID]
+	for _, branchID := range bi.BranchIDByCreators[creatorIdx] {
 		beforeSeq.Set(idx.Validator(branchID), forkDetectedSeq)
 	}
 	// sanity check
-	if !vi.atLeastOneFork() {
+	if len(bi.BranchIDCreatorIdxs) <= vi.validators.Len() {
 		vi.Log.Crit("Not written the correct branches info (inconsistent DB)")
 	}
 }
 
 // fillEventVectors calculates (and stores) event's vectors, and updates LowestAfter of newly-observed events.
-func (vi *Index) fillEventVectors(e *inter.EventHeaderData) allVecs {
+// HighestBefore vectors are taken from pre if they are still actual.
+func (vi *Index) fillEventVectors(e *inter.EventHeaderData, pre *Precalc) (myVecs allVecs, precalcUsed bool) {
 	meIdx := vi.validatorIdxs[e.Creator]
-	myVecs := allVecs{
-		beforeSeq:  NewHighestBeforeSeq(len(vi.bi.BranchIDCreatorIdxs)),
-		beforeTime: NewHighestBeforeTime(len(vi.bi.BranchIDCreatorIdxs)),
-		after:      NewLowestAfterSeq(len(vi.bi.BranchIDCreatorIdxs)),
-	}
+	size := len(vi.bi.BranchIDCreatorIdxs)
 
 	meBranchID := vi.fillGlobalBranchID(e, meIdx)
 
+	if pre != nil && pre.branchID == meBranchID && pre.size == size && size == len(vi.bi.BranchIDCreatorIdxs) {
+		// no new branches since the precalculation
+		myVecs = pre.vecs
+		precalcUsed = true
+	} else {
+		var ok bool
+		myVecs, ok = vi.calcHighestBefore(vi.bi, e, meBranchID, size)
+		if !ok {
+			vi.Log.Crit("Processed out of order, parent not found (inconsistent DB)", "event", e.String())
+		}
+	}
+
+	// graph traversal starting from e, but excluding e
+	onWalk := func(walk hash.Event) (godeeper bool) {
+		wLowestAfterSeq := vi.GetLowestAfterSeq(walk)
+
+		godeeper = wLowestAfterSeq.Get(meBranchID) == 0
+		if !godeeper {
+			return
+		}
+
+		// update LowestAfter vector of the old event, because newly-connected event observes it
+		wLowestAfterSeq.Set(meBranchID, e.Seq)
+		vi.SetLowestAfter(walk, wLowestAfterSeq)
+
+		return
+	}
+	err := vi.dfsSubgraph(e, onWalk)
+	if err != nil {
+		vi.Log.Crit("VectorClock: Failed to walk subgraph", "err", err)
+	}
+
+	// store calculated vectors
+	vi.SetHighestBefore(e.Hash(), myVecs.beforeSeq, myVecs.beforeTime)
+	vi.SetLowestAfter(e.Hash(), myVecs.after)
+	vi.setEventBranchID(e.Hash(), meBranchID)
+	vi.setFirstBySeq(e)
+
+	return myVecs, precalcUsed
+}
+
+// calcHighestBefore calculates event's HighestBefore vectors (and LowestAfter of the event itself).
+// It doesn't change the index. Returns false if a parent isn't found.
+func (vi *Index) calcHighestBefore(bi *branchesInfo, e *inter.EventHeaderData, meBranchID idx.Validator, size int) (myVecs allVecs, ok bool) {
+	myVecs = allVecs{
+		beforeSeq:  NewHighestBeforeSeq(size),
+		beforeTime: NewHighestBeforeTime(size),
+		after:      NewLowestAfterSeq(size),
+	}
+
 	// pre-load parents into RAM for quick access
 	parentsVecs := make([]allVecs, len(e.Parents))
-	parentsBranchIDs := make([]idx.Validator, len(e.Parents))
 	for i, p := range e.Parents {
-		parentsBranchIDs[i] = vi.getEventBranchID(p)
 		parentsVecs[i] = allVecs{
 			beforeSeq:  vi.GetHighestBeforeSeq(p),
 			beforeTime: vi.GetHighestBeforeTime(p),
 			//after : vi.GetLowestAfterSeq(p), not needed
 		}
 		if parentsVecs[i].beforeSeq == nil {
-			vi.Log.Crit("Processed out of order, parent not found (inconsistent DB)", "parent", p.String())
+			return myVecs, false
 		}
 	}
 
@@ -223,7 +282,7 @@ func (vi *Index) fillEventVectors(e *inter.EventHeaderData) allVecs {
 
 	for _, pVec := range parentsVecs {
 		// calculate HighestBefore vector. Detect forks for a case when parent observes a fork
-		for branchID := idx.Validator(0); branchID < idx.Validator(len(vi.bi.BranchIDCreatorIdxs)); branchID++ {
+		for branchID := idx.Validator(0); branchID < idx.Validator(len(bi.BranchIDCreatorIdxs)); branchID++ {
 			hisSeq := pVec.beforeSeq.Get(branchID)
 			if hisSeq.Seq == 0 && !hisSeq.IsForkDetected() {
 				// hisSeq doesn't observe anything about this branchID
@@ -237,7 +296,7 @@ func (vi *Index) fillEventVectors(e *inter.EventHeaderData) allVecs {
 			}
 			if hisSeq.IsForkDetected() {
 				// set fork detected
-				vi.setForkDetected(myVecs.beforeSeq, branchID)
+				vi.setForkDetected(bi, myVecs.beforeSeq, branchID)
 			} else {
 				if mySeq.Seq == 0 || mySeq.MinSeq > hisSeq.MinSeq {
 					// take hisSeq.MinSeq
@@ -259,8 +318,8 @@ func (vi *Index) fillEventVectors(e *inter.EventHeaderData) allVecs {
 			// fork is already detected from the creator
 			continue
 		}
-		for _, branchID1 := range vi.bi.BranchIDByCreators[n] {
-			for _, branchID2 := range vi.bi.BranchIDByCreators[n] {
+		for _, branchID1 := range bi.BranchIDByCreators[n] {
+			for _, branchID2 := range bi.BranchIDByCreators[n] {
 				if branchID1 == branchID2 {
 					continue
 				}
@@ -272,7 +331,7 @@ func (vi *Index) fillEventVectors(e *inter.EventHeaderData) allVecs {
 					continue
 				}
 				if a.MinSeq <= b.Seq && b.MinSeq <= a.Seq {
-					vi.setForkDetected(myVecs.beforeSeq, n)
+					vi.setForkDetected(bi, myVecs.beforeSeq, n)
 					goto nextCreator
 				}
 			}
@@ -280,32 +339,7 @@ func (vi *Index) fillEventVectors(e *inter.EventHeaderData) allVecs {
 	nextCreator:
 	}
 
-	// graph traversal starting from e, but excluding e
-	onWalk := func(walk hash.Event) (godeeper bool) {
-		wLowestAfterSeq := vi.GetLowestAfterSeq(walk)
-
-		godeeper = wLowestAfterSeq.Get(meBranchID) == 0
-		if !godeeper {
-			return
-		}
-
-		// update LowestAfter vector of the old event, because newly-connected event observes it
-		wLowestAfterSeq.Set(meBranchID, e.Seq)
-		vi.SetLowestAfter(walk, wLowestAfterSeq)
-
-		return
-	}
-	err := vi.dfsSubgraph(e, onWalk)
-	if err != nil {
-		vi.Log.Crit("VectorClock: Failed to walk subgraph", "err", err)
-	}
-
-	// store calculated vectors
-	vi.SetHighestBefore(e.Hash(), myVecs.beforeSeq, myVecs.beforeTime)
-	vi.SetLowestAfter(e.Hash(), myVecs.after)
-	vi.setEventBranchID(e.Hash(), meBranchID)
-
-	return myVecs
+	return myVecs, true
 }
 
 // GetHighestBeforeAllBranches returns HighestBefore vector clock without branches, where branches are merged into one
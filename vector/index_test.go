@@ -3,9 +3,12 @@ package vector
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/Fantom-foundation/go-lachesis/hash"
 	"github.com/Fantom-foundation/go-lachesis/inter"
 	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/utils/sharedcache"
 
 	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
 )
@@ -66,3 +69,42 @@ func BenchmarkIndex_Add(b *testing.B) {
 		}
 	}
 }
+
+func TestIndexResetReleasesCache(t *testing.T) {
+	assertar := assert.New(t)
+
+	ordered := make([]*inter.Event, 0)
+	nodes, _, _ := inter.ASCIIschemeForEach(testASCIIScheme, inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			ordered = append(ordered, e)
+		},
+	})
+	validatorsBuilder := pos.NewBuilder()
+	for _, peer := range nodes {
+		validatorsBuilder.Set(peer, 1)
+	}
+	validators := validatorsBuilder.Build()
+	events := make(map[hash.Event]*inter.EventHeaderData)
+	getEvent := func(id hash.Event) *inter.EventHeaderData {
+		return events[id]
+	}
+	for _, e := range ordered {
+		events[e.Hash()] = &e.EventHeaderData
+	}
+
+	cfg := DefaultIndexConfig()
+	cfg.Cache = sharedcache.New(1024 * 1024)
+	vecClock := NewIndex(cfg, validators, memorydb.New(), getEvent)
+	for _, e := range ordered {
+		vecClock.Add(&e.EventHeaderData)
+	}
+	vecClock.Flush()
+	for _, e := range ordered {
+		vecClock.ForklessCause(ordered[len(ordered)-1].Hash(), e.Hash())
+	}
+	assertar.NotZero(cfg.Cache.Used())
+
+	// new epoch
+	vecClock.Reset(validators, memorydb.New(), getEvent)
+	assertar.Zero(cfg.Cache.Used())
+}
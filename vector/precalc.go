@@ -0,0 +1,125 @@
+package vector
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+)
+
+// Precalc is a vector clock of an event, calculated before the event is added into Index.
+// The heaviest part of Add (HighestBefore merging) and ForklessCause checks of the event
+// may be done by Precalc concurrently, while the cheap rest is done by AddPrecalc serially.
+type Precalc struct {
+	e        *inter.EventHeaderData
+	bi       *branchesInfo
+	branchID idx.Validator
+	size     int
+	vecs     allVecs
+}
+
+// Precalc calculates event's vector clock without adding the event.
+// Event's parents must be added already. Returns nil if the event cannot
+// be precalculated (e.g. it's a fork or a parent is unknown), then just use Add.
+// It doesn't change the Index, so it's safe to call concurrently
+// with other read-only methods, but not with Add, Flush and DropNotFlushed.
+func (vi *Index) Precalc(e *inter.EventHeaderData) *Precalc {
+	meIdx, ok := vi.validatorIdxs[e.Creator]
+	if !ok {
+		return nil
+	}
+	// use own copy of branches info, because vi.bi is a write cache
+	bi := vi.getBranchesInfo()
+	if bi == nil {
+		bi = newInitialBranchesInfo(vi.validators)
+	}
+	if len(bi.BranchIDLastSeq) != len(bi.BranchIDCreatorIdxs) || len(bi.BranchIDCreatorIdxs) < vi.validators.Len() {
+		return nil
+	}
+
+	// the same conditions as in fillGlobalBranchID
+	var branchID idx.Validator
+	if e.SelfParent() == nil {
+		if bi.BranchIDLastSeq[meIdx] != 0 {
+			return nil // new fork
+		}
+		branchID = meIdx
+	} else {
+		b := vi.getBytes(vi.table.EventBranch, *e.SelfParent())
+		if b == nil {
+			return nil
+		}
+		branchID = idx.BytesToValidator(b)
+		if int(branchID) >= len(bi.BranchIDLastSeq) || bi.BranchIDLastSeq[branchID]+1 != e.Seq {
+			return nil // new fork
+		}
+	}
+
+	size := len(bi.BranchIDCreatorIdxs)
+	vecs, ok := vi.calcHighestBefore(bi, e, branchID, size)
+	if !ok {
+		return nil
+	}
+
+	return &Precalc{
+		e:        e,
+		bi:       bi,
+		branchID: branchID,
+		size:     size,
+		vecs:     vecs,
+	}
+}
+
+// AddPrecalc adds the event with precalculated vector clock into Index.
+// If the precalculated data isn't actual anymore (i.e. a new fork is observed), it's recalculated
+// and false is returned.
+func (vi *Index) AddPrecalc(e *inter.EventHeaderData, pre *Precalc) (actual bool) {
+	// sanity check
+	if vi.GetHighestBeforeSeq(e.Hash()) != nil {
+		vi.Log.Warn("Event already exists", "event", e.Hash().String())
+		return false
+	}
+	if pre != nil && pre.e.Hash() != e.Hash() {
+		pre = nil
+	}
+	vi.initBranchesInfo()
+	_, actual = vi.fillEventVectors(e, pre)
+	return actual
+}
+
+// ForklessCause calculates ForklessCause(a, b) for the not added yet event a.
+// The result is the same as ForklessCause after AddPrecalc.
+// It is safe for concurrent use as Precalc is.
+func (pre *Precalc) ForklessCause(vi *Index, bID hash.Event) bool {
+	return pre.ForklessCauseMany(vi, hash.Events{bID})[0]
+}
+
+// ForklessCauseMany calculates ForklessCause(a, b) for each of bIDs for the not added yet event a.
+// It is safe for concurrent use as Precalc is.
+func (pre *Precalc) ForklessCauseMany(vi *Index, bIDs hash.Events) []bool {
+	res := make([]bool, len(bIDs))
+	batch := newForklessCauseBatch(vi.validators, pre.bi, pre.vecs.beforeSeq)
+	for i, bID := range bIDs {
+		bBranchBytes := vi.getBytes(vi.table.EventBranch, bID)
+		b := vi.GetLowestAfterSeq(bID)
+		if bBranchBytes == nil || b == nil {
+			vi.Log.Crit("Event B not found", "event", bID.String())
+			return res
+		}
+		bBranchID := idx.BytesToValidator(bBranchBytes)
+
+		// check A doesn't observe any forks from B
+		if batch.forkObserved(bBranchID) {
+			continue
+		}
+
+		// LowestAfter of B isn't updated by A yet. A updates it on its branch, if A observes B
+		bSeq := b.Get(bBranchID)
+		if bSeq != 0 && batch.seq(bBranchID) >= bSeq && b.Get(pre.branchID) == 0 {
+			b = append(LowestAfterSeq{}, b...)
+			b.Set(pre.branchID, pre.e.Seq)
+		}
+
+		res[i] = batch.forklessCause(b)
+	}
+	return res
+}
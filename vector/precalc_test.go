@@ -0,0 +1,100 @@
+package vector
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/inter/idx"
+	"github.com/Fantom-foundation/go-lachesis/inter/pos"
+	"github.com/Fantom-foundation/go-lachesis/kvdb/memorydb"
+)
+
+// TestPrecalc checks that Precalc+AddPrecalc gives the same results as Add,
+// when events are precalculated in batches of events with connected parents (as pipeline does).
+func TestPrecalc(t *testing.T) {
+	assertar := assert.New(t)
+
+	nodes := inter.GenNodes(7)
+	cheaters := []idx.StakerID{nodes[0], nodes[1]}
+	validators := pos.EqualStakeValidators(nodes, 1)
+
+	var (
+		all      []*inter.EventHeaderData
+		events   = make(map[hash.Event]*inter.EventHeaderData)
+		getEvent = func(id hash.Event) *inter.EventHeaderData {
+			return events[id]
+		}
+	)
+	_ = inter.ForEachRandFork(nodes, cheaters, 100, 4, 10, rand.New(rand.NewSource(0)), inter.ForEachEvent{
+		Process: func(e *inter.Event, name string) {
+			if _, ok := events[e.Hash()]; ok {
+				return
+			}
+			events[e.Hash()] = &e.EventHeaderData
+			all = append(all, &e.EventHeaderData)
+		},
+	})
+
+	ref := NewIndex(DefaultIndexConfig(), validators, memorydb.New(), getEvent)
+	vi := NewIndex(DefaultIndexConfig(), validators, memorydb.New(), getEvent)
+
+	var (
+		added   = hash.EventsSet{}
+		ordered hash.Events
+	)
+	for len(added) < len(all) {
+		// batch of events with connected parents
+		var batch []*inter.EventHeaderData
+		for _, e := range all {
+			if added.Contains(e.Hash()) {
+				continue
+			}
+			ready := true
+			for _, p := range e.Parents {
+				ready = ready && added.Contains(p)
+			}
+			if ready {
+				batch = append(batch, e)
+			}
+		}
+
+		precalcs := make([]*Precalc, len(batch))
+		for i, e := range batch {
+			precalcs[i] = vi.Precalc(e)
+		}
+
+		for i, e := range batch {
+			ref.Add(e)
+			ref.Flush()
+			ref.DropNotFlushed()
+
+			pre := precalcs[i]
+			if pre != nil {
+				for _, b := range ordered {
+					assertar.Equal(ref.ForklessCause(e.Hash(), b), pre.ForklessCause(vi, b), "forkless cause before adding")
+				}
+			}
+
+			vi.AddPrecalc(e, pre)
+			vi.Flush()
+			vi.DropNotFlushed()
+
+			assertar.Equal(ref.GetHighestBeforeSeq(e.Hash()), vi.GetHighestBeforeSeq(e.Hash()))
+			assertar.Equal(ref.GetHighestBeforeTime(e.Hash()), vi.GetHighestBeforeTime(e.Hash()))
+
+			added.Add(e.Hash())
+			ordered.Add(e.Hash())
+		}
+	}
+
+	for _, id := range ordered {
+		assertar.Equal(ref.GetLowestAfterSeq(id), vi.GetLowestAfterSeq(id))
+		for _, b := range ordered {
+			assertar.Equal(ref.ForklessCause(id, b), vi.ForklessCause(id, b))
+		}
+	}
+}
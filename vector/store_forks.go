@@ -0,0 +1,45 @@
+package vector
+
+import (
+	"github.com/Fantom-foundation/go-lachesis/hash"
+	"github.com/Fantom-foundation/go-lachesis/inter"
+	"github.com/Fantom-foundation/go-lachesis/kvdb"
+)
+
+func creatorSeqKey(e *inter.EventHeaderData) []byte {
+	return append(e.Creator.Bytes(), e.Seq.Bytes()...)
+}
+
+func (vi *Index) getEventID(table kvdb.KeyValueStore, key []byte) *hash.Event {
+	b, err := table.Get(key)
+	if err != nil {
+		vi.Log.Crit("Failed to get key-value", "err", err)
+	}
+	if b == nil {
+		return nil
+	}
+	id := hash.BytesToEvent(b)
+	return &id
+}
+
+// setFirstBySeq stores the event if it's the first seen event with such creator and seq
+func (vi *Index) setFirstBySeq(e *inter.EventHeaderData) {
+	key := creatorSeqKey(e)
+	if vi.getEventID(vi.table.FirstBySeq, key) != nil {
+		return
+	}
+	err := vi.table.FirstBySeq.Put(key, e.Hash().Bytes())
+	if err != nil {
+		vi.Log.Crit("Failed to put key-value", "err", err)
+	}
+}
+
+// setConflictingEvent stores the event which has the same creator and seq as the fork event
+func (vi *Index) setConflictingEvent(fork hash.Event, conflicting hash.Event) {
+	vi.setBytes(vi.table.Forks, fork, conflicting.Bytes())
+}
+
+// GetConflictingEvent returns an event with the same creator and seq, if the event has created a new branch (i.e. it's a fork).
+func (vi *Index) GetConflictingEvent(id hash.Event) *hash.Event {
+	return vi.getEventID(vi.table.Forks, id.Bytes())
+}